@@ -0,0 +1,151 @@
+// Package lambdacontext provides the Lambda context,
+// populated from the Cloud Run, and Cloud Functions, environment.
+//
+// Package variables describe the function (the service, on Cloud Run),
+// and Handler attaches a LambdaContext to each request,
+// with the request ID, and the deadline to handle it.
+package lambdacontext
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// FunctionName is the name of the function, or service.
+	FunctionName string
+	// FunctionVersion is the version of the function, or the service revision.
+	FunctionVersion string
+	// MemoryLimitInMB is the memory available to the function.
+	MemoryLimitInMB int
+	// LogGroupName is the log group: the function name.
+	LogGroupName string
+	// LogStreamName is the log stream: the function version.
+	LogStreamName string
+	// Timeout is how long the function can take to handle a request.
+	Timeout time.Duration
+)
+
+func init() {
+	FunctionName = getenv("K_SERVICE", "FUNCTION_TARGET", "FUNCTION_NAME")
+	FunctionVersion = getenv("K_REVISION", "X_GOOGLE_FUNCTION_VERSION")
+	LogGroupName = FunctionName
+	LogStreamName = FunctionVersion
+
+	if mb, err := strconv.Atoi(getenv("FUNCTION_MEMORY_MB", "X_GOOGLE_FUNCTION_MEMORY_MB")); err == nil {
+		MemoryLimitInMB = mb
+	} else {
+		MemoryLimitInMB = cgroupMemoryMB()
+	}
+	if sec, err := strconv.Atoi(getenv("FUNCTION_TIMEOUT_SEC", "X_GOOGLE_FUNCTION_TIMEOUT_SEC")); err == nil {
+		Timeout = time.Duration(sec) * time.Second
+	}
+}
+
+func getenv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// cgroupMemoryMB reads the memory limit of the container.
+func cgroupMemoryMB() int {
+	for _, f := range []string{
+		"/sys/fs/cgroup/memory.max",
+		"/sys/fs/cgroup/memory/memory.limit_in_bytes",
+	} {
+		buf, err := ioutil.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(string(buf)), 10, 64); err == nil {
+			return int(n >> 20)
+		}
+	}
+	return 0
+}
+
+// CognitoIdentity is the identity of the caller, always empty.
+type CognitoIdentity struct {
+	CognitoIdentityID     string
+	CognitoIdentityPoolID string
+}
+
+// ClientContext is the context of the client, always empty.
+type ClientContext struct {
+	Client ClientApplication
+	Env    map[string]string `json:"env"`
+	Custom map[string]string `json:"custom"`
+}
+
+// ClientApplication is the client application, always empty.
+type ClientApplication struct {
+	InstallationID string `json:"installation_id"`
+	AppTitle       string `json:"app_title"`
+	AppVersionCode string `json:"app_version_code"`
+	AppPackageName string `json:"app_package_name"`
+}
+
+// LambdaContext is the context of a request.
+type LambdaContext struct {
+	AwsRequestID       string
+	InvokedFunctionArn string
+	Identity           CognitoIdentity
+	ClientContext      ClientContext
+}
+
+type contextKey struct{}
+
+// NewContext returns a context with lc attached.
+func NewContext(parent context.Context, lc *LambdaContext) context.Context {
+	return context.WithValue(parent, contextKey{}, lc)
+}
+
+// FromContext returns the LambdaContext attached to ctx.
+func FromContext(ctx context.Context) (*LambdaContext, bool) {
+	lc, ok := ctx.Value(contextKey{}).(*LambdaContext)
+	return lc, ok
+}
+
+// Handler attaches a LambdaContext to each request handled by h,
+// and limits the request's context to Timeout, if set.
+//
+// The request ID is the Cloud Functions execution ID,
+// or the Cloud Trace trace ID.
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(r.Context(), &LambdaContext{AwsRequestID: requestID(r)})
+		if Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, Timeout)
+			defer cancel()
+		}
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func requestID(r *http.Request) string {
+	if id := r.Header.Get("Function-Execution-Id"); id != "" {
+		return id
+	}
+	// traceparent: version-traceid-spanid-flags
+	if tp := strings.Split(r.Header.Get("traceparent"), "-"); len(tp) == 4 {
+		return tp[1]
+	}
+	// X-Cloud-Trace-Context: traceid/spanid;o=flags
+	if tc := r.Header.Get("X-Cloud-Trace-Context"); tc != "" {
+		if i := strings.IndexByte(tc, '/'); i > 0 {
+			return tc[:i]
+		}
+		return tc
+	}
+	return ""
+}
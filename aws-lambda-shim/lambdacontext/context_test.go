@@ -0,0 +1,99 @@
+package lambdacontext
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   string
+	}{
+		{"execution", map[string]string{
+			"Function-Execution-Id": "exec",
+			"traceparent":           "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		}, "exec"},
+		{"traceparent", map[string]string{
+			"traceparent":           "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"X-Cloud-Trace-Context": "other/1;o=1",
+		}, "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"cloud trace", map[string]string{
+			"X-Cloud-Trace-Context": "105445aa7843bc8bf206b12000100000/1;o=1",
+		}, "105445aa7843bc8bf206b12000100000"},
+		{"cloud trace only", map[string]string{
+			"X-Cloud-Trace-Context": "105445aa7843bc8bf206b12000100000",
+		}, "105445aa7843bc8bf206b12000100000"},
+		{"none", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *LambdaContext
+			h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got == nil {
+				t.Fatal("FromContext() = nil")
+			}
+			if got.AwsRequestID != tt.want {
+				t.Errorf("AwsRequestID = %q, want %q", got.AwsRequestID, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_timeout(t *testing.T) {
+	defer func(timeout time.Duration) { Timeout = timeout }(Timeout)
+
+	for _, timeout := range []time.Duration{0, time.Minute} {
+		Timeout = timeout
+
+		var deadline time.Time
+		var ok bool
+		h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok = r.Context().Deadline()
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		if ok != (timeout > 0) {
+			t.Errorf("Timeout = %v: Deadline() = %v, %v", timeout, deadline, ok)
+		}
+		if ok && time.Until(deadline) > timeout {
+			t.Errorf("Timeout = %v: Deadline() = %v", timeout, deadline)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("FromContext() found a LambdaContext in an empty context")
+	}
+	lc := &LambdaContext{AwsRequestID: "id"}
+	if got, ok := FromContext(NewContext(context.Background(), lc)); !ok || got != lc {
+		t.Errorf("FromContext() = %v, %v", got, ok)
+	}
+}
+
+func TestGetenv(t *testing.T) {
+	os.Setenv("LAMBDACONTEXT_TEST_B", "b")
+	defer os.Unsetenv("LAMBDACONTEXT_TEST_B")
+
+	if got := getenv("LAMBDACONTEXT_TEST_A", "LAMBDACONTEXT_TEST_B"); got != "b" {
+		t.Errorf("getenv() = %q, want %q", got, "b")
+	}
+	if got := getenv("LAMBDACONTEXT_TEST_A"); got != "" {
+		t.Errorf("getenv() = %q, want empty", got)
+	}
+}
@@ -0,0 +1,39 @@
+// Package awserr represents API errors, like the AWS SDK does.
+package awserr
+
+// An Error is an API error, with a code, and a message.
+type Error interface {
+	error
+
+	// Code returns the error code (for example, ResourceNotFoundException).
+	Code() string
+
+	// Message returns the error message.
+	Message() string
+
+	// OrigErr returns the underlying error, if any.
+	OrigErr() error
+}
+
+// New returns an Error.
+func New(code, message string, origErr error) Error {
+	return baseError{code, message, origErr}
+}
+
+type baseError struct {
+	code    string
+	message string
+	origErr error
+}
+
+func (e baseError) Code() string    { return e.code }
+func (e baseError) Message() string { return e.message }
+func (e baseError) OrigErr() error  { return e.origErr }
+
+func (e baseError) Error() string {
+	msg := e.code + ": " + e.message
+	if e.origErr != nil {
+		msg += "\ncaused by: " + e.origErr.Error()
+	}
+	return msg
+}
@@ -0,0 +1,22 @@
+// Package client provides service clients with their configuration.
+package client
+
+import "github.com/aws/aws-sdk-go/aws"
+
+// A ConfigProvider provides the configuration of service clients,
+// it's implemented by session.Session.
+type ConfigProvider interface {
+	ClientConfig(serviceName string, cfgs ...*aws.Config) Config
+}
+
+// Config is the configuration of a service client.
+type Config struct {
+	Config *aws.Config
+
+	// Endpoint is the Google Cloud API endpoint of the service.
+	Endpoint string
+
+	// Token returns the Google Cloud access token
+	// that authorizes calls to the service.
+	Token func() (string, error)
+}
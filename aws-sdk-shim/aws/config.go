@@ -0,0 +1,66 @@
+package aws
+
+import "github.com/aws/aws-sdk-go/aws/credentials"
+
+// A Config configures sessions, and service clients.
+type Config struct {
+	// Region is the AWS region.
+	Region *string
+
+	// Credentials are AWS credentials.
+	// Shimmed services authorize with Google Cloud credentials instead.
+	Credentials *credentials.Credentials
+
+	// Endpoint overrides the Google Cloud API endpoint of shimmed services
+	// (the base URL, including the API version).
+	Endpoint *string
+}
+
+// NewConfig returns an empty Config.
+func NewConfig() *Config {
+	return &Config{}
+}
+
+// WithRegion sets the region, and returns c for chaining.
+func (c *Config) WithRegion(region string) *Config {
+	c.Region = &region
+	return c
+}
+
+// WithCredentials sets the credentials, and returns c for chaining.
+func (c *Config) WithCredentials(creds *credentials.Credentials) *Config {
+	c.Credentials = creds
+	return c
+}
+
+// WithEndpoint sets the endpoint, and returns c for chaining.
+func (c *Config) WithEndpoint(endpoint string) *Config {
+	c.Endpoint = &endpoint
+	return c
+}
+
+// MergeIn merges the set fields of cfgs into c.
+func (c *Config) MergeIn(cfgs ...*Config) {
+	for _, other := range cfgs {
+		if other == nil {
+			continue
+		}
+		if other.Region != nil {
+			c.Region = other.Region
+		}
+		if other.Credentials != nil {
+			c.Credentials = other.Credentials
+		}
+		if other.Endpoint != nil {
+			c.Endpoint = other.Endpoint
+		}
+	}
+}
+
+// Copy returns a copy of c, with cfgs merged in.
+func (c *Config) Copy(cfgs ...*Config) *Config {
+	dst := &Config{}
+	dst.MergeIn(c)
+	dst.MergeIn(cfgs...)
+	return dst
+}
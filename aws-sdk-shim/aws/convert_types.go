@@ -0,0 +1,66 @@
+// Package aws provides helpers for the pointer fields of API inputs, and outputs.
+package aws
+
+import "time"
+
+// String returns a pointer to v.
+func String(v string) *string { return &v }
+
+// StringValue returns the value of p, or the zero value if p is nil.
+func StringValue(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+// Int64 returns a pointer to v.
+func Int64(v int64) *int64 { return &v }
+
+// Int64Value returns the value of p, or the zero value if p is nil.
+func Int64Value(p *int64) int64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// Bool returns a pointer to v.
+func Bool(v bool) *bool { return &v }
+
+// BoolValue returns the value of p, or the zero value if p is nil.
+func BoolValue(p *bool) bool {
+	if p == nil {
+		return false
+	}
+	return *p
+}
+
+// Time returns a pointer to v.
+func Time(v time.Time) *time.Time { return &v }
+
+// TimeValue returns the value of p, or the zero value if p is nil.
+func TimeValue(p *time.Time) time.Time {
+	if p == nil {
+		return time.Time{}
+	}
+	return *p
+}
+
+// StringMap returns a map of pointers to the values of m.
+func StringMap(m map[string]string) map[string]*string {
+	p := make(map[string]*string, len(m))
+	for k, v := range m {
+		p[k] = String(v)
+	}
+	return p
+}
+
+// StringValueMap returns a map of the values of m.
+func StringValueMap(m map[string]*string) map[string]string {
+	v := make(map[string]string, len(m))
+	for k, p := range m {
+		v[k] = StringValue(p)
+	}
+	return v
+}
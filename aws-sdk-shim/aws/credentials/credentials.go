@@ -0,0 +1,111 @@
+// Package credentials provides AWS credentials derived from
+// the Google Cloud environment, so AWS SDK calls from Cloud Run,
+// Cloud Functions, or Compute Engine, work without long-lived keys.
+package credentials
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// A Value is a set of AWS credentials.
+type Value struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// ProviderName is the name of the provider that retrieved the credentials.
+	ProviderName string
+}
+
+// HasKeys reports whether the credentials have keys.
+func (v Value) HasKeys() bool {
+	return v.AccessKeyID != "" && v.SecretAccessKey != ""
+}
+
+// A Provider retrieves credentials.
+type Provider interface {
+	// Retrieve returns credentials, or an error.
+	Retrieve() (Value, error)
+
+	// IsExpired reports whether the credentials need to be retrieved again.
+	IsExpired() bool
+}
+
+// Expiry tracks when credentials expire.
+// Embed it in providers of expiring credentials.
+type Expiry struct {
+	expiration time.Time
+}
+
+// SetExpiration sets when the credentials expire,
+// window earlier, so they're refreshed before they're rejected.
+func (e *Expiry) SetExpiration(expiration time.Time, window time.Duration) {
+	e.expiration = expiration.Add(-window)
+}
+
+// IsExpired reports whether the credentials have expired.
+func (e *Expiry) IsExpired() bool {
+	return !time.Now().Before(e.expiration)
+}
+
+// ExpiresAt returns when the credentials expire.
+func (e *Expiry) ExpiresAt() time.Time {
+	return e.expiration
+}
+
+// Credentials caches the credentials retrieved by a Provider,
+// retrieving them again when they expire.
+// It's safe for concurrent use.
+type Credentials struct {
+	mtx      sync.Mutex
+	provider Provider
+	value    Value
+	valid    bool
+}
+
+// NewCredentials returns Credentials that cache the credentials
+// retrieved by provider.
+func NewCredentials(provider Provider) *Credentials {
+	return &Credentials{provider: provider}
+}
+
+// Provider returns the provider of the credentials.
+func (c *Credentials) Provider() Provider {
+	return c.provider
+}
+
+// Get returns the cached credentials,
+// retrieving them if needed.
+func (c *Credentials) Get() (Value, error) {
+	if c == nil {
+		return Value{}, errors.New("credentials: nil credentials")
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.valid || c.provider.IsExpired() {
+		v, err := c.provider.Retrieve()
+		if err != nil {
+			return Value{}, err
+		}
+		c.value, c.valid = v, true
+	}
+	return c.value, nil
+}
+
+// Expire forces the credentials to be retrieved again.
+func (c *Credentials) Expire() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.valid = false
+}
+
+// IsExpired reports whether the credentials need to be retrieved again.
+func (c *Credentials) IsExpired() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return !c.valid || c.provider.IsExpired()
+}
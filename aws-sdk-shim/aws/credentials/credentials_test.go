@@ -0,0 +1,165 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/internal/gcptest"
+)
+
+func TestEnvProvider(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "id")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	os.Setenv("AWS_SESSION_TOKEN", "token")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	defer os.Unsetenv("AWS_SESSION_TOKEN")
+
+	c := NewEnvCredentials()
+	if !c.IsExpired() {
+		t.Error("IsExpired() = false before Get")
+	}
+	v, err := c.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Value{"id", "secret", "token", EnvProviderName}
+	if v != want {
+		t.Errorf("Get() = %v, want %v", v, want)
+	}
+	if c.IsExpired() {
+		t.Error("IsExpired() = true after Get")
+	}
+
+	os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	if _, err := NewEnvCredentials().Get(); err == nil {
+		t.Error("Get() without a secret key succeeded")
+	}
+}
+
+func TestWebIdentityProvider(t *testing.T) {
+	metadata := gcptest.NewMetadataServer(nil)
+	defer metadata.Close()
+
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if r.URL.RawQuery != "" {
+			t.Errorf("query = %q, want none", r.URL.RawQuery)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		r.ParseForm()
+		want := map[string]string{
+			"Action":           "AssumeRoleWithWebIdentity",
+			"RoleArn":          "arn:aws:iam::123456789012:role/test",
+			"RoleSessionName":  gcptest.Defaults["instance/id"],
+			"WebIdentityToken": "id-token:sts.amazonaws.com",
+			"DurationSeconds":  "900",
+		}
+		for k, v := range want {
+			if got := r.PostForm.Get(k); got != v {
+				t.Errorf("%s = %q, want %q", k, got, v)
+			}
+		}
+		w.Write([]byte(`<AssumeRoleWithWebIdentityResponse>
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>id</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>` + expiration.Format(time.RFC3339) + `</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`))
+	}))
+	defer sts.Close()
+	defer func(endpoint string) { stsEndpoint = endpoint }(stsEndpoint)
+	stsEndpoint = sts.URL + "/"
+
+	p := &WebIdentityProvider{
+		RoleARN:  "arn:aws:iam::123456789012:role/test",
+		Duration: 15 * time.Minute,
+	}
+	v, err := NewCredentials(p).Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Value{"id", "secret", "token", WebIdentityProviderName}
+	if v != want {
+		t.Errorf("Get() = %v, want %v", v, want)
+	}
+	if got := p.ExpiresAt(); !got.Equal(expiration.Add(-time.Minute)) {
+		t.Errorf("ExpiresAt() = %v, want %v", got, expiration.Add(-time.Minute))
+	}
+}
+
+func TestWebIdentityProvider_error(t *testing.T) {
+	metadata := gcptest.NewMetadataServer(nil)
+	defer metadata.Close()
+
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<ErrorResponse><Error><Code>AccessDenied</Code><Message>denied</Message></Error></ErrorResponse>`))
+	}))
+	defer sts.Close()
+	defer func(endpoint string) { stsEndpoint = endpoint }(stsEndpoint)
+	stsEndpoint = sts.URL + "/"
+
+	_, err := NewWebIdentityCredentials("arn:aws:iam::123456789012:role/test").Get()
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied: denied") {
+		t.Errorf("Get() = %v, want AccessDenied", err)
+	}
+}
+
+func TestSecretManagerProvider(t *testing.T) {
+	metadata := gcptest.NewMetadataServer(nil)
+	defer metadata.Close()
+
+	payload := `{"AccessKeyId": "id", "SecretAccessKey": "secret"}`
+	secrets := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		switch r.URL.Path {
+		case "/projects/project/secrets/aws/versions/latest:access":
+			w.Write([]byte(`{"payload": {"data": "` + base64.StdEncoding.EncodeToString([]byte(payload)) + `"}}`))
+		case "/projects/other/secrets/empty/versions/1:access":
+			w.Write([]byte(`{"payload": {"data": "` + base64.StdEncoding.EncodeToString([]byte(`{}`)) + `"}}`))
+		default:
+			body, _ := ioutil.ReadAll(r.Body)
+			t.Errorf("unexpected request: %s %s", r.URL.Path, body)
+			http.NotFound(w, r)
+		}
+	}))
+	defer secrets.Close()
+	defer func(endpoint string) { secretManagerEndpoint = endpoint }(secretManagerEndpoint)
+	secretManagerEndpoint = secrets.URL + "/"
+
+	c := NewSecretManagerCredentials("aws")
+	v, err := c.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Value{"id", "secret", "", SecretManagerProviderName}
+	if v != want {
+		t.Errorf("Get() = %v, want %v", v, want)
+	}
+	if c.IsExpired() {
+		t.Error("IsExpired() = true after Get")
+	}
+
+	_, err = NewSecretManagerCredentials("projects/other/secrets/empty/versions/1").Get()
+	if err == nil || !strings.Contains(err.Error(), "has no keys") {
+		t.Errorf("Get() = %v, want no keys", err)
+	}
+}
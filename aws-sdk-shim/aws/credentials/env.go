@@ -0,0 +1,40 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+)
+
+// EnvProviderName is the name of EnvProvider.
+const EnvProviderName = "EnvProvider"
+
+// EnvProvider retrieves credentials from the environment variables
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN.
+type EnvProvider struct {
+	retrieved bool
+}
+
+// NewEnvCredentials returns Credentials retrieved from the environment.
+func NewEnvCredentials() *Credentials {
+	return NewCredentials(&EnvProvider{})
+}
+
+// Retrieve implements Provider.
+func (p *EnvProvider) Retrieve() (Value, error) {
+	v := Value{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		ProviderName:    EnvProviderName,
+	}
+	if !v.HasKeys() {
+		return Value{}, errors.New("credentials: AWS_ACCESS_KEY_ID or AWS_SECRET_ACCESS_KEY not set")
+	}
+	p.retrieved = true
+	return v, nil
+}
+
+// IsExpired implements Provider.
+func (p *EnvProvider) IsExpired() bool {
+	return !p.retrieved
+}
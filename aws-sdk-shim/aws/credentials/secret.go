@@ -0,0 +1,93 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+// SecretManagerProviderName is the name of SecretManagerProvider.
+const SecretManagerProviderName = "SecretManagerProvider"
+
+// SecretManagerProvider retrieves static credentials
+// from a Google Secret Manager secret.
+//
+// The secret's payload is a JSON object, like:
+//
+//	{"AccessKeyId": "…", "SecretAccessKey": "…", "SessionToken": "…"}
+//
+// The service account needs the secretmanager.versions.access permission.
+type SecretManagerProvider struct {
+	// Secret is the secret's name, or its resource name
+	// (projects/PROJECT/secrets/SECRET[/versions/VERSION]).
+	// The latest version is used, if none is specified.
+	Secret string
+
+	retrieved bool
+}
+
+// NewSecretManagerCredentials returns Credentials
+// retrieved from a Google Secret Manager secret.
+func NewSecretManagerCredentials(secret string) *Credentials {
+	return NewCredentials(&SecretManagerProvider{Secret: secret})
+}
+
+var secretManagerEndpoint = "https://secretmanager.googleapis.com/v1/"
+
+// Retrieve implements Provider.
+func (p *SecretManagerProvider) Retrieve() (Value, error) {
+	name := p.Secret
+	if !strings.HasPrefix(name, "projects/") {
+		project, err := gcp.ProjectID()
+		if err != nil {
+			return Value{}, err
+		}
+		name = "projects/" + project + "/secrets/" + name
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	var res struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	err := gcp.Call("GET", secretManagerEndpoint+name+":access", nil, &res)
+	if err != nil {
+		return Value{}, err
+	}
+	data, err := base64.StdEncoding.DecodeString(res.Payload.Data)
+	if err != nil {
+		return Value{}, err
+	}
+
+	var creds struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		SessionToken    string `json:"SessionToken"`
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return Value{}, err
+	}
+	v := Value{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		ProviderName:    SecretManagerProviderName,
+	}
+	if !v.HasKeys() {
+		return Value{}, errors.New("credentials: secret " + p.Secret + " has no keys")
+	}
+	p.retrieved = true
+	return v, nil
+}
+
+// IsExpired implements Provider.
+// Static credentials don't expire once retrieved.
+func (p *SecretManagerProvider) IsExpired() bool {
+	return !p.retrieved
+}
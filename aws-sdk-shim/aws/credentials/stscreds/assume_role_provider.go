@@ -0,0 +1,139 @@
+// Package stscreds assumes roles by impersonating Google Cloud service accounts.
+//
+// Role ARNs are mapped to service account emails (see ServiceAccounts),
+// and AssumeRoleProvider generates a short-lived access token for
+// the service account (with the IAM generateAccessToken API).
+// The caller needs the iam.serviceAccounts.getAccessToken permission
+// (roles/iam.serviceAccountTokenCreator) on the service account.
+//
+// The retrieved credentials are not AWS credentials:
+// the access key ID is the service account's email,
+// and the session token is the Google Cloud access token.
+// Clients of shimmed services, created with these credentials,
+// call Google Cloud APIs as the service account.
+package stscreds
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+// ProviderName is the name of AssumeRoleProvider.
+const ProviderName = "AssumeRoleProvider"
+
+// DefaultDuration is the default duration of the credentials.
+var DefaultDuration = time.Hour
+
+// ServiceAccounts maps role ARNs to service account emails.
+// Roles that aren't mapped impersonate the service account
+// with the role's name, in the current project:
+// arn:aws:iam::123456789012:role/path/name is
+// name@PROJECT.iam.gserviceaccount.com.
+var ServiceAccounts = map[string]string{}
+
+// AssumeRoleProvider retrieves credentials for a role,
+// by impersonating the service account it maps to.
+type AssumeRoleProvider struct {
+	credentials.Expiry
+
+	// RoleARN is the ARN of the role to assume.
+	RoleARN string
+
+	// ServiceAccount overrides the service account the role maps to.
+	ServiceAccount string
+
+	// RoleSessionName identifies the session, it's ignored.
+	RoleSessionName string
+
+	// Duration of the credentials, the default is DefaultDuration,
+	// at most one hour (unless the organization allows longer).
+	Duration time.Duration
+
+	// ExpiryWindow refreshes the credentials this long before they expire.
+	ExpiryWindow time.Duration
+
+	// Scopes of the access token, the default is cloud-platform.
+	Scopes []string
+
+	token func() (string, error)
+}
+
+// NewCredentials returns Credentials for the role with roleARN.
+// The service account is impersonated with the credentials of c.
+func NewCredentials(c client.ConfigProvider, roleARN string, options ...func(*AssumeRoleProvider)) *credentials.Credentials {
+	p := &AssumeRoleProvider{
+		RoleARN:      roleARN,
+		Duration:     DefaultDuration,
+		ExpiryWindow: time.Minute,
+		token:        c.ClientConfig("sts").Token,
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return credentials.NewCredentials(p)
+}
+
+var iamCredentialsEndpoint = "https://iamcredentials.googleapis.com/v1/"
+
+// Retrieve implements credentials.Provider.
+func (p *AssumeRoleProvider) Retrieve() (credentials.Value, error) {
+	email, err := p.serviceAccount()
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	duration := p.Duration
+	if duration == 0 {
+		duration = DefaultDuration
+	}
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"https://www.googleapis.com/auth/cloud-platform"}
+	}
+	token := p.token
+	if token == nil {
+		token = gcp.AccessToken
+	}
+
+	var res struct {
+		AccessToken string    `json:"accessToken"`
+		ExpireTime  time.Time `json:"expireTime"`
+	}
+	req := struct {
+		Scope    []string `json:"scope"`
+		Lifetime string   `json:"lifetime"`
+	}{scopes, duration.String()}
+	err = gcp.CallAs(token, "POST", iamCredentialsEndpoint+"projects/-/serviceAccounts/"+url.PathEscape(email)+":generateAccessToken", req, &res)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	p.SetExpiration(res.ExpireTime, p.ExpiryWindow)
+	return credentials.Value{
+		AccessKeyID:     email,
+		SecretAccessKey: "-",
+		SessionToken:    res.AccessToken,
+		ProviderName:    ProviderName,
+	}, nil
+}
+
+func (p *AssumeRoleProvider) serviceAccount() (string, error) {
+	if p.ServiceAccount != "" {
+		return p.ServiceAccount, nil
+	}
+	if email, ok := ServiceAccounts[p.RoleARN]; ok {
+		return email, nil
+	}
+
+	name := p.RoleARN[strings.LastIndexByte(p.RoleARN, '/')+1:]
+	project, err := gcp.ProjectID()
+	if err != nil {
+		return "", err
+	}
+	return name + "@" + project + ".iam.gserviceaccount.com", nil
+}
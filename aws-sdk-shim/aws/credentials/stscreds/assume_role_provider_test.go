@@ -0,0 +1,97 @@
+package stscreds
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/internal/gcptest"
+)
+
+// configProvider authorizes calls with a fixed access token.
+type configProvider string
+
+func (p configProvider) ClientConfig(serviceName string, cfgs ...*aws.Config) client.Config {
+	return client.Config{Token: func() (string, error) { return string(p), nil }}
+}
+
+func TestAssumeRoleProvider(t *testing.T) {
+	metadata := gcptest.NewMetadataServer(nil)
+	defer metadata.Close()
+
+	expire := time.Now().Add(30 * time.Minute).UTC().Truncate(time.Second)
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer caller" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req struct {
+			Scope    []string `json:"scope"`
+			Lifetime string   `json:"lifetime"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if len(req.Scope) != 1 || req.Scope[0] != "https://www.googleapis.com/auth/cloud-platform" || req.Lifetime != "30m0s" {
+			t.Errorf("request = %+v", req)
+		}
+		path = r.URL.EscapedPath()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"accessToken": "impersonated",
+			"expireTime":  expire,
+		})
+	}))
+	defer server.Close()
+	defer func(endpoint string) { iamCredentialsEndpoint = endpoint }(iamCredentialsEndpoint)
+	iamCredentialsEndpoint = server.URL + "/v1/"
+
+	creds := NewCredentials(configProvider("caller"), "arn:aws:iam::123456789012:role/path/reader",
+		func(p *AssumeRoleProvider) { p.Duration = 30 * time.Minute })
+	v, err := creds.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const email = "reader@project.iam.gserviceaccount.com"
+	if want := "/v1/projects/-/serviceAccounts/" + email + ":generateAccessToken"; path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if v.AccessKeyID != email || v.SessionToken != "impersonated" || v.ProviderName != ProviderName {
+		t.Errorf("Get() = %+v", v)
+	}
+	p := creds.Provider().(*AssumeRoleProvider)
+	if got := p.ExpiresAt(); !got.Equal(expire.Add(-time.Minute)) {
+		t.Errorf("ExpiresAt() = %v, want %v", got, expire.Add(-time.Minute))
+	}
+}
+
+func TestAssumeRoleProvider_serviceAccount(t *testing.T) {
+	metadata := gcptest.NewMetadataServer(nil)
+	defer metadata.Close()
+
+	const role = "arn:aws:iam::123456789012:role/writer"
+	ServiceAccounts[role] = "mapped@other.iam.gserviceaccount.com"
+	defer delete(ServiceAccounts, role)
+
+	tests := []struct {
+		p    AssumeRoleProvider
+		want string
+	}{
+		{AssumeRoleProvider{RoleARN: "arn:aws:iam::123456789012:role/reader"}, "reader@project.iam.gserviceaccount.com"},
+		{AssumeRoleProvider{RoleARN: role}, "mapped@other.iam.gserviceaccount.com"},
+		{AssumeRoleProvider{RoleARN: role, ServiceAccount: "override@other.iam.gserviceaccount.com"}, "override@other.iam.gserviceaccount.com"},
+	}
+	for _, tt := range tests {
+		got, err := tt.p.serviceAccount()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("serviceAccount(%q) = %q, want %q", tt.p.RoleARN, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package credentials
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+// WebIdentityProviderName is the name of WebIdentityProvider.
+const WebIdentityProviderName = "WebIdentityProvider"
+
+// WebIdentityProvider retrieves temporary credentials from AWS STS,
+// by assuming a role with the Google ID token of the default service account
+// (AssumeRoleWithWebIdentity).
+//
+// The role's trust policy must allow the accounts.google.com identity provider,
+// with the service account's unique ID as the subject,
+// and the audience (sts.amazonaws.com, by default).
+type WebIdentityProvider struct {
+	Expiry
+
+	// RoleARN is the ARN of the role to assume.
+	RoleARN string
+
+	// RoleSessionName identifies the session, the default is the instance ID.
+	RoleSessionName string
+
+	// Audience is the ID token's audience, the default is sts.amazonaws.com.
+	Audience string
+
+	// Duration of the credentials, the default is one hour.
+	Duration time.Duration
+}
+
+// NewWebIdentityCredentials returns Credentials for the role with roleARN,
+// assumed with the Google identity of the default service account.
+func NewWebIdentityCredentials(roleARN string) *Credentials {
+	return NewCredentials(&WebIdentityProvider{RoleARN: roleARN})
+}
+
+var (
+	stsClient   = &http.Client{Timeout: 30 * time.Second}
+	stsEndpoint = "https://sts.amazonaws.com/"
+)
+
+// Retrieve implements Provider.
+func (p *WebIdentityProvider) Retrieve() (Value, error) {
+	audience := p.Audience
+	if audience == "" {
+		audience = "sts.amazonaws.com"
+	}
+	token, err := gcp.IdentityToken(audience)
+	if err != nil {
+		return Value{}, err
+	}
+
+	session := p.RoleSessionName
+	if session == "" {
+		session, _ = gcp.Metadata("instance/id")
+		if session == "" {
+			session = "gcp"
+		}
+		if len(session) > 64 {
+			session = session[:64]
+		}
+	}
+
+	// The token is sent in the request body, so it isn't logged with the URL.
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {p.RoleARN},
+		"RoleSessionName":  {session},
+		"WebIdentityToken": {token},
+	}
+	if p.Duration > 0 {
+		form.Set("DurationSeconds", strconv.Itoa(int(p.Duration/time.Second)))
+	}
+
+	res, err := stsClient.PostForm(stsEndpoint, form)
+	if err != nil {
+		return Value{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Code    string
+				Message string
+			}
+		}
+		xml.NewDecoder(res.Body).Decode(&e)
+		if e.Error.Code == "" {
+			return Value{}, errors.New("credentials: sts: " + res.Status)
+		}
+		return Value{}, errors.New("credentials: sts: " + e.Error.Code + ": " + e.Error.Message)
+	}
+
+	var out struct {
+		Result struct {
+			Credentials struct {
+				AccessKeyID     string `xml:"AccessKeyId"`
+				SecretAccessKey string
+				SessionToken    string
+				Expiration      time.Time
+			}
+		} `xml:"AssumeRoleWithWebIdentityResult"`
+	}
+	if err := xml.NewDecoder(res.Body).Decode(&out); err != nil {
+		return Value{}, err
+	}
+
+	creds := out.Result.Credentials
+	p.SetExpiration(creds.Expiration, time.Minute)
+	return Value{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		ProviderName:    WebIdentityProviderName,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package ec2metadata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAWSRegion(t *testing.T) {
+	tests := []struct{ gcp, aws string }{
+		{"us-central1", "us-east-2"},
+		{"us-central1-a", "us-east-2"},
+		{"us-central1-1", "us-east-2"},
+		{"europe-west10-b", "eu-central-1"},
+		{"projects/123456789012/zones/europe-west1-d", "eu-west-1"},
+		{"antarctica-south1-a", "antarctica-south1"},
+	}
+	for _, tt := range tests {
+		if got := AWSRegion(tt.gcp); got != tt.aws {
+			t.Errorf("AWSRegion(%q) = %q, want %q", tt.gcp, got, tt.aws)
+		}
+	}
+}
+
+func TestEC2Metadata_Region(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		want     string
+	}{
+		{"compute", map[string]string{
+			"instance/zone": "projects/123456789012/zones/europe-west1-d",
+		}, "eu-west-1"},
+		{"serverless", map[string]string{
+			"instance/zone":   "projects/123456789012/zones/us-central1-1",
+			"instance/region": "projects/123456789012/regions/us-central1",
+		}, "us-east-2"},
+		{"serverless zone", map[string]string{
+			"instance/zone": "projects/123456789012/zones/asia-northeast1-1",
+		}, "ap-northeast-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Metadata-Flavor") != "Google" {
+					http.Error(w, "missing header", http.StatusForbidden)
+					return
+				}
+				value, ok := tt.metadata[strings.TrimPrefix(r.URL.Path, "/computeMetadata/v1/")]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Metadata-Flavor", "Google")
+				w.Write([]byte(value))
+			}))
+			defer server.Close()
+
+			c := &EC2Metadata{
+				client: server.Client(),
+				host:   strings.TrimPrefix(server.URL, "http://"),
+			}
+			got, err := c.Region()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Region() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
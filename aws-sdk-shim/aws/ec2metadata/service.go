@@ -1,21 +1,124 @@
+// Package ec2metadata describes Google Compute Engine instances
+// (including Cloud Run, and Cloud Functions), through
+// the Compute Engine metadata server, as if they were EC2 instances.
 package ec2metadata
 
-type EC2Metadata struct{}
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
-func New(p *struct{}) *EC2Metadata {
-	return nil
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+)
+
+// EC2Metadata is a client for the Compute Engine metadata server.
+type EC2Metadata struct {
+	client *http.Client
+	host   string
+}
+
+// New creates a metadata client.
+// The GCE_METADATA_HOST environment variable overrides the server's address.
+func New(p client.ConfigProvider, cfgs ...*aws.Config) *EC2Metadata {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	return &EC2Metadata{
+		host: host,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{Timeout: time.Second}).DialContext,
+			},
+			Timeout: 5 * time.Second,
+		},
+	}
 }
 
+// Available reports whether the metadata server is reachable,
+// that is, whether this is running on Google Cloud.
 func (c *EC2Metadata) Available() bool {
-	return false
+	if c == nil {
+		return false
+	}
+	_, err := c.GetMetadata("instance/id")
+	return err == nil
+}
+
+// GetMetadata returns the value of a metadata path,
+// relative to computeMetadata/v1/.
+func (c *EC2Metadata) GetMetadata(path string) (string, error) {
+	if c == nil {
+		return "", errors.New("ec2metadata: nil client")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+c.host+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New("ec2metadata: " + path + ": " + res.Status)
+	}
+	if res.Header.Get("Metadata-Flavor") != "Google" {
+		return "", errors.New("ec2metadata: " + path + ": not a metadata server")
+	}
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf)), nil
 }
 
+// GetInstanceIdentityDocument describes the instance.
+// The region is the AWS region for the instance (see AWSRegion),
+// and the account ID is the numeric project ID.
 func (c *EC2Metadata) GetInstanceIdentityDocument() (d EC2InstanceIdentityDocument, err error) {
-	return
+	zone, err := c.GetMetadata("instance/zone")
+	if err != nil {
+		return d, err
+	}
+	// Zones look like: projects/123456789012/zones/us-central1-a
+	d.AvailabilityZone = zone[strings.LastIndexByte(zone, '/')+1:]
+	if d.Region, err = c.Region(); err != nil {
+		return d, err
+	}
+
+	if d.InstanceID, err = c.GetMetadata("instance/id"); err != nil {
+		return d, err
+	}
+	if d.AccountID, err = c.GetMetadata("project/numeric-project-id"); err != nil {
+		return d, err
+	}
+
+	// Not available on serverless platforms.
+	if typ, err := c.GetMetadata("instance/machine-type"); err == nil {
+		d.InstanceType = typ[strings.LastIndexByte(typ, '/')+1:]
+	}
+	if ip, err := c.GetMetadata("instance/network-interfaces/0/ip"); err == nil {
+		d.PrivateIP = ip
+	}
+	return d, nil
 }
 
+// EC2InstanceIdentityDocument describes an instance.
 type EC2InstanceIdentityDocument struct {
-	Region     string
-	InstanceID string
-	AccountID  string
+	Region           string
+	AvailabilityZone string
+	InstanceID       string
+	InstanceType     string
+	AccountID        string
+	PrivateIP        string
 }
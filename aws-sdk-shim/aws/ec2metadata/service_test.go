@@ -0,0 +1,92 @@
+package ec2metadata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/internal/gcptest"
+)
+
+func TestEC2Metadata_GetInstanceIdentityDocument(t *testing.T) {
+	server := gcptest.NewMetadataServer(map[string]string{
+		"instance/zone":                    "projects/123456789012/zones/europe-west1-d",
+		"instance/machine-type":            "projects/123456789012/machineTypes/e2-small",
+		"instance/network-interfaces/0/ip": "10.0.0.2",
+	})
+	defer server.Close()
+
+	c := New(nil)
+	if !c.Available() {
+		t.Fatal("Available() = false")
+	}
+	got, err := c.GetInstanceIdentityDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := EC2InstanceIdentityDocument{
+		Region:           "eu-west-1",
+		AvailabilityZone: "europe-west1-d",
+		InstanceID:       gcptest.Defaults["instance/id"],
+		InstanceType:     "e2-small",
+		AccountID:        gcptest.Defaults["project/numeric-project-id"],
+		PrivateIP:        "10.0.0.2",
+	}
+	if got != want {
+		t.Errorf("GetInstanceIdentityDocument() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEC2Metadata_GetInstanceIdentityDocument_serverless(t *testing.T) {
+	server := gcptest.NewMetadataServer(map[string]string{
+		"instance/zone":   "projects/123456789012/zones/us-central1-1",
+		"instance/region": "projects/123456789012/regions/us-central1",
+	})
+	defer server.Close()
+
+	got, err := New(nil).GetInstanceIdentityDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Region != "us-east-2" || got.AvailabilityZone != "us-central1-1" || got.InstanceType != "" || got.PrivateIP != "" {
+		t.Errorf("GetInstanceIdentityDocument() = %+v", got)
+	}
+}
+
+func TestEC2Metadata_GetMetadata(t *testing.T) {
+	server := gcptest.NewMetadataServer(nil)
+	defer server.Close()
+
+	c := New(nil)
+	if got, err := c.GetMetadata("project/project-id"); err != nil || got != "project" {
+		t.Errorf("GetMetadata() = %q, %v", got, err)
+	}
+	if _, err := c.GetMetadata("missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("GetMetadata() = %v, want 404", err)
+	}
+
+	var nilClient *EC2Metadata
+	if nilClient.Available() {
+		t.Error("Available() = true for nil client")
+	}
+}
+
+func TestEC2Metadata_GetMetadata_notMetadata(t *testing.T) {
+	// A server that doesn't answer with the Metadata-Flavor header.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+	os.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	defer os.Unsetenv("GCE_METADATA_HOST")
+
+	c := New(nil)
+	if c.Available() {
+		t.Error("Available() = true")
+	}
+	if _, err := c.GetMetadata("instance/id"); err == nil || !strings.Contains(err.Error(), "not a metadata server") {
+		t.Errorf("GetMetadata() = %v, want not a metadata server", err)
+	}
+}
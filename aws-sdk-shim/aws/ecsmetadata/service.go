@@ -0,0 +1,201 @@
+// Package ecsmetadata emulates the ECS task metadata endpoint (version 4),
+// describing Cloud Run services, jobs, and Cloud Functions,
+// for agents and sidecars that look for ECS metadata.
+//
+// The cluster is the project ID, the task family is the service (or job),
+// its revision the service revision (or job execution),
+// and the task's only container is the running instance.
+package ecsmetadata
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+)
+
+// Start serves the metadata endpoint on a loopback address,
+// and sets the ECS_CONTAINER_METADATA_URI_V4 (and ECS_CONTAINER_METADATA_URI)
+// environment variables to its URI, which it returns.
+// Child processes inherit the environment.
+func Start() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	go http.Serve(l, Handler())
+
+	uri := "http://" + l.Addr().String()
+	os.Setenv("ECS_CONTAINER_METADATA_URI_V4", uri)
+	os.Setenv("ECS_CONTAINER_METADATA_URI", uri)
+	return uri, nil
+}
+
+// Handler serves the metadata endpoint:
+// the container at /, and the task at /task.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t, err := describe()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		var v interface{}
+		switch strings.TrimSuffix(r.URL.Path, "/") {
+		case "":
+			v = t.Containers[0]
+		case "/task":
+			v = t
+		case "/stats", "/task/stats":
+			v = struct{}{}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	})
+}
+
+// Task describes the task.
+type Task struct {
+	Cluster          string
+	TaskARN          string
+	Family           string
+	Revision         string
+	DesiredStatus    string
+	KnownStatus      string
+	Limits           Limits
+	PullStartedAt    time.Time `json:",omitempty"`
+	AvailabilityZone string
+	LaunchType       string
+	Containers       []Container
+}
+
+// Container describes the container.
+type Container struct {
+	DockerID      string `json:"DockerId"`
+	Name          string
+	DockerName    string
+	Image         string
+	ImageID       string
+	Labels        map[string]string
+	DesiredStatus string
+	KnownStatus   string
+	Limits        Limits
+	CreatedAt     time.Time
+	StartedAt     time.Time
+	Type          string
+	ContainerARN  string
+	Networks      []Network
+}
+
+// Limits are resource limits:
+// CPU units (1024 per vCPU), and memory in MiB.
+type Limits struct {
+	CPU    float64
+	Memory int64
+}
+
+// Network describes a network interface.
+type Network struct {
+	NetworkMode   string
+	IPv4Addresses []string
+}
+
+var (
+	started = time.Now().UTC()
+	taskMtx sync.Mutex
+	task    *Task
+)
+
+func describe() (*Task, error) {
+	taskMtx.Lock()
+	defer taskMtx.Unlock()
+	if task != nil {
+		return task, nil
+	}
+
+	doc, err := ec2metadata.New(nil).GetInstanceIdentityDocument()
+	if err != nil {
+		return nil, err
+	}
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		project = doc.AccountID
+	}
+
+	family := getenv("K_SERVICE", "CLOUD_RUN_JOB", "FUNCTION_TARGET")
+	revision := getenv("K_REVISION", "CLOUD_RUN_EXECUTION")
+	limits := Limits{
+		CPU:    float64(runtime.NumCPU() * 1024),
+		Memory: memoryMB(),
+	}
+	arn := "arn:aws:ecs:" + doc.Region + ":" + doc.AccountID + ":"
+
+	c := Container{
+		DockerID:      doc.InstanceID,
+		Name:          family,
+		DockerName:    family + "-" + doc.InstanceID,
+		Labels:        map[string]string{},
+		DesiredStatus: "RUNNING",
+		KnownStatus:   "RUNNING",
+		Limits:        limits,
+		CreatedAt:     started,
+		StartedAt:     started,
+		Type:          "NORMAL",
+		ContainerARN:  arn + "container/" + project + "/" + doc.InstanceID,
+	}
+	if doc.PrivateIP != "" {
+		c.Networks = []Network{{NetworkMode: "awsvpc", IPv4Addresses: []string{doc.PrivateIP}}}
+	}
+
+	task = &Task{
+		Cluster:          project,
+		TaskARN:          arn + "task/" + project + "/" + doc.InstanceID,
+		Family:           family,
+		Revision:         revision,
+		DesiredStatus:    "RUNNING",
+		KnownStatus:      "RUNNING",
+		Limits:           limits,
+		AvailabilityZone: doc.AvailabilityZone,
+		LaunchType:       "FARGATE",
+		Containers:       []Container{c},
+	}
+	return task, nil
+}
+
+func getenv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// memoryMB reads the memory limit of the container.
+func memoryMB() int64 {
+	for _, f := range []string{
+		"/sys/fs/cgroup/memory.max",
+		"/sys/fs/cgroup/memory/memory.limit_in_bytes",
+	} {
+		buf, err := ioutil.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(string(buf)), 10, 64); err == nil && n < 1<<50 {
+			return n >> 20
+		}
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package ecsmetadata
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/internal/gcptest"
+)
+
+func get(t *testing.T, url string, v interface{}) int {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if v != nil && res.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res.StatusCode
+}
+
+func TestStart(t *testing.T) {
+	// The task is only cached once described, so this fails first.
+	metadata := gcptest.NewMetadataServer(nil)
+	metadata.Close()
+	os.Setenv("GCE_METADATA_HOST", metadata.Listener.Addr().String())
+
+	uri, err := Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("ECS_CONTAINER_METADATA_URI_V4")
+	defer os.Unsetenv("ECS_CONTAINER_METADATA_URI")
+	if os.Getenv("ECS_CONTAINER_METADATA_URI_V4") != uri || os.Getenv("ECS_CONTAINER_METADATA_URI") != uri {
+		t.Errorf("environment not set to %q", uri)
+	}
+
+	if status := get(t, uri+"/task", nil); status != http.StatusServiceUnavailable {
+		t.Errorf("without metadata: status = %d, want 503", status)
+	}
+
+	metadata = gcptest.NewMetadataServer(map[string]string{
+		"instance/zone":   "projects/123456789012/zones/us-central1-1",
+		"instance/region": "projects/123456789012/regions/us-central1",
+	})
+	defer metadata.Close()
+	os.Setenv("K_SERVICE", "service")
+	os.Setenv("K_REVISION", "service-00001")
+	defer os.Unsetenv("K_SERVICE")
+	defer os.Unsetenv("K_REVISION")
+
+	id := gcptest.Defaults["instance/id"]
+	account := gcptest.Defaults["project/numeric-project-id"]
+	arn := "arn:aws:ecs:us-east-2:" + account + ":"
+
+	var task Task
+	if status := get(t, uri+"/task", &task); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if task.Cluster != account || task.Family != "service" || task.Revision != "service-00001" ||
+		task.TaskARN != arn+"task/"+account+"/"+id ||
+		task.AvailabilityZone != "us-central1-1" || len(task.Containers) != 1 {
+		t.Errorf("task = %+v", task)
+	}
+
+	var container Container
+	if status := get(t, uri, &container); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if container.DockerID != id || container.Name != "service" ||
+		container.ContainerARN != arn+"container/"+account+"/"+id ||
+		container.Networks != nil || container.KnownStatus != "RUNNING" {
+		t.Errorf("container = %+v", container)
+	}
+
+	if status := get(t, uri+"/task/stats", nil); status != http.StatusOK {
+		t.Errorf("stats: status = %d", status)
+	}
+	if status := get(t, uri+"/missing", nil); status != http.StatusNotFound {
+		t.Errorf("missing: status = %d, want 404", status)
+	}
+}
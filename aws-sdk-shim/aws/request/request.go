@@ -0,0 +1,66 @@
+// Package request describes service requests, which can be presigned.
+package request
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// An Operation describes a service operation.
+type Operation struct {
+	Name       string
+	HTTPMethod string
+	HTTPPath   string
+}
+
+// A Signer presigns requests.
+type Signer func(r *Request, expire time.Duration) (url string, header http.Header, err error)
+
+// A Request is a service request.
+type Request struct {
+	Operation   *Operation
+	HTTPRequest *http.Request
+	Params      interface{}
+	Data        interface{}
+	Error       error
+
+	// ExpireTime is the expiry of the last presigned URL.
+	ExpireTime time.Duration
+
+	signer Signer
+}
+
+// New creates a Request, presigned by signer.
+func New(operation *Operation, httpReq *http.Request, params, data interface{}, signer Signer) *Request {
+	return &Request{
+		Operation:   operation,
+		HTTPRequest: httpReq,
+		Params:      params,
+		Data:        data,
+		signer:      signer,
+	}
+}
+
+// Presign returns a URL, valid for expire,
+// that can be used without credentials to make the request.
+// If the request has signed headers, they must be sent as well
+// (see PresignRequest).
+func (r *Request) Presign(expire time.Duration) (string, error) {
+	url, _, err := r.PresignRequest(expire)
+	return url, err
+}
+
+// PresignRequest returns a URL, valid for expire,
+// and the headers that must be sent,
+// that can be used without credentials to make the request.
+func (r *Request) PresignRequest(expire time.Duration) (string, http.Header, error) {
+	if r.Error != nil {
+		return "", nil, r.Error
+	}
+	if r.signer == nil {
+		return "", nil, errors.New("request: " + r.Operation.Name + " can't be presigned")
+	}
+	r.ExpireTime = expire
+	return r.signer(r, expire)
+}
@@ -1,5 +1,127 @@
+// Package session creates sessions, used to create service clients.
+//
+// NewSession fills in the configuration from the Google Cloud environment,
+// so no AWS environment variables are needed:
+//
+//   - the region is read from AWS_REGION (or AWS_DEFAULT_REGION),
+//     or mapped from the instance's zone (see ec2metadata.Regions);
+//   - credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY,
+//     or retrieved for the role in AWS_ROLE_ARN (see credentials.WebIdentityProvider),
+//     or from the secret in AWS_CREDENTIALS_SECRET (see credentials.SecretManagerProvider);
+//   - shimmed services use their Google Cloud API endpoints.
 package session
 
-func NewSession() (*struct{}, error) {
-	return nil, nil
+import (
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+// Endpoints are the Google Cloud API endpoints of shimmed services,
+// by service name.
+var Endpoints = map[string]string{
+	"kms":            "https://cloudkms.googleapis.com/v1/",
+	"s3":             "https://storage.googleapis.com/",
+	"secretsmanager": "https://secretmanager.googleapis.com/v1/",
+	"sns":            "https://pubsub.googleapis.com/v1/",
+}
+
+// A Session holds the configuration shared by service clients.
+type Session struct {
+	Config *aws.Config
+}
+
+// NewSession creates a Session, with cfgs merged in,
+// filling in the region, and credentials, if not set.
+func NewSession(cfgs ...*aws.Config) (*Session, error) {
+	cfg := aws.NewConfig().Copy(cfgs...)
+
+	if cfg.Region == nil {
+		if region := getenv("AWS_REGION", "AWS_DEFAULT_REGION"); region != "" {
+			cfg.WithRegion(region)
+		} else if md := ec2metadata.New(nil); md.Available() {
+			if region, err := md.Region(); err == nil {
+				cfg.WithRegion(region)
+			}
+		}
+	}
+
+	if cfg.Credentials == nil {
+		switch {
+		case os.Getenv("AWS_ACCESS_KEY_ID") != "":
+			cfg.WithCredentials(credentials.NewEnvCredentials())
+		case os.Getenv("AWS_ROLE_ARN") != "":
+			cfg.WithCredentials(credentials.NewWebIdentityCredentials(os.Getenv("AWS_ROLE_ARN")))
+		case os.Getenv("AWS_CREDENTIALS_SECRET") != "":
+			cfg.WithCredentials(credentials.NewSecretManagerCredentials(os.Getenv("AWS_CREDENTIALS_SECRET")))
+		}
+	}
+
+	return &Session{Config: cfg}, nil
+}
+
+// Must panics if err is not nil, and returns sess otherwise.
+func Must(sess *Session, err error) *Session {
+	if err != nil {
+		panic(err)
+	}
+	return sess
+}
+
+// Copy returns a copy of s, with cfgs merged in.
+func (s *Session) Copy(cfgs ...*aws.Config) *Session {
+	return &Session{Config: s.Config.Copy(cfgs...)}
+}
+
+// ClientConfig returns the configuration of a service client,
+// with cfgs merged in.
+func (s *Session) ClientConfig(serviceName string, cfgs ...*aws.Config) client.Config {
+	var cfg *aws.Config
+	if s == nil || s.Config == nil {
+		cfg = aws.NewConfig().Copy(cfgs...)
+	} else {
+		cfg = s.Config.Copy(cfgs...)
+	}
+
+	endpoint := Endpoints[serviceName]
+	if cfg.Endpoint != nil {
+		endpoint = *cfg.Endpoint
+	}
+	if endpoint != "" && !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+	return client.Config{Config: cfg, Endpoint: endpoint, Token: token(cfg.Credentials)}
+}
+
+// token returns the access token of impersonated credentials
+// (see stscreds), or the default service account's.
+func token(creds *credentials.Credentials) func() (string, error) {
+	if creds == nil {
+		return gcp.AccessToken
+	}
+	if _, ok := creds.Provider().(*stscreds.AssumeRoleProvider); !ok {
+		return gcp.AccessToken
+	}
+	return func() (string, error) {
+		v, err := creds.Get()
+		if err != nil {
+			return "", err
+		}
+		return v.SessionToken, nil
+	}
+}
+
+func getenv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
 }
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/internal/gcptest"
+)
+
+func setenv(env map[string]string) (restore func()) {
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+	return func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+func TestNewSession_region(t *testing.T) {
+	metadata := gcptest.NewMetadataServer(map[string]string{
+		"instance/region": "projects/123456789012/regions/europe-west1",
+	})
+	defer metadata.Close()
+
+	sess := Must(NewSession())
+	if got := aws.StringValue(sess.Config.Region); got != "eu-west-1" {
+		t.Errorf("Region = %q, want %q", got, "eu-west-1")
+	}
+
+	defer setenv(map[string]string{"AWS_DEFAULT_REGION": "us-west-2"})()
+	sess = Must(NewSession())
+	if got := aws.StringValue(sess.Config.Region); got != "us-west-2" {
+		t.Errorf("Region = %q, want %q", got, "us-west-2")
+	}
+
+	defer setenv(map[string]string{"AWS_REGION": "ap-south-1"})()
+	sess = Must(NewSession())
+	if got := aws.StringValue(sess.Config.Region); got != "ap-south-1" {
+		t.Errorf("Region = %q, want %q", got, "ap-south-1")
+	}
+
+	sess = Must(NewSession(aws.NewConfig().WithRegion("sa-east-1")))
+	if got := aws.StringValue(sess.Config.Region); got != "sa-east-1" {
+		t.Errorf("Region = %q, want %q", got, "sa-east-1")
+	}
+}
+
+func TestNewSession_credentials(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want credentials.Provider
+	}{
+		{"none", nil, nil},
+		{"env", map[string]string{
+			"AWS_ACCESS_KEY_ID": "id",
+			"AWS_ROLE_ARN":      "arn:aws:iam::123456789012:role/test",
+		}, &credentials.EnvProvider{}},
+		{"web identity", map[string]string{
+			"AWS_ROLE_ARN":           "arn:aws:iam::123456789012:role/test",
+			"AWS_CREDENTIALS_SECRET": "aws",
+		}, &credentials.WebIdentityProvider{}},
+		{"secret", map[string]string{
+			"AWS_CREDENTIALS_SECRET": "aws",
+		}, &credentials.SecretManagerProvider{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setenv(tt.env)()
+
+			sess := Must(NewSession(aws.NewConfig().WithRegion("us-east-1")))
+			creds := sess.Config.Credentials
+			switch {
+			case tt.want == nil && creds != nil:
+				t.Errorf("Credentials = %T, want none", creds.Provider())
+			case tt.want == nil:
+			case creds == nil:
+				t.Errorf("Credentials = nil, want %T", tt.want)
+			default:
+				if got, want := fmt.Sprintf("%T", creds.Provider()), fmt.Sprintf("%T", tt.want); got != want {
+					t.Errorf("Credentials = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSession_ClientConfig(t *testing.T) {
+	metadata := gcptest.NewMetadataServer(nil)
+	defer metadata.Close()
+
+	sess := Must(NewSession(aws.NewConfig().WithRegion("us-east-1")))
+
+	c := sess.ClientConfig("kms")
+	if c.Endpoint != Endpoints["kms"] {
+		t.Errorf("Endpoint = %q, want %q", c.Endpoint, Endpoints["kms"])
+	}
+	if token, err := c.Token(); err != nil || token != "token" {
+		t.Errorf("Token() = %q, %v", token, err)
+	}
+
+	c = sess.ClientConfig("kms", aws.NewConfig().WithEndpoint("http://localhost:8080/v1"))
+	if c.Endpoint != "http://localhost:8080/v1/" {
+		t.Errorf("Endpoint = %q, want a trailing slash", c.Endpoint)
+	}
+	if aws.StringValue(c.Config.Region) != "us-east-1" {
+		t.Errorf("Region = %q, want the session's", aws.StringValue(c.Config.Region))
+	}
+
+	var nilSession *Session
+	if c := nilSession.ClientConfig("sns"); c.Endpoint != Endpoints["sns"] || c.Token == nil {
+		t.Errorf("ClientConfig() = %+v", c)
+	}
+}
@@ -0,0 +1,169 @@
+// Package gcp calls Google Cloud APIs, authorized by the metadata server,
+// using only the standard library.
+package gcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var metadataClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: time.Second}).DialContext,
+	},
+	Timeout: 5 * time.Second,
+}
+
+var apiClient = &http.Client{Timeout: 30 * time.Second}
+
+// Metadata returns the value of a metadata server path,
+// relative to computeMetadata/v1/.
+// The GCE_METADATA_HOST environment variable overrides the server's address.
+func Metadata(path string) (string, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+host+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := metadataClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New("metadata: " + path + ": " + res.Status)
+	}
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// ProjectID returns the Google Cloud project ID,
+// from the GOOGLE_CLOUD_PROJECT environment variable,
+// or the metadata server.
+func ProjectID() (string, error) {
+	if id := os.Getenv("GOOGLE_CLOUD_PROJECT"); id != "" {
+		return id, nil
+	}
+	return Metadata("project/project-id")
+}
+
+var (
+	tokenMtx     sync.Mutex
+	tokenValue   string
+	tokenExpires time.Time
+)
+
+// AccessToken returns an OAuth2 access token for the default service account.
+// Tokens are cached until shortly before they expire.
+func AccessToken() (string, error) {
+	tokenMtx.Lock()
+	defer tokenMtx.Unlock()
+
+	if tokenValue != "" && time.Until(tokenExpires) > time.Minute {
+		return tokenValue, nil
+	}
+
+	res, err := Metadata("instance/service-accounts/default/token")
+	if err != nil {
+		return "", err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal([]byte(res), &token); err != nil {
+		return "", err
+	}
+	tokenValue = token.AccessToken
+	tokenExpires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return tokenValue, nil
+}
+
+// IdentityToken returns an OpenID Connect ID token
+// for the default service account, with the given audience.
+func IdentityToken(audience string) (string, error) {
+	return Metadata("instance/service-accounts/default/identity?format=full&audience=" + url.QueryEscape(audience))
+}
+
+// An Error is an error returned by a Google Cloud API.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.Code)
+	}
+	return e.Message
+}
+
+// Call calls a Google Cloud JSON API, authorized with AccessToken,
+// encoding in (if not nil) as the request body,
+// and decoding the response body into out (if not nil).
+func Call(method, url string, in, out interface{}) error {
+	return CallAs(AccessToken, method, url, in, out)
+}
+
+// CallAs calls a Google Cloud JSON API, like Call,
+// authorized with the access token returned by token.
+func CallAs(token func() (string, error), method, url string, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, url, &body)
+	if err != nil {
+		return err
+	}
+	bearer, err := token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+bearer)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := apiClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var e struct {
+			Error Error `json:"error"`
+		}
+		json.NewDecoder(res.Body).Decode(&e)
+		e.Error.Code = res.StatusCode
+		return &e.Error
+	}
+	if out != nil {
+		return json.NewDecoder(res.Body).Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Package gcptest implements a fake metadata server,
+// for testing code that calls Google Cloud APIs through package gcp.
+package gcptest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+)
+
+// Defaults are the metadata values served unless overridden.
+var Defaults = map[string]string{
+	"project/project-id":                      "project",
+	"project/numeric-project-id":              "123456789012",
+	"instance/id":                             "1234567890",
+	"instance/zone":                           "projects/123456789012/zones/us-central1-a",
+	"instance/service-accounts/default/email": "default@project.iam.gserviceaccount.com",
+	"instance/service-accounts/default/token": `{"access_token":"token","expires_in":3600}`,
+}
+
+// A MetadataServer is a fake metadata server.
+type MetadataServer struct {
+	*httptest.Server
+}
+
+// NewMetadataServer starts a fake metadata server,
+// serving values (keyed by path, relative to computeMetadata/v1/),
+// and Defaults for missing values.
+// ID tokens are served as "id-token:" followed by the audience.
+// GCE_METADATA_HOST points to the server until it's closed.
+func NewMetadataServer(values map[string]string) *MetadataServer {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing Metadata-Flavor", http.StatusForbidden)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/computeMetadata/v1/")
+		value, ok := values[path]
+		if !ok {
+			value, ok = Defaults[path]
+		}
+		if !ok && path == "instance/service-accounts/default/identity" {
+			value, ok = "id-token:"+r.URL.Query().Get("audience"), true
+		}
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Metadata-Flavor", "Google")
+		w.Write([]byte(value))
+	}))
+	os.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	return &MetadataServer{server}
+}
+
+// Close shuts down the server, and unsets GCE_METADATA_HOST.
+func (s *MetadataServer) Close() {
+	os.Unsetenv("GCE_METADATA_HOST")
+	s.Server.Close()
+}
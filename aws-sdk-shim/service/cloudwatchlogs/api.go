@@ -0,0 +1,109 @@
+package cloudwatchlogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// CreateLogGroupInput is the input of CreateLogGroup.
+type CreateLogGroupInput struct {
+	LogGroupName *string
+	Tags         map[string]*string
+}
+
+// CreateLogGroupOutput is the output of CreateLogGroup.
+type CreateLogGroupOutput struct{}
+
+// CreateLogGroup is a no-op: log groups are labels.
+func (c *CloudWatchLogs) CreateLogGroup(input *CreateLogGroupInput) (*CreateLogGroupOutput, error) {
+	if aws.StringValue(input.LogGroupName) == "" {
+		return nil, awserr.New(ErrCodeInvalidParameterException, "missing LogGroupName", nil)
+	}
+	return &CreateLogGroupOutput{}, nil
+}
+
+// CreateLogStreamInput is the input of CreateLogStream.
+type CreateLogStreamInput struct {
+	LogGroupName  *string
+	LogStreamName *string
+}
+
+// CreateLogStreamOutput is the output of CreateLogStream.
+type CreateLogStreamOutput struct{}
+
+// CreateLogStream is a no-op: log streams are labels.
+func (c *CloudWatchLogs) CreateLogStream(input *CreateLogStreamInput) (*CreateLogStreamOutput, error) {
+	if aws.StringValue(input.LogGroupName) == "" || aws.StringValue(input.LogStreamName) == "" {
+		return nil, awserr.New(ErrCodeInvalidParameterException, "missing LogGroupName or LogStreamName", nil)
+	}
+	return &CreateLogStreamOutput{}, nil
+}
+
+// InputLogEvent is a log event.
+type InputLogEvent struct {
+	Message *string
+
+	// Timestamp is in milliseconds since the Unix epoch.
+	Timestamp *int64
+}
+
+// PutLogEventsInput is the input of PutLogEvents.
+type PutLogEventsInput struct {
+	LogGroupName  *string
+	LogStreamName *string
+	LogEvents     []*InputLogEvent
+
+	// SequenceToken is ignored.
+	SequenceToken *string
+}
+
+// PutLogEventsOutput is the output of PutLogEvents.
+type PutLogEventsOutput struct {
+	NextSequenceToken *string
+}
+
+// PutLogEvents writes log events to Output.
+func (c *CloudWatchLogs) PutLogEvents(input *PutLogEventsInput) (*PutLogEventsOutput, error) {
+	if aws.StringValue(input.LogGroupName) == "" || aws.StringValue(input.LogStreamName) == "" {
+		return nil, awserr.New(ErrCodeInvalidParameterException, "missing LogGroupName or LogStreamName", nil)
+	}
+
+	labels, _ := json.Marshal(map[string]string{
+		"log_group":  *input.LogGroupName,
+		"log_stream": *input.LogStreamName,
+	})
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range input.LogEvents {
+		if e == nil || e.Message == nil {
+			continue
+		}
+
+		entry := make(map[string]json.RawMessage)
+		if json.Unmarshal([]byte(*e.Message), &entry) != nil {
+			entry = make(map[string]json.RawMessage)
+			entry["message"], _ = json.Marshal(*e.Message)
+		}
+		if e.Timestamp != nil {
+			t := time.Unix(0, *e.Timestamp*int64(time.Millisecond)).UTC()
+			entry["timestamp"], _ = json.Marshal(t.Format(time.RFC3339Nano))
+		}
+		entry["logging.googleapis.com/labels"] = labels
+		enc.Encode(entry)
+	}
+
+	outputMtx.Lock()
+	defer outputMtx.Unlock()
+	if _, err := Output.Write(buf.Bytes()); err != nil {
+		return nil, awserr.New(ErrCodeServiceUnavailableException, "write failed", err)
+	}
+	return &PutLogEventsOutput{
+		NextSequenceToken: aws.String(strconv.FormatInt(time.Now().UnixNano(), 10)),
+	}, nil
+}
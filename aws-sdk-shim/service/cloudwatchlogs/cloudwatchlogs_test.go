@@ -0,0 +1,81 @@
+package cloudwatchlogs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+func TestCloudWatchLogs_PutLogEvents(t *testing.T) {
+	var buf bytes.Buffer
+	defer func() { Output = os.Stdout }()
+	Output = &buf
+
+	_, err := New(nil).PutLogEvents(&PutLogEventsInput{
+		LogGroupName:  aws.String("group"),
+		LogStreamName: aws.String("stream"),
+		LogEvents: []*InputLogEvent{
+			{Message: aws.String("hello"), Timestamp: aws.Int64(1700000000123)},
+			{Message: aws.String(`{"severity": "ERROR", "message": "structured"}`)},
+			nil,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"logging.googleapis.com/labels":{"log_group":"group","log_stream":"stream"},"message":"hello","timestamp":"2023-11-14T22:13:20.123Z"}
+{"logging.googleapis.com/labels":{"log_group":"group","log_stream":"stream"},"message":"structured","severity":"ERROR"}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("closed") }
+
+func TestCloudWatchLogs_PutLogEvents_errors(t *testing.T) {
+	logs := New(nil)
+
+	_, err := logs.PutLogEvents(&PutLogEventsInput{LogGroupName: aws.String("group")})
+	if e, ok := err.(awserr.Error); !ok || e.Code() != ErrCodeInvalidParameterException {
+		t.Errorf("PutLogEvents() = %v, want InvalidParameterException", err)
+	}
+
+	defer func() { Output = os.Stdout }()
+	Output = failingWriter{}
+	_, err = logs.PutLogEvents(&PutLogEventsInput{
+		LogGroupName:  aws.String("group"),
+		LogStreamName: aws.String("stream"),
+		LogEvents:     []*InputLogEvent{{Message: aws.String("hello")}},
+	})
+	if e, ok := err.(awserr.Error); !ok || e.Code() != ErrCodeServiceUnavailableException {
+		t.Errorf("PutLogEvents() = %v, want ServiceUnavailableException", err)
+	}
+}
+
+func TestCloudWatchLogs_Create(t *testing.T) {
+	logs := New(nil)
+
+	if _, err := logs.CreateLogGroup(&CreateLogGroupInput{LogGroupName: aws.String("group")}); err != nil {
+		t.Error(err)
+	}
+	if _, err := logs.CreateLogGroup(&CreateLogGroupInput{}); err == nil {
+		t.Error("CreateLogGroup() without a name succeeded")
+	}
+	if _, err := logs.CreateLogStream(&CreateLogStreamInput{
+		LogGroupName:  aws.String("group"),
+		LogStreamName: aws.String("stream"),
+	}); err != nil {
+		t.Error(err)
+	}
+	if _, err := logs.CreateLogStream(&CreateLogStreamInput{LogGroupName: aws.String("group")}); err == nil {
+		t.Error("CreateLogStream() without a stream succeeded")
+	}
+}
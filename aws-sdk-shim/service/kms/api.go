@@ -0,0 +1,162 @@
+package kms
+
+import (
+	"crypto/rand"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+// EncryptInput is the input of Encrypt.
+type EncryptInput struct {
+	// KeyId is the key's ID, ARN, alias, or resource name.
+	KeyId *string
+
+	Plaintext         []byte
+	EncryptionContext map[string]*string
+}
+
+// EncryptOutput is the output of Encrypt.
+type EncryptOutput struct {
+	// KeyId is the resource name of the crypto key.
+	KeyId          *string
+	CiphertextBlob []byte
+}
+
+// Encrypt encrypts plaintext with a crypto key.
+func (c *KMS) Encrypt(input *EncryptInput) (*EncryptOutput, error) {
+	name, err := keyName(input.KeyId)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := c.encrypt(name, input.Plaintext, input.EncryptionContext)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptOutput{
+		KeyId:          aws.String(name),
+		CiphertextBlob: wrap(name, ciphertext),
+	}, nil
+}
+
+// DecryptInput is the input of Decrypt.
+type DecryptInput struct {
+	// KeyId is optional, if set it must match
+	// the crypto key that encrypted the blob.
+	KeyId *string
+
+	CiphertextBlob    []byte
+	EncryptionContext map[string]*string
+}
+
+// DecryptOutput is the output of Decrypt.
+type DecryptOutput struct {
+	// KeyId is the resource name of the crypto key.
+	KeyId     *string
+	Plaintext []byte
+}
+
+// Decrypt decrypts a ciphertext blob produced by Encrypt, or GenerateDataKey.
+func (c *KMS) Decrypt(input *DecryptInput) (*DecryptOutput, error) {
+	name, ciphertext, err := unwrap(input.CiphertextBlob)
+	if err != nil {
+		return nil, err
+	}
+	if input.KeyId != nil {
+		want, err := keyName(input.KeyId)
+		if err != nil {
+			return nil, err
+		}
+		if want != name {
+			return nil, awserr.New(ErrCodeIncorrectKeyException, "ciphertext was encrypted with "+name, nil)
+		}
+	}
+
+	var res struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	req := struct {
+		Ciphertext                  []byte `json:"ciphertext"`
+		AdditionalAuthenticatedData []byte `json:"additionalAuthenticatedData,omitempty"`
+	}{ciphertext, additionalData(input.EncryptionContext)}
+	if err := gcp.CallAs(c.token, "POST", c.endpoint+name+":decrypt", req, &res); err != nil {
+		return nil, apiError(err)
+	}
+	return &DecryptOutput{
+		KeyId:     aws.String(name),
+		Plaintext: res.Plaintext,
+	}, nil
+}
+
+// GenerateDataKeyInput is the input of GenerateDataKey.
+type GenerateDataKeyInput struct {
+	// KeyId is the key's ID, ARN, alias, or resource name.
+	KeyId *string
+
+	// Either KeySpec (AES_256 or AES_128), or NumberOfBytes.
+	KeySpec       *string
+	NumberOfBytes *int64
+
+	EncryptionContext map[string]*string
+}
+
+// GenerateDataKeyOutput is the output of GenerateDataKey.
+type GenerateDataKeyOutput struct {
+	// KeyId is the resource name of the crypto key.
+	KeyId          *string
+	Plaintext      []byte
+	CiphertextBlob []byte
+}
+
+// GenerateDataKey generates a random data key locally,
+// and returns it in plaintext, and encrypted with a crypto key.
+func (c *KMS) GenerateDataKey(input *GenerateDataKeyInput) (*GenerateDataKeyOutput, error) {
+	name, err := keyName(input.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	var size int64
+	switch {
+	case input.KeySpec != nil && input.NumberOfBytes != nil:
+		return nil, awserr.New(ErrCodeValidationException, "both KeySpec and NumberOfBytes set", nil)
+	case aws.StringValue(input.KeySpec) == DataKeySpecAes256:
+		size = 32
+	case aws.StringValue(input.KeySpec) == DataKeySpecAes128:
+		size = 16
+	case input.NumberOfBytes != nil:
+		size = *input.NumberOfBytes
+	}
+	if size < 1 || size > 1024 {
+		return nil, awserr.New(ErrCodeValidationException, "invalid KeySpec or NumberOfBytes", nil)
+	}
+
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, awserr.New(ErrCodeInternalException, "random data key", err)
+	}
+	ciphertext, err := c.encrypt(name, key, input.EncryptionContext)
+	if err != nil {
+		return nil, err
+	}
+	return &GenerateDataKeyOutput{
+		KeyId:          aws.String(name),
+		Plaintext:      key,
+		CiphertextBlob: wrap(name, ciphertext),
+	}, nil
+}
+
+func (c *KMS) encrypt(name string, plaintext []byte, encryptionContext map[string]*string) ([]byte, error) {
+	var res struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	req := struct {
+		Plaintext                   []byte `json:"plaintext"`
+		AdditionalAuthenticatedData []byte `json:"additionalAuthenticatedData,omitempty"`
+	}{plaintext, additionalData(encryptionContext)}
+	if err := gcp.CallAs(c.token, "POST", c.endpoint+name+":encrypt", req, &res); err != nil {
+		return nil, apiError(err)
+	}
+	return res.Ciphertext, nil
+}
@@ -0,0 +1,146 @@
+// Package kms implements a subset of the AWS KMS API,
+// backed by Google Cloud KMS.
+//
+// Key IDs, key ARNs, and aliases are translated to crypto keys in KeyRing:
+// characters not allowed by Cloud KMS are replaced by _.
+// Resource names (projects/PROJECT/locations/LOCATION/keyRings/RING/cryptoKeys/KEY)
+// are used as is.
+//
+// Ciphertext blobs embed the name of the crypto key,
+// so Decrypt does not require a KeyId.
+// Encryption contexts are authenticated as additional data.
+package kms
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+// Error codes.
+const (
+	ErrCodeNotFoundException          = "NotFoundException"
+	ErrCodeInvalidArnException        = "InvalidArnException"
+	ErrCodeInvalidCiphertextException = "InvalidCiphertextException"
+	ErrCodeIncorrectKeyException      = "IncorrectKeyException"
+	ErrCodeDisabledException          = "DisabledException"
+	ErrCodeAccessDeniedException      = "AccessDeniedException"
+	ErrCodeValidationException        = "ValidationException"
+	ErrCodeInternalException          = "KMSInternalException"
+)
+
+// Data key specs.
+const (
+	DataKeySpecAes256 = "AES_256"
+	DataKeySpecAes128 = "AES_128"
+)
+
+// KeyRing is the key ring where key IDs and aliases are looked up.
+// The default is the "aws" key ring, in the global location,
+// of the current project.
+var KeyRing string
+
+// ServiceName is the name of the service, used to look up its endpoint.
+const ServiceName = "kms"
+
+// KMS is a KMS client.
+type KMS struct {
+	endpoint string
+	token    func() (string, error)
+}
+
+// New creates a KMS client.
+func New(p client.ConfigProvider, cfgs ...*aws.Config) *KMS {
+	c := p.ClientConfig(ServiceName, cfgs...)
+	return &KMS{endpoint: c.Endpoint, token: c.Token}
+}
+
+var invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// keyName translates a key ID to a crypto key resource name.
+func keyName(id *string) (string, error) {
+	if id == nil || *id == "" {
+		return "", awserr.New(ErrCodeValidationException, "missing KeyId", nil)
+	}
+	name := *id
+	if strings.HasPrefix(name, "projects/") {
+		return name, nil
+	}
+	if strings.HasPrefix(name, "arn:") {
+		parts := strings.SplitN(name, ":", 6)
+		if len(parts) != 6 {
+			return "", awserr.New(ErrCodeInvalidArnException, "invalid ARN: "+name, nil)
+		}
+		name = parts[5]
+		name = strings.TrimPrefix(name, "key/")
+	}
+	name = strings.TrimPrefix(name, "alias/")
+
+	ring := KeyRing
+	if ring == "" {
+		project, err := gcp.ProjectID()
+		if err != nil {
+			return "", awserr.New(ErrCodeInternalException, "project not found", err)
+		}
+		ring = "projects/" + project + "/locations/global/keyRings/aws"
+	}
+	return ring + "/cryptoKeys/" + invalidChars.ReplaceAllString(name, "_"), nil
+}
+
+// additionalData serializes an encryption context,
+// sorted by key, so it can be authenticated.
+func additionalData(ctx map[string]*string) []byte {
+	if len(ctx) == 0 {
+		return nil
+	}
+	buf, err := json.Marshal(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return buf
+}
+
+// wrap embeds the crypto key name in a ciphertext blob.
+func wrap(name string, ciphertext []byte) []byte {
+	blob := make([]byte, 2, 2+len(name)+len(ciphertext))
+	binary.BigEndian.PutUint16(blob, uint16(len(name)))
+	blob = append(blob, name...)
+	return append(blob, ciphertext...)
+}
+
+// unwrap extracts the crypto key name from a ciphertext blob.
+func unwrap(blob []byte) (name string, ciphertext []byte, err error) {
+	if len(blob) > 2 {
+		n := int(binary.BigEndian.Uint16(blob))
+		if len(blob) > 2+n {
+			return string(blob[2 : 2+n]), blob[2+n:], nil
+		}
+	}
+	return "", nil, awserr.New(ErrCodeInvalidCiphertextException, "invalid ciphertext blob", nil)
+}
+
+// apiError translates a Cloud KMS error.
+func apiError(err error) error {
+	e, ok := err.(*gcp.Error)
+	if !ok {
+		return awserr.New(ErrCodeInternalException, err.Error(), err)
+	}
+	switch e.Code {
+	case http.StatusNotFound:
+		return awserr.New(ErrCodeNotFoundException, e.Error(), err)
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return awserr.New(ErrCodeAccessDeniedException, e.Error(), err)
+	case http.StatusBadRequest:
+		return awserr.New(ErrCodeInvalidCiphertextException, e.Error(), err)
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return awserr.New(ErrCodeDisabledException, e.Error(), err)
+	}
+	return awserr.New(ErrCodeInternalException, e.Error(), err)
+}
@@ -0,0 +1,108 @@
+package s3
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// GetObjectInput is the input of GetObject.
+type GetObjectInput struct {
+	Bucket *string
+	Key    *string
+
+	// Override response headers.
+	ResponseCacheControl       *string
+	ResponseContentDisposition *string
+	ResponseContentEncoding    *string
+	ResponseContentLanguage    *string
+	ResponseContentType        *string
+}
+
+// GetObjectOutput is the output of GetObject.
+type GetObjectOutput struct{}
+
+// GetObjectRequest returns a request to download an object.
+func (c *S3) GetObjectRequest(input *GetObjectInput) (*request.Request, *GetObjectOutput) {
+	query := url.Values{}
+	setQuery(query, "response-cache-control", input.ResponseCacheControl)
+	setQuery(query, "response-content-disposition", input.ResponseContentDisposition)
+	setQuery(query, "response-content-encoding", input.ResponseContentEncoding)
+	setQuery(query, "response-content-language", input.ResponseContentLanguage)
+	setQuery(query, "response-content-type", input.ResponseContentType)
+
+	op := &request.Operation{Name: "GetObject", HTTPMethod: http.MethodGet, HTTPPath: "/{Bucket}/{Key+}"}
+	out := &GetObjectOutput{}
+	return c.newRequest(op, input.Bucket, input.Key, query, http.Header{}, input, out), out
+}
+
+// HeadObjectInput is the input of HeadObject.
+type HeadObjectInput struct {
+	Bucket *string
+	Key    *string
+}
+
+// HeadObjectOutput is the output of HeadObject.
+type HeadObjectOutput struct{}
+
+// HeadObjectRequest returns a request to get an object's metadata.
+func (c *S3) HeadObjectRequest(input *HeadObjectInput) (*request.Request, *HeadObjectOutput) {
+	op := &request.Operation{Name: "HeadObject", HTTPMethod: http.MethodHead, HTTPPath: "/{Bucket}/{Key+}"}
+	out := &HeadObjectOutput{}
+	return c.newRequest(op, input.Bucket, input.Key, url.Values{}, http.Header{}, input, out), out
+}
+
+// PutObjectInput is the input of PutObject.
+type PutObjectInput struct {
+	Bucket *string
+	Key    *string
+
+	// If set, the upload must be sent with these headers.
+	CacheControl       *string
+	ContentDisposition *string
+	ContentType        *string
+}
+
+// PutObjectOutput is the output of PutObject.
+type PutObjectOutput struct{}
+
+// PutObjectRequest returns a request to upload an object.
+func (c *S3) PutObjectRequest(input *PutObjectInput) (*request.Request, *PutObjectOutput) {
+	header := http.Header{}
+	setHeader(header, "Cache-Control", input.CacheControl)
+	setHeader(header, "Content-Disposition", input.ContentDisposition)
+	setHeader(header, "Content-Type", input.ContentType)
+
+	op := &request.Operation{Name: "PutObject", HTTPMethod: http.MethodPut, HTTPPath: "/{Bucket}/{Key+}"}
+	out := &PutObjectOutput{}
+	return c.newRequest(op, input.Bucket, input.Key, url.Values{}, header, input, out), out
+}
+
+// DeleteObjectInput is the input of DeleteObject.
+type DeleteObjectInput struct {
+	Bucket *string
+	Key    *string
+}
+
+// DeleteObjectOutput is the output of DeleteObject.
+type DeleteObjectOutput struct{}
+
+// DeleteObjectRequest returns a request to delete an object.
+func (c *S3) DeleteObjectRequest(input *DeleteObjectInput) (*request.Request, *DeleteObjectOutput) {
+	op := &request.Operation{Name: "DeleteObject", HTTPMethod: http.MethodDelete, HTTPPath: "/{Bucket}/{Key+}"}
+	out := &DeleteObjectOutput{}
+	return c.newRequest(op, input.Bucket, input.Key, url.Values{}, http.Header{}, input, out), out
+}
+
+func setQuery(query url.Values, key string, value *string) {
+	if value != nil {
+		query.Set(key, *value)
+	}
+}
+
+func setHeader(header http.Header, key string, value *string) {
+	if value != nil {
+		header.Set(key, *value)
+	}
+}
@@ -0,0 +1,228 @@
+package s3
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+const maxExpire = 7 * 24 * time.Hour
+
+// timeNow is the clock used to sign URLs.
+var timeNow = time.Now
+
+// presign signs r using the V4 signing process of Cloud Storage.
+func presign(r *request.Request, expire time.Duration) (string, http.Header, error) {
+	if expire <= 0 || expire > maxExpire {
+		return "", nil, awserr.New("InvalidParameter", "expire must be between 1 second and 7 days", nil)
+	}
+
+	s, err := getSigner()
+	if err != nil {
+		return "", nil, awserr.New("SigningError", "no signer", err)
+	}
+
+	now := timeNow().UTC()
+	date := now.Format("20060102")
+	timestamp := now.Format("20060102T150405Z")
+	scope := date + "/auto/storage/goog4_request"
+
+	// Signed headers: host, and those that must be sent.
+	headers := map[string]string{"host": r.HTTPRequest.URL.Host}
+	for k := range r.HTTPRequest.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(r.HTTPRequest.Header.Get(k))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := r.HTTPRequest.URL.Query()
+	query.Set("X-Goog-Algorithm", "GOOG4-RSA-SHA256")
+	query.Set("X-Goog-Credential", s.email+"/"+scope)
+	query.Set("X-Goog-Date", timestamp)
+	query.Set("X-Goog-Expires", strconv.FormatInt(int64((expire+time.Second-1)/time.Second), 10))
+	query.Set("X-Goog-SignedHeaders", signedHeaders)
+	canonicalQuery := encodeQuery(query)
+	canonicalPath := escape(r.HTTPRequest.URL.Path, true)
+
+	canonicalRequest := strings.Join([]string{
+		r.HTTPRequest.Method,
+		canonicalPath,
+		canonicalQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+
+	stringToSign := "GOOG4-RSA-SHA256\n" + timestamp + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	signature, err := s.sign([]byte(stringToSign))
+	if err != nil {
+		return "", nil, awserr.New("SigningError", "sign failed", err)
+	}
+
+	u := *r.HTTPRequest.URL
+	u.RawPath = canonicalPath
+	u.RawQuery = canonicalQuery + "&X-Goog-Signature=" + hex.EncodeToString(signature)
+
+	var header http.Header
+	if len(r.HTTPRequest.Header) != 0 {
+		header = r.HTTPRequest.Header
+	}
+	return u.String(), header, nil
+}
+
+// escape percent-encodes s as specified by RFC 3986,
+// leaving slashes if path is true.
+func escape(s string, path bool) string {
+	const hex = "0123456789ABCDEF"
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', path && c == '/':
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte('%')
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&15])
+		}
+	}
+	return buf.String()
+}
+
+// encodeQuery encodes query, sorted by key, as specified by RFC 3986.
+func encodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, escape(k, false)+"="+escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+type signer struct {
+	email string
+	key   *rsa.PrivateKey // nil signs with IAM.
+}
+
+var (
+	signerMtx sync.Mutex
+	signerVal *signer
+)
+
+// getSigner loads a service account key from GOOGLE_APPLICATION_CREDENTIALS,
+// or uses the default service account to sign with IAM.
+func getSigner() (*signer, error) {
+	signerMtx.Lock()
+	defer signerMtx.Unlock()
+	if signerVal != nil {
+		return signerVal, nil
+	}
+
+	if file := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); file != "" {
+		s, err := keySigner(file)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			signerVal = s
+			return s, nil
+		}
+	}
+	email, err := gcp.Metadata("instance/service-accounts/default/email")
+	if err != nil {
+		return nil, err
+	}
+	signerVal = &signer{email: email}
+	return signerVal, nil
+}
+
+// keySigner loads a service account key,
+// or returns nil if file has other credentials.
+func keySigner(file string) (*signer, error) {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var creds struct {
+		Type        string `json:"type"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+	}
+	if err := json.Unmarshal(buf, &creds); err != nil {
+		return nil, err
+	}
+	if creds.Type != "service_account" {
+		return nil, nil
+	}
+
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return nil, errors.New("s3: invalid private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("s3: private key is not RSA")
+	}
+	return &signer{email: creds.ClientEmail, key: rsaKey}, nil
+}
+
+func (s *signer) sign(data []byte) ([]byte, error) {
+	if s.key != nil {
+		hash := sha256.Sum256(data)
+		return rsa.SignPKCS1v15(nil, s.key, crypto.SHA256, hash[:])
+	}
+
+	var res struct {
+		SignedBlob []byte `json:"signedBlob"`
+	}
+	req := struct {
+		Payload []byte `json:"payload"`
+	}{data}
+	err := gcp.Call("POST", "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/"+url.PathEscape(s.email)+":signBlob", req, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res.SignedBlob, nil
+}
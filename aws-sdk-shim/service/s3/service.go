@@ -0,0 +1,66 @@
+// Package s3 implements the presign APIs of AWS S3,
+// generating V4 signed URLs for Google Cloud Storage.
+//
+// Buckets and keys are Cloud Storage buckets and object names.
+// URLs are signed by the service account in GOOGLE_APPLICATION_CREDENTIALS,
+// if it's a service account key, or by the default service account,
+// through the IAM signBlob API
+// (which requires the iam.serviceAccounts.signBlob permission).
+//
+// As with S3, URLs expire after at most 7 days.
+package s3
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ServiceName is the name of the service, used to look up its endpoint.
+const ServiceName = "s3"
+
+// S3 is an S3 client.
+type S3 struct {
+	endpoint string
+}
+
+// New creates an S3 client.
+func New(p client.ConfigProvider, cfgs ...*aws.Config) *S3 {
+	c := p.ClientConfig(ServiceName, cfgs...)
+	return &S3{endpoint: c.Endpoint}
+}
+
+func (c *S3) newRequest(op *request.Operation, bucket, key *string, query url.Values, header http.Header, params, data interface{}) *request.Request {
+	if aws.StringValue(bucket) == "" {
+		r := request.New(op, nil, params, data, nil)
+		r.Error = awserr.New("InvalidParameter", "missing Bucket", nil)
+		return r
+	}
+	if aws.StringValue(key) == "" {
+		r := request.New(op, nil, params, data, nil)
+		r.Error = awserr.New("InvalidParameter", "missing Key", nil)
+		return r
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(c.endpoint, "/"))
+	if err != nil {
+		r := request.New(op, nil, params, data, nil)
+		r.Error = awserr.New("InvalidParameter", "invalid endpoint", err)
+		return r
+	}
+	u.Path += "/" + *bucket + "/" + *key
+	u.RawQuery = query.Encode()
+
+	req := &http.Request{
+		Method: op.HTTPMethod,
+		URL:    u,
+		Host:   u.Host,
+		Header: header,
+	}
+	return request.New(op, req, params, data, presign)
+}
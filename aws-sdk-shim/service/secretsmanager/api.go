@@ -0,0 +1,150 @@
+package secretsmanager
+
+import (
+	"encoding/base64"
+	"path"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+// GetSecretValueInput is the input of GetSecretValue.
+type GetSecretValueInput struct {
+	// SecretId is the secret's name, ARN, or resource name.
+	SecretId *string
+
+	// VersionId is a version number.
+	VersionId *string
+
+	// VersionStage is a staging label, the default is AWSCURRENT.
+	VersionStage *string
+}
+
+// GetSecretValueOutput is the output of GetSecretValue.
+type GetSecretValueOutput struct {
+	ARN           *string
+	Name          *string
+	VersionId     *string
+	VersionStages []*string
+
+	// SecretString is set if the secret is valid UTF-8,
+	// SecretBinary otherwise.
+	SecretString *string
+	SecretBinary []byte
+}
+
+// GetSecretValue returns the value of a version of a secret.
+func (c *SecretsManager) GetSecretValue(input *GetSecretValueInput) (*GetSecretValueOutput, error) {
+	name, err := secretName(input.SecretId)
+	if err != nil {
+		return nil, err
+	}
+
+	version := "latest"
+	stages := []*string{aws.String(StageCurrent)}
+	if v := aws.StringValue(input.VersionId); v != "" {
+		version, stages = v, nil
+	} else if s := aws.StringValue(input.VersionStage); s != "" && s != StageCurrent {
+		// Other stages are version aliases.
+		version, stages = s, []*string{aws.String(s)}
+	}
+
+	var res struct {
+		Name    string `json:"name"`
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := gcp.CallAs(c.token, "GET", c.endpoint+name+"/versions/"+version+":access", nil, &res); err != nil {
+		return nil, apiError(err)
+	}
+	data, err := base64.StdEncoding.DecodeString(res.Payload.Data)
+	if err != nil {
+		return nil, awserr.New(ErrCodeInternalServiceError, "invalid payload", err)
+	}
+
+	out := &GetSecretValueOutput{
+		ARN:           aws.String(name),
+		Name:          aws.String(path.Base(name)),
+		VersionId:     aws.String(path.Base(res.Name)),
+		VersionStages: stages,
+	}
+	if utf8.Valid(data) {
+		out.SecretString = aws.String(string(data))
+	} else {
+		out.SecretBinary = data
+	}
+	return out, nil
+}
+
+// DescribeSecretInput is the input of DescribeSecret.
+type DescribeSecretInput struct {
+	// SecretId is the secret's name, ARN, or resource name.
+	SecretId *string
+}
+
+// DescribeSecretOutput is the output of DescribeSecret.
+type DescribeSecretOutput struct {
+	ARN         *string
+	Name        *string
+	CreatedDate *time.Time
+	Tags        []*Tag
+
+	// VersionIdsToStages maps version numbers to staging labels:
+	// AWSCURRENT for the latest enabled version, and version aliases.
+	VersionIdsToStages map[string][]*string
+}
+
+// A Tag is a secret label.
+type Tag struct {
+	Key   *string
+	Value *string
+}
+
+// DescribeSecret describes a secret.
+func (c *SecretsManager) DescribeSecret(input *DescribeSecretInput) (*DescribeSecretOutput, error) {
+	name, err := secretName(input.SecretId)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret struct {
+		CreateTime     time.Time         `json:"createTime"`
+		Labels         map[string]string `json:"labels"`
+		VersionAliases map[string]string `json:"versionAliases"`
+	}
+	if err := gcp.CallAs(c.token, "GET", c.endpoint+name, nil, &secret); err != nil {
+		return nil, apiError(err)
+	}
+
+	var versions struct {
+		Versions []struct {
+			Name string `json:"name"`
+		} `json:"versions"`
+	}
+	// Versions are listed newest first.
+	if err := gcp.CallAs(c.token, "GET", c.endpoint+name+"/versions?pageSize=1&filter=state:ENABLED", nil, &versions); err != nil {
+		return nil, apiError(err)
+	}
+
+	out := &DescribeSecretOutput{
+		ARN:                aws.String(name),
+		Name:               aws.String(path.Base(name)),
+		CreatedDate:        aws.Time(secret.CreateTime),
+		VersionIdsToStages: map[string][]*string{},
+	}
+	for k, v := range secret.Labels {
+		out.Tags = append(out.Tags, &Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	if len(versions.Versions) > 0 {
+		id := path.Base(versions.Versions[0].Name)
+		out.VersionIdsToStages[id] = append(out.VersionIdsToStages[id], aws.String(StageCurrent))
+	}
+	for alias, id := range secret.VersionAliases {
+		out.VersionIdsToStages[id] = append(out.VersionIdsToStages[id], aws.String(alias))
+	}
+	return out, nil
+}
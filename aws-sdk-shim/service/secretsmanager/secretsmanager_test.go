@@ -0,0 +1,169 @@
+package secretsmanager
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/internal/gcptest"
+)
+
+func TestMain(m *testing.M) {
+	// A fake metadata server, to authorize requests.
+	metadata := gcptest.NewMetadataServer(nil)
+	code := m.Run()
+	metadata.Close()
+	os.Exit(code)
+}
+
+func TestSecretName(t *testing.T) {
+	tests := []struct{ id, name string }{
+		{"db-password", "projects/project/secrets/db-password"},
+		{"prod/db/password", "projects/project/secrets/prod_db_password"},
+		{"arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf", "projects/project/secrets/prod_db"},
+		{"projects/other/secrets/name", "projects/other/secrets/name"},
+	}
+	for _, tt := range tests {
+		got, err := secretName(aws.String(tt.id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.name {
+			t.Errorf("secretName(%q) = %q, want %q", tt.id, got, tt.name)
+		}
+	}
+
+	_, err := secretName(nil)
+	if e, ok := err.(awserr.Error); !ok || e.Code() != ErrCodeInvalidParameterException {
+		t.Errorf("secretName(nil) = %v, want InvalidParameterException", err)
+	}
+}
+
+func newClient(handler http.HandlerFunc) (*SecretsManager, func()) {
+	server := httptest.NewServer(handler)
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:   aws.String("us-east-1"),
+		Endpoint: aws.String(server.URL + "/v1"),
+	}))
+	return New(sess), server.Close
+}
+
+func notFound(w http.ResponseWriter) {
+	http.Error(w, `{"error":{"code":404,"message":"not found"}}`, http.StatusNotFound)
+}
+
+func TestSecretsManager_GetSecretValue(t *testing.T) {
+	versions := map[string]string{
+		"latest": "3",
+		"1":      "1",
+		"stable": "2",
+	}
+	payloads := map[string][]byte{
+		"1": []byte("one"),
+		"2": {0xff, 0xfe},
+		"3": []byte("three"),
+	}
+
+	client, close := newClient(func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "/v1/projects/project/secrets/prod_db/versions/"
+		if r.Header.Get("Authorization") != "Bearer token" ||
+			!strings.HasPrefix(r.URL.Path, prefix) || !strings.HasSuffix(r.URL.Path, ":access") {
+			notFound(w)
+			return
+		}
+		version, ok := versions[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefix), ":access")]
+		if !ok {
+			notFound(w)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"name": "projects/123/secrets/prod_db/versions/" + version,
+			"payload": map[string]string{
+				"data": base64.StdEncoding.EncodeToString(payloads[version]),
+			},
+		})
+	})
+	defer close()
+
+	id := aws.String("arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf")
+
+	out, err := client.GetSecretValue(&GetSecretValueInput{SecretId: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(out.SecretString) != "three" || aws.StringValue(out.VersionId) != "3" ||
+		len(out.VersionStages) != 1 || aws.StringValue(out.VersionStages[0]) != StageCurrent ||
+		aws.StringValue(out.Name) != "prod_db" {
+		t.Errorf("GetSecretValue() = %+v", out)
+	}
+
+	out, err = client.GetSecretValue(&GetSecretValueInput{SecretId: id, VersionId: aws.String("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(out.SecretString) != "one" || len(out.VersionStages) != 0 {
+		t.Errorf("GetSecretValue(1) = %+v", out)
+	}
+
+	// Binary secrets, and version aliases as staging labels.
+	out, err = client.GetSecretValue(&GetSecretValueInput{SecretId: id, VersionStage: aws.String("stable")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.SecretString != nil || string(out.SecretBinary) != "\xff\xfe" || aws.StringValue(out.VersionStages[0]) != "stable" {
+		t.Errorf("GetSecretValue(stable) = %+v", out)
+	}
+
+	_, err = client.GetSecretValue(&GetSecretValueInput{SecretId: aws.String("missing")})
+	if e, ok := err.(awserr.Error); !ok || e.Code() != ErrCodeResourceNotFoundException {
+		t.Errorf("GetSecretValue(missing) = %v, want ResourceNotFoundException", err)
+	}
+}
+
+func TestSecretsManager_DescribeSecret(t *testing.T) {
+	client, close := newClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/projects/project/secrets/db":
+			w.Write([]byte(`{
+				"createTime": "2024-01-02T03:04:05Z",
+				"labels": {"env": "prod"},
+				"versionAliases": {"stable": "2"}
+			}`))
+		case "/v1/projects/project/secrets/db/versions":
+			if r.URL.Query().Get("filter") != "state:ENABLED" {
+				t.Errorf("filter = %q", r.URL.Query().Get("filter"))
+			}
+			w.Write([]byte(`{"versions": [{"name": "projects/123/secrets/db/versions/3"}]}`))
+		default:
+			notFound(w)
+		}
+	})
+	defer close()
+
+	out, err := client.DescribeSecret(&DescribeSecretInput{SecretId: aws.String("db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(out.Name) != "db" || out.CreatedDate.Year() != 2024 ||
+		len(out.Tags) != 1 || aws.StringValue(out.Tags[0].Key) != "env" || aws.StringValue(out.Tags[0].Value) != "prod" {
+		t.Errorf("DescribeSecret() = %+v", out)
+	}
+	if stages := out.VersionIdsToStages["3"]; len(stages) != 1 || aws.StringValue(stages[0]) != StageCurrent {
+		t.Errorf("VersionIdsToStages[3] = %v", stages)
+	}
+	if stages := out.VersionIdsToStages["2"]; len(stages) != 1 || aws.StringValue(stages[0]) != "stable" {
+		t.Errorf("VersionIdsToStages[2] = %v", stages)
+	}
+
+	_, err = client.DescribeSecret(&DescribeSecretInput{SecretId: aws.String("missing")})
+	if e, ok := err.(awserr.Error); !ok || e.Code() != ErrCodeResourceNotFoundException {
+		t.Errorf("DescribeSecret(missing) = %v, want ResourceNotFoundException", err)
+	}
+}
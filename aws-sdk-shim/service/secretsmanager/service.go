@@ -0,0 +1,96 @@
+// Package secretsmanager implements a subset of the AWS Secrets Manager API,
+// backed by Google Secret Manager.
+//
+// Secret IDs are translated to secret names in the current project:
+// characters not allowed by Secret Manager (like /) are replaced by _,
+// and the random suffix of secret ARNs is dropped.
+// Resource names (projects/PROJECT/secrets/SECRET) are used as is.
+//
+// Versions map to staging labels: AWSCURRENT is the latest version,
+// and other labels are version aliases.
+package secretsmanager
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+// Error codes.
+const (
+	ErrCodeResourceNotFoundException = "ResourceNotFoundException"
+	ErrCodeInvalidParameterException = "InvalidParameterException"
+	ErrCodeInvalidRequestException   = "InvalidRequestException"
+	ErrCodeInternalServiceError      = "InternalServiceError"
+	ErrCodeAccessDeniedException     = "AccessDeniedException"
+)
+
+// Staging labels.
+const (
+	StageCurrent  = "AWSCURRENT"
+	StagePrevious = "AWSPREVIOUS"
+)
+
+// ServiceName is the name of the service, used to look up its endpoint.
+const ServiceName = "secretsmanager"
+
+// SecretsManager is a Secrets Manager client.
+type SecretsManager struct {
+	endpoint string
+	token    func() (string, error)
+}
+
+// New creates a Secrets Manager client.
+func New(p client.ConfigProvider, cfgs ...*aws.Config) *SecretsManager {
+	c := p.ClientConfig(ServiceName, cfgs...)
+	return &SecretsManager{endpoint: c.Endpoint, token: c.Token}
+}
+
+var arnSuffix = regexp.MustCompile(`-[a-zA-Z0-9]{6}$`)
+var invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// secretName translates a secret ID to a secret resource name.
+func secretName(id *string) (string, error) {
+	if id == nil || *id == "" {
+		return "", awserr.New(ErrCodeInvalidParameterException, "missing SecretId", nil)
+	}
+	name := *id
+	if strings.HasPrefix(name, "projects/") {
+		return name, nil
+	}
+	if strings.HasPrefix(name, "arn:") {
+		if i := strings.Index(name, ":secret:"); i >= 0 {
+			name = arnSuffix.ReplaceAllString(name[i+len(":secret:"):], "")
+		}
+	}
+
+	project, err := gcp.ProjectID()
+	if err != nil {
+		return "", awserr.New(ErrCodeInternalServiceError, "project not found", err)
+	}
+	return "projects/" + project + "/secrets/" + invalidChars.ReplaceAllString(name, "_"), nil
+}
+
+// apiError translates a Secret Manager error.
+func apiError(err error) error {
+	e, ok := err.(*gcp.Error)
+	if !ok {
+		return awserr.New(ErrCodeInternalServiceError, err.Error(), err)
+	}
+	switch e.Code {
+	case http.StatusNotFound:
+		return awserr.New(ErrCodeResourceNotFoundException, e.Error(), err)
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return awserr.New(ErrCodeAccessDeniedException, e.Error(), err)
+	case http.StatusBadRequest:
+		return awserr.New(ErrCodeInvalidParameterException, e.Error(), err)
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return awserr.New(ErrCodeInvalidRequestException, e.Error(), err)
+	}
+	return awserr.New(ErrCodeInternalServiceError, e.Error(), err)
+}
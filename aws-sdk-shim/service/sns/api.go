@@ -0,0 +1,91 @@
+package sns
+
+import (
+	"encoding/base64"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+// MessageAttributeValue is the value of a message attribute.
+type MessageAttributeValue struct {
+	// DataType is String, Number, or Binary.
+	DataType    *string
+	StringValue *string
+	BinaryValue []byte
+}
+
+// PublishInput is the input of Publish.
+type PublishInput struct {
+	// TopicArn is the topic's ARN, or resource name.
+	// TargetArn is an alias for TopicArn.
+	TopicArn  *string
+	TargetArn *string
+
+	Message           *string
+	Subject           *string
+	MessageAttributes map[string]*MessageAttributeValue
+
+	// MessageGroupId is used as the ordering key.
+	MessageGroupId *string
+}
+
+// PublishOutput is the output of Publish.
+type PublishOutput struct {
+	MessageId *string
+}
+
+// Publish publishes a message to a topic.
+func (c *SNS) Publish(input *PublishInput) (*PublishOutput, error) {
+	arn := input.TopicArn
+	if arn == nil {
+		arn = input.TargetArn
+	}
+	name, err := topicName(arn)
+	if err != nil {
+		return nil, err
+	}
+	if input.Message == nil {
+		return nil, awserr.New(ErrCodeInvalidParameterException, "missing Message", nil)
+	}
+
+	attrs := map[string]string{}
+	for k, v := range input.MessageAttributes {
+		if v == nil {
+			continue
+		}
+		if aws.StringValue(v.DataType) == "Binary" {
+			attrs[k] = base64.StdEncoding.EncodeToString(v.BinaryValue)
+		} else {
+			attrs[k] = aws.StringValue(v.StringValue)
+		}
+	}
+	if input.Subject != nil {
+		attrs["Subject"] = *input.Subject
+	}
+
+	type message struct {
+		Data        []byte            `json:"data"`
+		Attributes  map[string]string `json:"attributes,omitempty"`
+		OrderingKey string            `json:"orderingKey,omitempty"`
+	}
+	req := struct {
+		Messages []message `json:"messages"`
+	}{[]message{{
+		Data:        []byte(*input.Message),
+		Attributes:  attrs,
+		OrderingKey: aws.StringValue(input.MessageGroupId),
+	}}}
+
+	var res struct {
+		MessageIds []string `json:"messageIds"`
+	}
+	if err := gcp.CallAs(c.token, "POST", c.endpoint+name+":publish", req, &res); err != nil {
+		return nil, apiError(err)
+	}
+	if len(res.MessageIds) != 1 {
+		return nil, awserr.New(ErrCodeInternalErrorException, "unexpected publish response", nil)
+	}
+	return &PublishOutput{MessageId: aws.String(res.MessageIds[0])}, nil
+}
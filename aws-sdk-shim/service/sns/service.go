@@ -0,0 +1,92 @@
+// Package sns implements a subset of the AWS SNS API,
+// backed by Google Cloud Pub/Sub.
+//
+// Topic ARNs are translated to topics in the current project:
+// characters not allowed by Pub/Sub are replaced by _.
+// Resource names (projects/PROJECT/topics/TOPIC) are used as is.
+//
+// Message attributes are carried as Pub/Sub attributes:
+// string and number values as is, binary values base64 encoded.
+// The subject is carried in the Subject attribute,
+// and the message group ID is used as the ordering key.
+package sns
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/internal/gcp"
+)
+
+// Error codes.
+const (
+	ErrCodeNotFoundException           = "NotFound"
+	ErrCodeInvalidParameterException   = "InvalidParameter"
+	ErrCodeAuthorizationErrorException = "AuthorizationError"
+	ErrCodeInternalErrorException      = "InternalError"
+	ErrCodeThrottledException          = "Throttled"
+)
+
+// ServiceName is the name of the service, used to look up its endpoint.
+const ServiceName = "sns"
+
+// SNS is an SNS client.
+type SNS struct {
+	endpoint string
+	token    func() (string, error)
+}
+
+// New creates an SNS client.
+func New(p client.ConfigProvider, cfgs ...*aws.Config) *SNS {
+	c := p.ClientConfig(ServiceName, cfgs...)
+	return &SNS{endpoint: c.Endpoint, token: c.Token}
+}
+
+var invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.~+%-]`)
+
+// topicName translates a topic ARN to a topic resource name.
+func topicName(arn *string) (string, error) {
+	if arn == nil || *arn == "" {
+		return "", awserr.New(ErrCodeInvalidParameterException, "missing TopicArn", nil)
+	}
+	name := *arn
+	if strings.HasPrefix(name, "projects/") {
+		return name, nil
+	}
+	if strings.HasPrefix(name, "arn:") {
+		parts := strings.SplitN(name, ":", 6)
+		if len(parts) != 6 {
+			return "", awserr.New(ErrCodeInvalidParameterException, "invalid ARN: "+name, nil)
+		}
+		name = parts[5]
+	}
+
+	project, err := gcp.ProjectID()
+	if err != nil {
+		return "", awserr.New(ErrCodeInternalErrorException, "project not found", err)
+	}
+	return "projects/" + project + "/topics/" + invalidChars.ReplaceAllString(name, "_"), nil
+}
+
+// apiError translates a Pub/Sub error.
+func apiError(err error) error {
+	e, ok := err.(*gcp.Error)
+	if !ok {
+		return awserr.New(ErrCodeInternalErrorException, err.Error(), err)
+	}
+	switch e.Code {
+	case http.StatusNotFound:
+		return awserr.New(ErrCodeNotFoundException, e.Error(), err)
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return awserr.New(ErrCodeAuthorizationErrorException, e.Error(), err)
+	case http.StatusBadRequest:
+		return awserr.New(ErrCodeInvalidParameterException, e.Error(), err)
+	case http.StatusTooManyRequests:
+		return awserr.New(ErrCodeThrottledException, e.Error(), err)
+	}
+	return awserr.New(ErrCodeInternalErrorException, e.Error(), err)
+}
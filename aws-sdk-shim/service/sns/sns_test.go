@@ -0,0 +1,137 @@
+package sns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/internal/gcptest"
+)
+
+func TestMain(m *testing.M) {
+	// A fake metadata server, to authorize requests.
+	metadata := gcptest.NewMetadataServer(nil)
+	code := m.Run()
+	metadata.Close()
+	os.Exit(code)
+}
+
+func TestSNS_Publish(t *testing.T) {
+	type message struct {
+		Data        []byte            `json:"data"`
+		Attributes  map[string]string `json:"attributes"`
+		OrderingKey string            `json:"orderingKey"`
+	}
+	var path string
+	var published []message
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, `{"error":{"code":401,"message":"unauthorized"}}`, http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Messages []message `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":{"code":400,"message":"bad request"}}`, http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path == "/v1/projects/project/topics/missing:publish" {
+			http.Error(w, `{"error":{"code":404,"message":"topic not found"}}`, http.StatusNotFound)
+			return
+		}
+		path = r.URL.Path
+		published = req.Messages
+		w.Write([]byte(`{"messageIds":["42"]}`))
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:   aws.String("us-east-1"),
+		Endpoint: aws.String(server.URL + "/v1/"),
+	}))
+	client := New(sess)
+	out, err := client.Publish(&PublishInput{
+		TopicArn:       aws.String("arn:aws:sns:us-east-1:123456789012:orders.fifo"),
+		Message:        aws.String("hello"),
+		Subject:        aws.String("greeting"),
+		MessageGroupId: aws.String("group"),
+		MessageAttributes: map[string]*MessageAttributeValue{
+			"kind":  {DataType: aws.String("String"), StringValue: aws.String("test")},
+			"count": {DataType: aws.String("Number"), StringValue: aws.String("3")},
+			"blob":  {DataType: aws.String("Binary"), BinaryValue: []byte{1, 2, 3}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(out.MessageId) != "42" {
+		t.Errorf("MessageId = %q, want %q", aws.StringValue(out.MessageId), "42")
+	}
+
+	if want := "/v1/projects/project/topics/orders.fifo:publish"; path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if len(published) != 1 {
+		t.Fatalf("published %d messages, want 1", len(published))
+	}
+	msg := published[0]
+	if string(msg.Data) != "hello" || msg.OrderingKey != "group" {
+		t.Errorf("published %+v", msg)
+	}
+	want := map[string]string{
+		"kind":    "test",
+		"count":   "3",
+		"blob":    "AQID",
+		"Subject": "greeting",
+	}
+	for k, v := range want {
+		if msg.Attributes[k] != v {
+			t.Errorf("attribute %s = %q, want %q", k, msg.Attributes[k], v)
+		}
+	}
+	if len(msg.Attributes) != len(want) {
+		t.Errorf("attributes = %v, want %v", msg.Attributes, want)
+	}
+
+	_, err = client.Publish(&PublishInput{
+		TargetArn: aws.String("arn:aws:sns:us-east-1:123456789012:missing"),
+		Message:   aws.String("hello"),
+	})
+	if e, ok := err.(awserr.Error); !ok || e.Code() != ErrCodeNotFoundException {
+		t.Errorf("Publish(missing) = %v, want NotFound", err)
+	}
+
+	_, err = client.Publish(&PublishInput{TopicArn: aws.String("orders")})
+	if e, ok := err.(awserr.Error); !ok || e.Code() != ErrCodeInvalidParameterException {
+		t.Errorf("Publish() without a message = %v, want InvalidParameter", err)
+	}
+}
+
+func TestTopicName(t *testing.T) {
+	tests := []struct{ arn, name string }{
+		{"arn:aws:sns:us-east-1:123456789012:orders", "projects/project/topics/orders"},
+		{"arn:aws:sns:us-east-1:123456789012:a/b", "projects/project/topics/a_b"},
+		{"projects/other/topics/events", "projects/other/topics/events"},
+	}
+	for _, tt := range tests {
+		got, err := topicName(aws.String(tt.arn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.name {
+			t.Errorf("topicName(%q) = %q, want %q", tt.arn, got, tt.name)
+		}
+	}
+
+	_, err := topicName(aws.String("arn:aws:sns"))
+	if e, ok := err.(awserr.Error); !ok || e.Code() != ErrCodeInvalidParameterException {
+		t.Errorf("topicName() = %v, want InvalidParameter", err)
+	}
+}
@@ -0,0 +1,74 @@
+// Package aws provides the configuration shared by service clients.
+package aws
+
+import "net/http"
+
+// A Config holds the configuration shared by service clients.
+type Config struct {
+	// Region is the AWS region.
+	Region string
+
+	// Credentials provides AWS credentials.
+	// Shimmed services authorize with Google Cloud credentials instead.
+	Credentials CredentialsProvider
+
+	// HTTPClient sends API requests.
+	// Wrap its transport to instrument, or modify, requests.
+	HTTPClient HTTPClient
+
+	// BaseEndpoint overrides the Google Cloud API endpoint
+	// of shimmed services (for emulators, or private endpoints).
+	BaseEndpoint *string
+
+	// EndpointResolverWithOptions resolves service endpoints,
+	// it's used if BaseEndpoint is not set.
+	EndpointResolverWithOptions EndpointResolverWithOptions
+}
+
+// NewConfig returns an empty Config.
+func NewConfig() *Config {
+	return &Config{}
+}
+
+// Copy returns a shallow copy of c.
+func (c Config) Copy() Config {
+	return c
+}
+
+// An HTTPClient sends HTTP requests.
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// An Endpoint is a service endpoint.
+type Endpoint struct {
+	URL string
+}
+
+// An EndpointResolverWithOptions resolves service endpoints.
+type EndpointResolverWithOptions interface {
+	ResolveEndpoint(service, region string, options ...interface{}) (Endpoint, error)
+}
+
+// EndpointResolverWithOptionsFunc adapts a function to an EndpointResolverWithOptions.
+type EndpointResolverWithOptionsFunc func(service, region string, options ...interface{}) (Endpoint, error)
+
+// ResolveEndpoint calls fn.
+func (fn EndpointResolverWithOptionsFunc) ResolveEndpoint(service, region string, options ...interface{}) (Endpoint, error) {
+	return fn(service, region, options...)
+}
+
+// EndpointNotFoundError is returned by an EndpointResolverWithOptions
+// to fall back to the default endpoint.
+type EndpointNotFoundError struct {
+	Err error
+}
+
+func (e *EndpointNotFoundError) Error() string {
+	return "endpoint not found"
+}
+
+// Unwrap returns the underlying error.
+func (e *EndpointNotFoundError) Unwrap() error {
+	return e.Err
+}
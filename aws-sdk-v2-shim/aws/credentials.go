@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Credentials are AWS credentials.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Source is the name of the provider that retrieved the credentials.
+	Source string
+
+	// CanExpire is true if the credentials expire at Expires.
+	CanExpire bool
+	Expires   time.Time
+}
+
+// Expired reports whether the credentials have expired.
+func (v Credentials) Expired() bool {
+	return v.CanExpire && !time.Now().Before(v.Expires)
+}
+
+// HasKeys reports whether the credentials have keys.
+func (v Credentials) HasKeys() bool {
+	return v.AccessKeyID != "" && v.SecretAccessKey != ""
+}
+
+// A CredentialsProvider retrieves credentials.
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsProviderFunc adapts a function to a CredentialsProvider.
+type CredentialsProviderFunc func(context.Context) (Credentials, error)
+
+// Retrieve calls fn.
+func (fn CredentialsProviderFunc) Retrieve(ctx context.Context) (Credentials, error) {
+	return fn(ctx)
+}
+
+// AnonymousCredentials provides empty credentials.
+type AnonymousCredentials struct{}
+
+// Retrieve returns empty credentials.
+func (AnonymousCredentials) Retrieve(context.Context) (Credentials, error) {
+	return Credentials{Source: "AnonymousCredentials"}, nil
+}
+
+// CredentialsCacheOptions configures a CredentialsCache.
+type CredentialsCacheOptions struct {
+	// ExpiryWindow refreshes credentials this long before they expire.
+	ExpiryWindow time.Duration
+}
+
+// A CredentialsCache caches the credentials retrieved by a provider,
+// retrieving them again when they expire.
+// It's safe for concurrent use.
+type CredentialsCache struct {
+	provider CredentialsProvider
+	options  CredentialsCacheOptions
+
+	mtx   sync.Mutex
+	value Credentials
+	valid bool
+}
+
+// NewCredentialsCache returns a CredentialsCache for provider.
+func NewCredentialsCache(provider CredentialsProvider, optFns ...func(*CredentialsCacheOptions)) *CredentialsCache {
+	c := &CredentialsCache{provider: provider}
+	for _, fn := range optFns {
+		fn(&c.options)
+	}
+	return c
+}
+
+// Retrieve returns the cached credentials,
+// retrieving them if needed.
+func (c *CredentialsCache) Retrieve(ctx context.Context) (Credentials, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.valid && (!c.value.CanExpire || time.Now().Add(c.options.ExpiryWindow).Before(c.value.Expires)) {
+		return c.value, nil
+	}
+	v, err := c.provider.Retrieve(ctx)
+	if err != nil {
+		return Credentials{}, err
+	}
+	c.value, c.valid = v, true
+	return v, nil
+}
+
+// Invalidate forces the credentials to be retrieved again.
+func (c *CredentialsCache) Invalidate() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.valid = false
+}
@@ -0,0 +1,43 @@
+package aws
+
+import "time"
+
+// ToString returns the value of p, or the zero value if p is nil.
+func ToString(p *string) (v string) {
+	if p != nil {
+		v = *p
+	}
+	return v
+}
+
+// ToBool returns the value of p, or the zero value if p is nil.
+func ToBool(p *bool) (v bool) {
+	if p != nil {
+		v = *p
+	}
+	return v
+}
+
+// ToInt32 returns the value of p, or the zero value if p is nil.
+func ToInt32(p *int32) (v int32) {
+	if p != nil {
+		v = *p
+	}
+	return v
+}
+
+// ToInt64 returns the value of p, or the zero value if p is nil.
+func ToInt64(p *int64) (v int64) {
+	if p != nil {
+		v = *p
+	}
+	return v
+}
+
+// ToTime returns the value of p, or the zero value if p is nil.
+func ToTime(p *time.Time) (v time.Time) {
+	if p != nil {
+		v = *p
+	}
+	return v
+}
@@ -0,0 +1,18 @@
+package aws
+
+import "time"
+
+// String returns a pointer to v.
+func String(v string) *string { return &v }
+
+// Bool returns a pointer to v.
+func Bool(v bool) *bool { return &v }
+
+// Int32 returns a pointer to v.
+func Int32(v int32) *int32 { return &v }
+
+// Int64 returns a pointer to v.
+func Int64(v int64) *int64 { return &v }
+
+// Time returns a pointer to v.
+func Time(v time.Time) *time.Time { return &v }
@@ -0,0 +1,115 @@
+// Package config loads the configuration shared by service clients.
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcp"
+)
+
+// Regions maps Google Cloud regions to the nearest AWS regions,
+// so libraries that branch on AWS region names behave sensibly.
+// Add, or replace, entries to override the mapping.
+var Regions = gcp.Regions
+
+// LoadOptions override the defaults of LoadDefaultConfig.
+type LoadOptions struct {
+	Region                      string
+	Credentials                 aws.CredentialsProvider
+	HTTPClient                  aws.HTTPClient
+	BaseEndpoint                string
+	EndpointResolverWithOptions aws.EndpointResolverWithOptions
+}
+
+// LoadDefaultConfig loads the configuration shared by service clients,
+// filling it in from the Google Cloud environment, unless overridden:
+// the region is read from AWS_REGION (or AWS_DEFAULT_REGION),
+// or mapped from the instance's region to the nearest AWS region (see Regions);
+// credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+func LoadDefaultConfig(ctx context.Context, optFns ...func(*LoadOptions) error) (aws.Config, error) {
+	var o LoadOptions
+	for _, fn := range optFns {
+		if err := fn(&o); err != nil {
+			return aws.Config{}, err
+		}
+	}
+
+	cfg := aws.Config{
+		Region:                      o.Region,
+		Credentials:                 o.Credentials,
+		HTTPClient:                  o.HTTPClient,
+		EndpointResolverWithOptions: o.EndpointResolverWithOptions,
+	}
+	if o.BaseEndpoint != "" {
+		cfg.BaseEndpoint = aws.String(o.BaseEndpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.Region == "" {
+		cfg.Region, _ = gcp.Region(ctx)
+	}
+	if cfg.Credentials == nil && os.Getenv("AWS_ACCESS_KEY_ID") != "" {
+		cfg.Credentials = aws.NewCredentialsCache(aws.CredentialsProviderFunc(envCredentials))
+	}
+	return cfg, nil
+}
+
+func envCredentials(context.Context) (aws.Credentials, error) {
+	v := aws.Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Source:          "EnvConfigCredentials",
+	}
+	if !v.HasKeys() {
+		return aws.Credentials{}, errors.New("config: AWS_ACCESS_KEY_ID or AWS_SECRET_ACCESS_KEY not set")
+	}
+	return v, nil
+}
+
+// WithRegion sets the region.
+func WithRegion(v string) func(*LoadOptions) error {
+	return func(o *LoadOptions) error {
+		o.Region = v
+		return nil
+	}
+}
+
+// WithCredentialsProvider sets the credentials provider.
+func WithCredentialsProvider(v aws.CredentialsProvider) func(*LoadOptions) error {
+	return func(o *LoadOptions) error {
+		o.Credentials = v
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client.
+func WithHTTPClient(v aws.HTTPClient) func(*LoadOptions) error {
+	return func(o *LoadOptions) error {
+		o.HTTPClient = v
+		return nil
+	}
+}
+
+// WithBaseEndpoint sets the Google Cloud API endpoint of shimmed services.
+func WithBaseEndpoint(v string) func(*LoadOptions) error {
+	return func(o *LoadOptions) error {
+		o.BaseEndpoint = v
+		return nil
+	}
+}
+
+// WithEndpointResolverWithOptions sets the endpoint resolver.
+func WithEndpointResolverWithOptions(v aws.EndpointResolverWithOptions) func(*LoadOptions) error {
+	return func(o *LoadOptions) error {
+		o.EndpointResolverWithOptions = v
+		return nil
+	}
+}
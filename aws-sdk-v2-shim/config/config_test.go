@@ -0,0 +1,41 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/internal/gcptest"
+)
+
+func TestLoadDefaultConfig_region(t *testing.T) {
+	metadata := gcptest.NewMetadataServer(map[string]string{
+		"instance/region": "projects/123456789012/regions/europe-west4",
+	})
+	defer metadata.Close()
+
+	ctx := context.Background()
+	cfg, err := LoadDefaultConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Region != "eu-central-1" {
+		t.Errorf("Region = %q, want %q", cfg.Region, "eu-central-1")
+	}
+
+	// Overrides.
+	Regions["europe-west4"] = "eu-west-1"
+	defer func() { Regions["europe-west4"] = "eu-central-1" }()
+	if cfg, _ := LoadDefaultConfig(ctx); cfg.Region != "eu-west-1" {
+		t.Errorf("Region = %q, want %q", cfg.Region, "eu-west-1")
+	}
+
+	os.Setenv("AWS_REGION", "us-west-2")
+	defer os.Unsetenv("AWS_REGION")
+	if cfg, _ := LoadDefaultConfig(ctx); cfg.Region != "us-west-2" {
+		t.Errorf("Region = %q, want %q", cfg.Region, "us-west-2")
+	}
+	if cfg, _ := LoadDefaultConfig(ctx, WithRegion("ap-south-1")); cfg.Region != "ap-south-1" {
+		t.Errorf("Region = %q, want %q", cfg.Region, "ap-south-1")
+	}
+}
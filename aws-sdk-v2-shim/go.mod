@@ -0,0 +1,3 @@
+module github.com/aws/aws-sdk-go-v2
+
+go 1.11
@@ -0,0 +1,224 @@
+// Package gcp calls Google Cloud APIs, authorized by the metadata server,
+// using only the standard library.
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+var metadataClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: time.Second}).DialContext,
+	},
+	Timeout: 5 * time.Second,
+}
+
+// DefaultClient is used when no HTTPClient is configured.
+var DefaultClient aws.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// Metadata returns the value of a metadata server path,
+// relative to computeMetadata/v1/.
+// The GCE_METADATA_HOST environment variable overrides the server's address.
+func Metadata(ctx context.Context, path string) (string, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+host+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := metadataClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New("metadata: " + path + ": " + res.Status)
+	}
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// ProjectID returns the Google Cloud project ID,
+// from the GOOGLE_CLOUD_PROJECT environment variable,
+// or the metadata server.
+func ProjectID(ctx context.Context) (string, error) {
+	if id := os.Getenv("GOOGLE_CLOUD_PROJECT"); id != "" {
+		return id, nil
+	}
+	return Metadata(ctx, "project/project-id")
+}
+
+var (
+	tokenMtx     sync.Mutex
+	tokenValue   string
+	tokenExpires time.Time
+)
+
+// AccessToken returns an OAuth2 access token for the default service account.
+// Tokens are cached until shortly before they expire.
+func AccessToken(ctx context.Context) (string, error) {
+	tokenMtx.Lock()
+	defer tokenMtx.Unlock()
+
+	if tokenValue != "" && time.Until(tokenExpires) > time.Minute {
+		return tokenValue, nil
+	}
+
+	res, err := Metadata(ctx, "instance/service-accounts/default/token")
+	if err != nil {
+		return "", err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal([]byte(res), &token); err != nil {
+		return "", err
+	}
+	tokenValue = token.AccessToken
+	tokenExpires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return tokenValue, nil
+}
+
+// An Error is an error returned by a Google Cloud API.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.Code)
+	}
+	return e.Message
+}
+
+// A Client calls a Google Cloud JSON API.
+type Client struct {
+	HTTPClient aws.HTTPClient
+	Endpoint   string
+}
+
+// NewClient creates a Client for the given service,
+// using the HTTP client and endpoint configured in cfg,
+// or the given default endpoint.
+func NewClient(service, region string, client aws.HTTPClient, base *string, resolver aws.EndpointResolverWithOptions, endpoint string) (*Client, error) {
+	if client == nil {
+		client = DefaultClient
+	}
+	if base != nil {
+		endpoint = *base
+	} else if resolver != nil {
+		e, err := resolver.ResolveEndpoint(service, region)
+		if err == nil {
+			endpoint = e.URL
+		} else if _, ok := err.(*aws.EndpointNotFoundError); !ok {
+			return nil, err
+		}
+	}
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+	return &Client{HTTPClient: client, Endpoint: endpoint}, nil
+}
+
+// Call calls the API at path, relative to the endpoint,
+// authorized with AccessToken,
+// encoding in (if not nil) as the request body,
+// and decoding the response body into out (if not nil).
+func (c *Client) Call(ctx context.Context, method, path string, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.Endpoint+path, &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	token, err := AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var e struct {
+			Error Error `json:"error"`
+		}
+		json.NewDecoder(res.Body).Decode(&e)
+		e.Error.Code = res.StatusCode
+		return &e.Error
+	}
+	if out != nil {
+		return json.NewDecoder(res.Body).Decode(out)
+	}
+	return nil
+}
+
+// An APIError is an error with an AWS error code,
+// it implements the ErrorCode and ErrorMessage methods of smithy.APIError.
+type APIError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+// NewAPIError creates an APIError.
+func NewAPIError(code, message string, err error) *APIError {
+	return &APIError{Code: code, Message: message, Err: err}
+}
+
+func (e *APIError) Error() string {
+	return "api error " + e.Code + ": " + e.Message
+}
+
+// ErrorCode returns the AWS error code.
+func (e *APIError) ErrorCode() string {
+	return e.Code
+}
+
+// ErrorMessage returns the error message.
+func (e *APIError) ErrorMessage() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying error.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,88 @@
+package gcp
+
+import (
+	"context"
+	"strings"
+)
+
+// Regions maps Google Cloud regions to the nearest AWS regions,
+// like ec2metadata.Regions in the v1 shim.
+// It's exported to users as config.Regions.
+var Regions = map[string]string{
+	"us-central1":             "us-east-2",
+	"us-east1":                "us-east-1",
+	"us-east4":                "us-east-1",
+	"us-east5":                "us-east-2",
+	"us-south1":               "us-east-2",
+	"us-west1":                "us-west-2",
+	"us-west2":                "us-west-1",
+	"us-west3":                "us-west-1",
+	"us-west4":                "us-west-1",
+	"northamerica-northeast1": "ca-central-1",
+	"northamerica-northeast2": "ca-central-1",
+	"southamerica-east1":      "sa-east-1",
+	"southamerica-west1":      "sa-east-1",
+	"europe-west1":            "eu-west-1",
+	"europe-west2":            "eu-west-2",
+	"europe-west3":            "eu-central-1",
+	"europe-west4":            "eu-central-1",
+	"europe-west6":            "eu-central-2",
+	"europe-west8":            "eu-south-1",
+	"europe-west9":            "eu-west-3",
+	"europe-west10":           "eu-central-1",
+	"europe-west12":           "eu-south-1",
+	"europe-north1":           "eu-north-1",
+	"europe-central2":         "eu-central-1",
+	"europe-southwest1":       "eu-south-2",
+	"asia-east1":              "ap-east-1",
+	"asia-east2":              "ap-east-1",
+	"asia-northeast1":         "ap-northeast-1",
+	"asia-northeast2":         "ap-northeast-3",
+	"asia-northeast3":         "ap-northeast-2",
+	"asia-south1":             "ap-south-1",
+	"asia-south2":             "ap-south-1",
+	"asia-southeast1":         "ap-southeast-1",
+	"asia-southeast2":         "ap-southeast-3",
+	"australia-southeast1":    "ap-southeast-2",
+	"australia-southeast2":    "ap-southeast-4",
+	"me-central1":             "me-central-1",
+	"me-central2":             "me-central-1",
+	"me-west1":                "il-central-1",
+	"africa-south1":           "af-south-1",
+}
+
+// AWSRegion returns the AWS region for a Google Cloud region, or zone.
+// Returns the Google Cloud region if there's no mapping (see Regions).
+func AWSRegion(gcp string) string {
+	region := zoneRegion(gcp)
+	if aws, ok := Regions[region]; ok {
+		return aws
+	}
+	return region
+}
+
+// zoneRegion returns the region of a zone
+// (like us-central1-a, or us-central1-1 on serverless platforms),
+// or the region itself.
+func zoneRegion(zone string) string {
+	zone = zone[strings.LastIndexByte(zone, '/')+1:]
+	// Regions have a single dash (like us-central1), zones add a suffix.
+	if strings.Count(zone, "-") > 1 {
+		return zone[:strings.LastIndexByte(zone, '-')]
+	}
+	return zone
+}
+
+// Region returns the AWS region for the instance (see AWSRegion).
+func Region(ctx context.Context) (string, error) {
+	// Serverless platforms provide the region:
+	// projects/PROJECT_NUMBER/regions/REGION
+	if region, err := Metadata(ctx, "instance/region"); err == nil {
+		return AWSRegion(region), nil
+	}
+	zone, err := Metadata(ctx, "instance/zone")
+	if err != nil {
+		return "", err
+	}
+	return AWSRegion(zone), nil
+}
@@ -0,0 +1,58 @@
+package gcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/internal/gcptest"
+)
+
+func TestAWSRegion(t *testing.T) {
+	tests := []struct{ gcp, aws string }{
+		{"us-central1", "us-east-2"},
+		{"us-central1-a", "us-east-2"},
+		{"us-central1-1", "us-east-2"},
+		{"europe-west10-b", "eu-central-1"},
+		{"projects/123456789012/zones/europe-west1-d", "eu-west-1"},
+		{"projects/123456789012/regions/asia-south1", "ap-south-1"},
+		{"antarctica-south1-a", "antarctica-south1"},
+	}
+	for _, tt := range tests {
+		if got := AWSRegion(tt.gcp); got != tt.aws {
+			t.Errorf("AWSRegion(%q) = %q, want %q", tt.gcp, got, tt.aws)
+		}
+	}
+}
+
+func TestRegion(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		want     string
+	}{
+		{"compute", map[string]string{
+			"instance/zone": "projects/123456789012/zones/europe-west1-d",
+		}, "eu-west-1"},
+		{"serverless", map[string]string{
+			"instance/zone":   "projects/123456789012/zones/us-central1-1",
+			"instance/region": "projects/123456789012/regions/us-central1",
+		}, "us-east-2"},
+		{"serverless zone", map[string]string{
+			"instance/zone": "projects/123456789012/zones/asia-northeast1-1",
+		}, "ap-northeast-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := gcptest.NewMetadataServer(tt.metadata)
+			defer server.Close()
+
+			got, err := Region(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Region() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
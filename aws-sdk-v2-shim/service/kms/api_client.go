@@ -0,0 +1,188 @@
+// Package kms implements a subset of the AWS KMS API,
+// backed by Google Cloud KMS.
+//
+// Key IDs, key ARNs, and aliases are translated to crypto keys in KeyRing:
+// characters not allowed by Cloud KMS are replaced by _.
+// Resource names (projects/PROJECT/locations/LOCATION/keyRings/RING/cryptoKeys/KEY)
+// are used as is.
+//
+// Ciphertext blobs embed the name of the crypto key,
+// so Decrypt does not require a KeyId.
+// Encryption contexts are authenticated as additional data.
+//
+// Errors implement the ErrorCode and ErrorMessage methods of smithy.APIError.
+package kms
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcp"
+)
+
+// ServiceID is the service identifier passed to endpoint resolvers.
+const ServiceID = "KMS"
+
+const endpoint = "https://cloudkms.googleapis.com/v1/"
+
+// Options configure a Client.
+type Options struct {
+	// Region is the AWS region.
+	Region string
+
+	// Credentials provides AWS credentials, which are not used:
+	// requests are authorized with Google Cloud credentials.
+	Credentials aws.CredentialsProvider
+
+	// HTTPClient sends API requests.
+	HTTPClient aws.HTTPClient
+
+	// BaseEndpoint overrides the Cloud KMS API endpoint.
+	BaseEndpoint *string
+
+	// EndpointResolverWithOptions resolves the Cloud KMS API endpoint,
+	// it's used if BaseEndpoint is not set.
+	EndpointResolverWithOptions aws.EndpointResolverWithOptions
+}
+
+// Copy returns a shallow copy of o.
+func (o Options) Copy() Options {
+	return o
+}
+
+// Client is a KMS client.
+type Client struct {
+	options Options
+}
+
+// New creates a Client.
+func New(options Options, optFns ...func(*Options)) *Client {
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	return &Client{options: options}
+}
+
+// NewFromConfig creates a Client from cfg.
+func NewFromConfig(cfg aws.Config, optFns ...func(*Options)) *Client {
+	return New(Options{
+		Region:                      cfg.Region,
+		Credentials:                 cfg.Credentials,
+		HTTPClient:                  cfg.HTTPClient,
+		BaseEndpoint:                cfg.BaseEndpoint,
+		EndpointResolverWithOptions: cfg.EndpointResolverWithOptions,
+	}, optFns...)
+}
+
+// Options returns a copy of the client's options.
+func (c *Client) Options() Options {
+	return c.options.Copy()
+}
+
+func (c *Client) invoke(ctx context.Context, optFns []func(*Options), method, path string, in, out interface{}) error {
+	o := c.options.Copy()
+	for _, fn := range optFns {
+		fn(&o)
+	}
+	api, err := gcp.NewClient(ServiceID, o.Region, o.HTTPClient, o.BaseEndpoint, o.EndpointResolverWithOptions, endpoint)
+	if err != nil {
+		return err
+	}
+	if err := api.Call(ctx, method, path, in, out); err != nil {
+		return apiError(err)
+	}
+	return nil
+}
+
+// KeyRing is the key ring where key IDs and aliases are looked up.
+// The default is the "aws" key ring, in the global location,
+// of the current project.
+var KeyRing string
+
+var invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// keyName translates a key ID to a crypto key resource name.
+func keyName(ctx context.Context, id *string) (string, error) {
+	if id == nil || *id == "" {
+		return "", gcp.NewAPIError("ValidationException", "missing KeyId", nil)
+	}
+	name := *id
+	if strings.HasPrefix(name, "projects/") {
+		return name, nil
+	}
+	if strings.HasPrefix(name, "arn:") {
+		parts := strings.SplitN(name, ":", 6)
+		if len(parts) != 6 {
+			return "", gcp.NewAPIError("InvalidArnException", "invalid ARN: "+name, nil)
+		}
+		name = parts[5]
+		name = strings.TrimPrefix(name, "key/")
+	}
+	name = strings.TrimPrefix(name, "alias/")
+
+	ring := KeyRing
+	if ring == "" {
+		project, err := gcp.ProjectID(ctx)
+		if err != nil {
+			return "", gcp.NewAPIError("KMSInternalException", "project not found", err)
+		}
+		ring = "projects/" + project + "/locations/global/keyRings/aws"
+	}
+	return ring + "/cryptoKeys/" + invalidChars.ReplaceAllString(name, "_"), nil
+}
+
+// additionalData serializes an encryption context,
+// sorted by key, so it can be authenticated.
+func additionalData(ctx map[string]string) []byte {
+	if len(ctx) == 0 {
+		return nil
+	}
+	buf, err := json.Marshal(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return buf
+}
+
+// wrap embeds the crypto key name in a ciphertext blob.
+func wrap(name string, ciphertext []byte) []byte {
+	blob := make([]byte, 2, 2+len(name)+len(ciphertext))
+	binary.BigEndian.PutUint16(blob, uint16(len(name)))
+	blob = append(blob, name...)
+	return append(blob, ciphertext...)
+}
+
+// unwrap extracts the crypto key name from a ciphertext blob.
+func unwrap(blob []byte) (name string, ciphertext []byte, err error) {
+	if len(blob) > 2 {
+		n := int(binary.BigEndian.Uint16(blob))
+		if len(blob) > 2+n {
+			return string(blob[2 : 2+n]), blob[2+n:], nil
+		}
+	}
+	return "", nil, gcp.NewAPIError("InvalidCiphertextException", "invalid ciphertext blob", nil)
+}
+
+// apiError translates a Cloud KMS error.
+func apiError(err error) error {
+	e, ok := err.(*gcp.Error)
+	if !ok {
+		return gcp.NewAPIError("KMSInternalException", err.Error(), err)
+	}
+	switch e.Code {
+	case http.StatusNotFound:
+		return gcp.NewAPIError("NotFoundException", e.Error(), err)
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return gcp.NewAPIError("AccessDeniedException", e.Error(), err)
+	case http.StatusBadRequest:
+		return gcp.NewAPIError("InvalidCiphertextException", e.Error(), err)
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return gcp.NewAPIError("DisabledException", e.Error(), err)
+	}
+	return gcp.NewAPIError("KMSInternalException", e.Error(), err)
+}
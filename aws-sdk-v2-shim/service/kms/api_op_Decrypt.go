@@ -0,0 +1,60 @@
+package kms
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcp"
+)
+
+// DecryptInput is the input of Decrypt.
+type DecryptInput struct {
+	// KeyId is optional, if set it must match
+	// the crypto key that encrypted the blob.
+	KeyId *string
+
+	CiphertextBlob    []byte
+	EncryptionContext map[string]string
+}
+
+// DecryptOutput is the output of Decrypt.
+type DecryptOutput struct {
+	// KeyId is the resource name of the crypto key.
+	KeyId     *string
+	Plaintext []byte
+}
+
+// Decrypt decrypts a ciphertext blob produced by Encrypt, or GenerateDataKey.
+func (c *Client) Decrypt(ctx context.Context, params *DecryptInput, optFns ...func(*Options)) (*DecryptOutput, error) {
+	if params == nil {
+		params = &DecryptInput{}
+	}
+	name, ciphertext, err := unwrap(params.CiphertextBlob)
+	if err != nil {
+		return nil, err
+	}
+	if params.KeyId != nil {
+		want, err := keyName(ctx, params.KeyId)
+		if err != nil {
+			return nil, err
+		}
+		if want != name {
+			return nil, gcp.NewAPIError("IncorrectKeyException", "ciphertext was encrypted with "+name, nil)
+		}
+	}
+
+	var res struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	req := struct {
+		Ciphertext                  []byte `json:"ciphertext"`
+		AdditionalAuthenticatedData []byte `json:"additionalAuthenticatedData,omitempty"`
+	}{ciphertext, additionalData(params.EncryptionContext)}
+	if err := c.invoke(ctx, optFns, "POST", name+":decrypt", req, &res); err != nil {
+		return nil, err
+	}
+	return &DecryptOutput{
+		KeyId:     aws.String(name),
+		Plaintext: res.Plaintext,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package kms
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// EncryptInput is the input of Encrypt.
+type EncryptInput struct {
+	// KeyId is the key's ID, ARN, alias, or resource name.
+	KeyId *string
+
+	Plaintext         []byte
+	EncryptionContext map[string]string
+}
+
+// EncryptOutput is the output of Encrypt.
+type EncryptOutput struct {
+	// KeyId is the resource name of the crypto key.
+	KeyId          *string
+	CiphertextBlob []byte
+}
+
+// Encrypt encrypts plaintext with a crypto key.
+func (c *Client) Encrypt(ctx context.Context, params *EncryptInput, optFns ...func(*Options)) (*EncryptOutput, error) {
+	if params == nil {
+		params = &EncryptInput{}
+	}
+	name, err := keyName(ctx, params.KeyId)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := c.encrypt(ctx, optFns, name, params.Plaintext, params.EncryptionContext)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptOutput{
+		KeyId:          aws.String(name),
+		CiphertextBlob: wrap(name, ciphertext),
+	}, nil
+}
+
+func (c *Client) encrypt(ctx context.Context, optFns []func(*Options), name string, plaintext []byte, encryptionContext map[string]string) ([]byte, error) {
+	var res struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	req := struct {
+		Plaintext                   []byte `json:"plaintext"`
+		AdditionalAuthenticatedData []byte `json:"additionalAuthenticatedData,omitempty"`
+	}{plaintext, additionalData(encryptionContext)}
+	if err := c.invoke(ctx, optFns, "POST", name+":encrypt", req, &res); err != nil {
+		return nil, err
+	}
+	return res.Ciphertext, nil
+}
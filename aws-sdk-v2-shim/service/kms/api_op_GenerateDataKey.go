@@ -0,0 +1,71 @@
+package kms
+
+import (
+	"context"
+	"crypto/rand"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcp"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// GenerateDataKeyInput is the input of GenerateDataKey.
+type GenerateDataKeyInput struct {
+	// KeyId is the key's ID, ARN, alias, or resource name.
+	KeyId *string
+
+	// Either KeySpec, or NumberOfBytes.
+	KeySpec       types.DataKeySpec
+	NumberOfBytes *int32
+
+	EncryptionContext map[string]string
+}
+
+// GenerateDataKeyOutput is the output of GenerateDataKey.
+type GenerateDataKeyOutput struct {
+	// KeyId is the resource name of the crypto key.
+	KeyId          *string
+	Plaintext      []byte
+	CiphertextBlob []byte
+}
+
+// GenerateDataKey generates a random data key locally,
+// and returns it in plaintext, and encrypted with a crypto key.
+func (c *Client) GenerateDataKey(ctx context.Context, params *GenerateDataKeyInput, optFns ...func(*Options)) (*GenerateDataKeyOutput, error) {
+	if params == nil {
+		params = &GenerateDataKeyInput{}
+	}
+	name, err := keyName(ctx, params.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	var size int32
+	switch {
+	case params.KeySpec != "" && params.NumberOfBytes != nil:
+		return nil, gcp.NewAPIError("ValidationException", "both KeySpec and NumberOfBytes set", nil)
+	case params.KeySpec == types.DataKeySpecAes256:
+		size = 32
+	case params.KeySpec == types.DataKeySpecAes128:
+		size = 16
+	case params.NumberOfBytes != nil:
+		size = *params.NumberOfBytes
+	}
+	if size < 1 || size > 1024 {
+		return nil, gcp.NewAPIError("ValidationException", "invalid KeySpec or NumberOfBytes", nil)
+	}
+
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, gcp.NewAPIError("KMSInternalException", "random data key", err)
+	}
+	ciphertext, err := c.encrypt(ctx, optFns, name, key, params.EncryptionContext)
+	if err != nil {
+		return nil, err
+	}
+	return &GenerateDataKeyOutput{
+		KeyId:          aws.String(name),
+		Plaintext:      key,
+		CiphertextBlob: wrap(name, ciphertext),
+	}, nil
+}
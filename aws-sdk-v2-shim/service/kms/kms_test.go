@@ -0,0 +1,200 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcptest"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+func TestMain(m *testing.M) {
+	// A fake metadata server, to authorize requests.
+	metadata := gcptest.NewMetadataServer(nil)
+	code := m.Run()
+	metadata.Close()
+	os.Exit(code)
+}
+
+// newServer starts a fake Cloud KMS server,
+// whose ciphertexts are the JSON encoding of what they authenticate.
+// Call close when finished, to shut it down.
+func newServer() (client *Client, close func()) {
+	type sealed struct {
+		Key       string `json:"key"`
+		Plaintext []byte `json:"plaintext"`
+		AAD       []byte `json:"aad"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, `{"error":{"message":"unauthorized"}}`, http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Plaintext  []byte `json:"plaintext"`
+			Ciphertext []byte `json:"ciphertext"`
+			AAD        []byte `json:"additionalAuthenticatedData"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, "/v1/")
+		switch {
+		case strings.HasSuffix(key, ":encrypt"):
+			key = strings.TrimSuffix(key, ":encrypt")
+			ciphertext, _ := json.Marshal(sealed{key, req.Plaintext, req.AAD})
+			json.NewEncoder(w).Encode(map[string][]byte{"ciphertext": ciphertext})
+		case strings.HasSuffix(key, ":decrypt"):
+			key = strings.TrimSuffix(key, ":decrypt")
+			var s sealed
+			if json.Unmarshal(req.Ciphertext, &s) != nil || s.Key != key || !bytes.Equal(s.AAD, req.AAD) {
+				http.Error(w, `{"error":{"message":"decryption failed"}}`, http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string][]byte{"plaintext": s.Plaintext})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return New(Options{BaseEndpoint: aws.String(server.URL + "/v1/")}), server.Close
+}
+
+func TestClient_roundTrip(t *testing.T) {
+	ctx := context.Background()
+	client, close := newServer()
+	defer close()
+	encryption := map[string]string{"purpose": "test"}
+
+	enc, err := client.Encrypt(ctx, &EncryptInput{
+		KeyId:             aws.String("alias/my-key"),
+		Plaintext:         []byte("secret"),
+		EncryptionContext: encryption,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const name = "projects/project/locations/global/keyRings/aws/cryptoKeys/my-key"
+	if aws.ToString(enc.KeyId) != name {
+		t.Errorf("KeyId = %q, want %q", aws.ToString(enc.KeyId), name)
+	}
+
+	// Decrypt doesn't need the key, it's in the blob.
+	dec, err := client.Decrypt(ctx, &DecryptInput{
+		CiphertextBlob:    enc.CiphertextBlob,
+		EncryptionContext: encryption,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(dec.Plaintext) != "secret" || aws.ToString(dec.KeyId) != name {
+		t.Errorf("Decrypt() = %q, %q", dec.Plaintext, aws.ToString(dec.KeyId))
+	}
+
+	// The encryption context is authenticated.
+	_, err = client.Decrypt(ctx, &DecryptInput{CiphertextBlob: enc.CiphertextBlob})
+	if code := errorCode(err); code != "InvalidCiphertextException" {
+		t.Errorf("Decrypt() = %v, want InvalidCiphertextException", err)
+	}
+
+	// A mismatched key is rejected.
+	_, err = client.Decrypt(ctx, &DecryptInput{
+		KeyId:             aws.String("other-key"),
+		CiphertextBlob:    enc.CiphertextBlob,
+		EncryptionContext: encryption,
+	})
+	if code := errorCode(err); code != "IncorrectKeyException" {
+		t.Errorf("Decrypt() = %v, want IncorrectKeyException", err)
+	}
+}
+
+func TestClient_GenerateDataKey(t *testing.T) {
+	ctx := context.Background()
+	client, close := newServer()
+	defer close()
+
+	key, err := client.GenerateDataKey(ctx, &GenerateDataKeyInput{
+		KeyId:   aws.String("arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab"),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key.Plaintext) != 32 {
+		t.Errorf("len(Plaintext) = %d, want 32", len(key.Plaintext))
+	}
+
+	dec, err := client.Decrypt(ctx, &DecryptInput{CiphertextBlob: key.CiphertextBlob})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec.Plaintext, key.Plaintext) {
+		t.Error("Decrypt() didn't return the data key")
+	}
+
+	_, err = client.GenerateDataKey(ctx, &GenerateDataKeyInput{
+		KeyId:         aws.String("key"),
+		KeySpec:       types.DataKeySpecAes128,
+		NumberOfBytes: aws.Int32(16),
+	})
+	if code := errorCode(err); code != "ValidationException" {
+		t.Errorf("GenerateDataKey() = %v, want ValidationException", err)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	const name = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+	blob := wrap(name, []byte("ciphertext"))
+
+	got, ciphertext, err := unwrap(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != name || string(ciphertext) != "ciphertext" {
+		t.Errorf("unwrap() = %q, %q", got, ciphertext)
+	}
+
+	for _, blob := range [][]byte{nil, {0}, {0, 5, 'a'}, blob[:2+len(name)]} {
+		if _, _, err := unwrap(blob); errorCode(err) != "InvalidCiphertextException" {
+			t.Errorf("unwrap(%q) = %v, want InvalidCiphertextException", blob, err)
+		}
+	}
+}
+
+func TestKeyName(t *testing.T) {
+	KeyRing = "projects/p/locations/global/keyRings/r"
+	defer func() { KeyRing = "" }()
+
+	tests := []struct{ id, name string }{
+		{"my-key", KeyRing + "/cryptoKeys/my-key"},
+		{"alias/my/key", KeyRing + "/cryptoKeys/my_key"},
+		{"arn:aws:kms:us-east-1:123456789012:key/1234abcd", KeyRing + "/cryptoKeys/1234abcd"},
+		{"arn:aws:kms:us-east-1:123456789012:alias/my.key", KeyRing + "/cryptoKeys/my_key"},
+		{"projects/x/locations/y/keyRings/z/cryptoKeys/k", "projects/x/locations/y/keyRings/z/cryptoKeys/k"},
+	}
+	for _, tt := range tests {
+		got, err := keyName(context.Background(), aws.String(tt.id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.name {
+			t.Errorf("keyName(%q) = %q, want %q", tt.id, got, tt.name)
+		}
+	}
+}
+
+func errorCode(err error) string {
+	if e, ok := err.(interface{ ErrorCode() string }); ok {
+		return e.ErrorCode()
+	}
+	return ""
+}
@@ -0,0 +1,11 @@
+// Package types declares the types used by the KMS API.
+package types
+
+// DataKeySpec is the length of a data key.
+type DataKeySpec string
+
+// Data key specs.
+const (
+	DataKeySpecAes256 DataKeySpec = "AES_256"
+	DataKeySpecAes128 DataKeySpec = "AES_128"
+)
@@ -0,0 +1,141 @@
+// Package secretsmanager implements a subset of the AWS Secrets Manager API,
+// backed by Google Secret Manager.
+//
+// Secret IDs are translated to secret names in the current project:
+// characters not allowed by Secret Manager (like /) are replaced by _,
+// and the random suffix of secret ARNs is dropped.
+// Resource names (projects/PROJECT/secrets/SECRET) are used as is.
+//
+// Versions map to staging labels: AWSCURRENT is the latest version,
+// and other labels are version aliases.
+//
+// Errors implement the ErrorCode and ErrorMessage methods of smithy.APIError.
+package secretsmanager
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcp"
+)
+
+// ServiceID is the service identifier passed to endpoint resolvers.
+const ServiceID = "Secrets Manager"
+
+const endpoint = "https://secretmanager.googleapis.com/v1/"
+
+// Options configure a Client.
+type Options struct {
+	// Region is the AWS region.
+	Region string
+
+	// Credentials provides AWS credentials, which are not used:
+	// requests are authorized with Google Cloud credentials.
+	Credentials aws.CredentialsProvider
+
+	// HTTPClient sends API requests.
+	HTTPClient aws.HTTPClient
+
+	// BaseEndpoint overrides the Secret Manager API endpoint.
+	BaseEndpoint *string
+
+	// EndpointResolverWithOptions resolves the Secret Manager API endpoint,
+	// it's used if BaseEndpoint is not set.
+	EndpointResolverWithOptions aws.EndpointResolverWithOptions
+}
+
+// Copy returns a shallow copy of o.
+func (o Options) Copy() Options {
+	return o
+}
+
+// Client is a Secrets Manager client.
+type Client struct {
+	options Options
+}
+
+// New creates a Client.
+func New(options Options, optFns ...func(*Options)) *Client {
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	return &Client{options: options}
+}
+
+// NewFromConfig creates a Client from cfg.
+func NewFromConfig(cfg aws.Config, optFns ...func(*Options)) *Client {
+	return New(Options{
+		Region:                      cfg.Region,
+		Credentials:                 cfg.Credentials,
+		HTTPClient:                  cfg.HTTPClient,
+		BaseEndpoint:                cfg.BaseEndpoint,
+		EndpointResolverWithOptions: cfg.EndpointResolverWithOptions,
+	}, optFns...)
+}
+
+// Options returns a copy of the client's options.
+func (c *Client) Options() Options {
+	return c.options.Copy()
+}
+
+func (c *Client) invoke(ctx context.Context, optFns []func(*Options), method, path string, in, out interface{}) error {
+	o := c.options.Copy()
+	for _, fn := range optFns {
+		fn(&o)
+	}
+	api, err := gcp.NewClient(ServiceID, o.Region, o.HTTPClient, o.BaseEndpoint, o.EndpointResolverWithOptions, endpoint)
+	if err != nil {
+		return err
+	}
+	if err := api.Call(ctx, method, path, in, out); err != nil {
+		return apiError(err)
+	}
+	return nil
+}
+
+var arnSuffix = regexp.MustCompile(`-[a-zA-Z0-9]{6}$`)
+var invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// secretName translates a secret ID to a secret resource name.
+func secretName(ctx context.Context, id *string) (string, error) {
+	if id == nil || *id == "" {
+		return "", gcp.NewAPIError("InvalidParameterException", "missing SecretId", nil)
+	}
+	name := *id
+	if strings.HasPrefix(name, "projects/") {
+		return name, nil
+	}
+	if strings.HasPrefix(name, "arn:") {
+		if i := strings.Index(name, ":secret:"); i >= 0 {
+			name = arnSuffix.ReplaceAllString(name[i+len(":secret:"):], "")
+		}
+	}
+
+	project, err := gcp.ProjectID(ctx)
+	if err != nil {
+		return "", gcp.NewAPIError("InternalServiceError", "project not found", err)
+	}
+	return "projects/" + project + "/secrets/" + invalidChars.ReplaceAllString(name, "_"), nil
+}
+
+// apiError translates a Secret Manager error.
+func apiError(err error) error {
+	e, ok := err.(*gcp.Error)
+	if !ok {
+		return gcp.NewAPIError("InternalServiceError", err.Error(), err)
+	}
+	switch e.Code {
+	case http.StatusNotFound:
+		return gcp.NewAPIError("ResourceNotFoundException", e.Error(), err)
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return gcp.NewAPIError("AccessDeniedException", e.Error(), err)
+	case http.StatusBadRequest:
+		return gcp.NewAPIError("InvalidParameterException", e.Error(), err)
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return gcp.NewAPIError("InvalidRequestException", e.Error(), err)
+	}
+	return gcp.NewAPIError("InternalServiceError", e.Error(), err)
+}
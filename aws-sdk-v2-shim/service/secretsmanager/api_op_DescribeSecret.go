@@ -0,0 +1,76 @@
+package secretsmanager
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// DescribeSecretInput is the input of DescribeSecret.
+type DescribeSecretInput struct {
+	// SecretId is the secret's name, ARN, or resource name.
+	SecretId *string
+}
+
+// DescribeSecretOutput is the output of DescribeSecret.
+type DescribeSecretOutput struct {
+	ARN         *string
+	Name        *string
+	CreatedDate *time.Time
+	Tags        []types.Tag
+
+	// VersionIdsToStages maps version numbers to staging labels:
+	// AWSCURRENT for the latest enabled version, and version aliases.
+	VersionIdsToStages map[string][]string
+}
+
+// DescribeSecret describes a secret.
+func (c *Client) DescribeSecret(ctx context.Context, params *DescribeSecretInput, optFns ...func(*Options)) (*DescribeSecretOutput, error) {
+	if params == nil {
+		params = &DescribeSecretInput{}
+	}
+	name, err := secretName(ctx, params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret struct {
+		CreateTime     time.Time         `json:"createTime"`
+		Labels         map[string]string `json:"labels"`
+		VersionAliases map[string]string `json:"versionAliases"`
+	}
+	if err := c.invoke(ctx, optFns, "GET", name, nil, &secret); err != nil {
+		return nil, err
+	}
+
+	var versions struct {
+		Versions []struct {
+			Name string `json:"name"`
+		} `json:"versions"`
+	}
+	// Versions are listed newest first.
+	if err := c.invoke(ctx, optFns, "GET", name+"/versions?pageSize=1&filter=state:ENABLED", nil, &versions); err != nil {
+		return nil, err
+	}
+
+	out := &DescribeSecretOutput{
+		ARN:                aws.String(name),
+		Name:               aws.String(path.Base(name)),
+		CreatedDate:        aws.Time(secret.CreateTime),
+		VersionIdsToStages: map[string][]string{},
+	}
+	for k, v := range secret.Labels {
+		out.Tags = append(out.Tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	if len(versions.Versions) > 0 {
+		id := path.Base(versions.Versions[0].Name)
+		out.VersionIdsToStages[id] = append(out.VersionIdsToStages[id], "AWSCURRENT")
+	}
+	for alias, id := range secret.VersionAliases {
+		out.VersionIdsToStages[id] = append(out.VersionIdsToStages[id], alias)
+	}
+	return out, nil
+}
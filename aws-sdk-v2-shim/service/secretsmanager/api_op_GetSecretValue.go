@@ -0,0 +1,83 @@
+package secretsmanager
+
+import (
+	"context"
+	"encoding/base64"
+	"path"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcp"
+)
+
+// GetSecretValueInput is the input of GetSecretValue.
+type GetSecretValueInput struct {
+	// SecretId is the secret's name, ARN, or resource name.
+	SecretId *string
+
+	// VersionId is a version number.
+	VersionId *string
+
+	// VersionStage is a staging label, the default is AWSCURRENT.
+	VersionStage *string
+}
+
+// GetSecretValueOutput is the output of GetSecretValue.
+type GetSecretValueOutput struct {
+	ARN           *string
+	Name          *string
+	VersionId     *string
+	VersionStages []string
+
+	// SecretString is set if the secret is valid UTF-8,
+	// SecretBinary otherwise.
+	SecretString *string
+	SecretBinary []byte
+}
+
+// GetSecretValue returns the value of a version of a secret.
+func (c *Client) GetSecretValue(ctx context.Context, params *GetSecretValueInput, optFns ...func(*Options)) (*GetSecretValueOutput, error) {
+	if params == nil {
+		params = &GetSecretValueInput{}
+	}
+	name, err := secretName(ctx, params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+
+	version := "latest"
+	stages := []string{"AWSCURRENT"}
+	if v := aws.ToString(params.VersionId); v != "" {
+		version, stages = v, nil
+	} else if s := aws.ToString(params.VersionStage); s != "" && s != "AWSCURRENT" {
+		// Other stages are version aliases.
+		version, stages = s, []string{s}
+	}
+
+	var res struct {
+		Name    string `json:"name"`
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := c.invoke(ctx, optFns, "GET", name+"/versions/"+version+":access", nil, &res); err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(res.Payload.Data)
+	if err != nil {
+		return nil, gcp.NewAPIError("InternalServiceError", "invalid payload", err)
+	}
+
+	out := &GetSecretValueOutput{
+		ARN:           aws.String(name),
+		Name:          aws.String(path.Base(name)),
+		VersionId:     aws.String(path.Base(res.Name)),
+		VersionStages: stages,
+	}
+	if utf8.Valid(data) {
+		out.SecretString = aws.String(string(data))
+	} else {
+		out.SecretBinary = data
+	}
+	return out, nil
+}
@@ -0,0 +1,7 @@
+module github.com/aws/aws-sdk-go-v2/service/secretsmanager
+
+go 1.11
+
+require github.com/aws/aws-sdk-go-v2 v1.0.0
+
+replace github.com/aws/aws-sdk-go-v2 => ../../
@@ -0,0 +1,116 @@
+package secretsmanager
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcptest"
+)
+
+func TestMain(m *testing.M) {
+	// A fake metadata server, to authorize requests.
+	metadata := gcptest.NewMetadataServer(nil)
+	code := m.Run()
+	metadata.Close()
+	os.Exit(code)
+}
+
+func TestSecretName(t *testing.T) {
+	tests := []struct{ id, name string }{
+		{"db-password", "projects/project/secrets/db-password"},
+		{"prod/db/password", "projects/project/secrets/prod_db_password"},
+		{"arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf", "projects/project/secrets/prod_db"},
+		{"projects/other/secrets/name", "projects/other/secrets/name"},
+	}
+	for _, tt := range tests {
+		got, err := secretName(context.Background(), aws.String(tt.id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.name {
+			t.Errorf("secretName(%q) = %q, want %q", tt.id, got, tt.name)
+		}
+	}
+
+	_, err := secretName(context.Background(), nil)
+	if e, ok := err.(interface{ ErrorCode() string }); !ok || e.ErrorCode() != "InvalidParameterException" {
+		t.Errorf("secretName(nil) = %v, want InvalidParameterException", err)
+	}
+}
+
+func TestClient_GetSecretValue(t *testing.T) {
+	versions := map[string]string{
+		"latest": "3",
+		"1":      "1",
+		"stable": "2",
+	}
+	payloads := map[string][]byte{
+		"1": []byte("one"),
+		"2": {0xff, 0xfe},
+		"3": []byte("three"),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "/v1/projects/project/secrets/prod_db/versions/"
+		if r.Header.Get("Authorization") != "Bearer token" ||
+			!strings.HasPrefix(r.URL.Path, prefix) || !strings.HasSuffix(r.URL.Path, ":access") {
+			http.Error(w, `{"error":{"message":"not found"}}`, http.StatusNotFound)
+			return
+		}
+		version, ok := versions[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefix), ":access")]
+		if !ok {
+			http.Error(w, `{"error":{"message":"not found"}}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"name": "projects/123/secrets/prod_db/versions/" + version,
+			"payload": map[string]string{
+				"data": base64.StdEncoding.EncodeToString(payloads[version]),
+			},
+		})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := New(Options{BaseEndpoint: aws.String(server.URL + "/v1/")})
+	id := aws.String("arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/db-AbCdEf")
+
+	out, err := client.GetSecretValue(ctx, &GetSecretValueInput{SecretId: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.ToString(out.SecretString) != "three" || aws.ToString(out.VersionId) != "3" ||
+		len(out.VersionStages) != 1 || out.VersionStages[0] != "AWSCURRENT" ||
+		aws.ToString(out.Name) != "prod_db" {
+		t.Errorf("GetSecretValue() = %+v", out)
+	}
+
+	out, err = client.GetSecretValue(ctx, &GetSecretValueInput{SecretId: id, VersionId: aws.String("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.ToString(out.SecretString) != "one" || len(out.VersionStages) != 0 {
+		t.Errorf("GetSecretValue(1) = %+v", out)
+	}
+
+	// Binary secrets, and version aliases as staging labels.
+	out, err = client.GetSecretValue(ctx, &GetSecretValueInput{SecretId: id, VersionStage: aws.String("stable")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.SecretString != nil || string(out.SecretBinary) != "\xff\xfe" || out.VersionStages[0] != "stable" {
+		t.Errorf("GetSecretValue(stable) = %+v", out)
+	}
+
+	_, err = client.GetSecretValue(ctx, &GetSecretValueInput{SecretId: aws.String("missing")})
+	if e, ok := err.(interface{ ErrorCode() string }); !ok || e.ErrorCode() != "ResourceNotFoundException" {
+		t.Errorf("GetSecretValue(missing) = %v, want ResourceNotFoundException", err)
+	}
+}
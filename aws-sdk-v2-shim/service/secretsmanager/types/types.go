@@ -0,0 +1,8 @@
+// Package types declares the types used by the Secrets Manager API.
+package types
+
+// A Tag is a secret label.
+type Tag struct {
+	Key   *string
+	Value *string
+}
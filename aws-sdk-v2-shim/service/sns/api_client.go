@@ -0,0 +1,143 @@
+// Package sns implements a subset of the AWS SNS API,
+// backed by Google Cloud Pub/Sub.
+//
+// Topic ARNs are translated to topics in the current project:
+// characters not allowed by Pub/Sub are replaced by _.
+// Resource names (projects/PROJECT/topics/TOPIC) are used as is.
+//
+// Message attributes are carried as Pub/Sub attributes:
+// string and number values as is, binary values base64 encoded.
+// The subject is carried in the Subject attribute,
+// and the message group ID is used as the ordering key.
+//
+// Errors implement the ErrorCode and ErrorMessage methods of smithy.APIError.
+package sns
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcp"
+)
+
+// ServiceID is the service identifier passed to endpoint resolvers.
+const ServiceID = "SNS"
+
+const endpoint = "https://pubsub.googleapis.com/v1/"
+
+// Options configure a Client.
+type Options struct {
+	// Region is the AWS region.
+	Region string
+
+	// Credentials provides AWS credentials, which are not used:
+	// requests are authorized with Google Cloud credentials.
+	Credentials aws.CredentialsProvider
+
+	// HTTPClient sends API requests.
+	HTTPClient aws.HTTPClient
+
+	// BaseEndpoint overrides the Pub/Sub API endpoint.
+	BaseEndpoint *string
+
+	// EndpointResolverWithOptions resolves the Pub/Sub API endpoint,
+	// it's used if BaseEndpoint is not set.
+	EndpointResolverWithOptions aws.EndpointResolverWithOptions
+}
+
+// Copy returns a shallow copy of o.
+func (o Options) Copy() Options {
+	return o
+}
+
+// Client is a SNS client.
+type Client struct {
+	options Options
+}
+
+// New creates a Client.
+func New(options Options, optFns ...func(*Options)) *Client {
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	return &Client{options: options}
+}
+
+// NewFromConfig creates a Client from cfg.
+func NewFromConfig(cfg aws.Config, optFns ...func(*Options)) *Client {
+	return New(Options{
+		Region:                      cfg.Region,
+		Credentials:                 cfg.Credentials,
+		HTTPClient:                  cfg.HTTPClient,
+		BaseEndpoint:                cfg.BaseEndpoint,
+		EndpointResolverWithOptions: cfg.EndpointResolverWithOptions,
+	}, optFns...)
+}
+
+// Options returns a copy of the client's options.
+func (c *Client) Options() Options {
+	return c.options.Copy()
+}
+
+func (c *Client) invoke(ctx context.Context, optFns []func(*Options), method, path string, in, out interface{}) error {
+	o := c.options.Copy()
+	for _, fn := range optFns {
+		fn(&o)
+	}
+	api, err := gcp.NewClient(ServiceID, o.Region, o.HTTPClient, o.BaseEndpoint, o.EndpointResolverWithOptions, endpoint)
+	if err != nil {
+		return err
+	}
+	if err := api.Call(ctx, method, path, in, out); err != nil {
+		return apiError(err)
+	}
+	return nil
+}
+
+var invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.~+%-]`)
+
+// topicName translates a topic ARN to a topic resource name.
+func topicName(ctx context.Context, arn *string) (string, error) {
+	if arn == nil || *arn == "" {
+		return "", gcp.NewAPIError("InvalidParameter", "missing TopicArn", nil)
+	}
+	name := *arn
+	if strings.HasPrefix(name, "projects/") {
+		return name, nil
+	}
+	if strings.HasPrefix(name, "arn:") {
+		parts := strings.SplitN(name, ":", 6)
+		if len(parts) != 6 {
+			return "", gcp.NewAPIError("InvalidParameter", "invalid ARN: "+name, nil)
+		}
+		name = parts[5]
+	}
+
+	project, err := gcp.ProjectID(ctx)
+	if err != nil {
+		return "", gcp.NewAPIError("InternalError", "project not found", err)
+	}
+	return "projects/" + project + "/topics/" + invalidChars.ReplaceAllString(name, "_"), nil
+}
+
+// apiError translates a Pub/Sub error.
+func apiError(err error) error {
+	e, ok := err.(*gcp.Error)
+	if !ok {
+		return gcp.NewAPIError("InternalError", err.Error(), err)
+	}
+	switch e.Code {
+	case http.StatusNotFound:
+		return gcp.NewAPIError("NotFound", e.Error(), err)
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return gcp.NewAPIError("AuthorizationError", e.Error(), err)
+	case http.StatusBadRequest:
+		return gcp.NewAPIError("InvalidParameter", e.Error(), err)
+	case http.StatusTooManyRequests:
+		return gcp.NewAPIError("Throttled", e.Error(), err)
+	}
+	return gcp.NewAPIError("InternalError", e.Error(), err)
+}
@@ -0,0 +1,84 @@
+package sns
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcp"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// PublishInput is the input of Publish.
+type PublishInput struct {
+	// TopicArn is the topic's ARN, or resource name.
+	// TargetArn is an alias for TopicArn.
+	TopicArn  *string
+	TargetArn *string
+
+	Message           *string
+	Subject           *string
+	MessageAttributes map[string]types.MessageAttributeValue
+
+	// MessageGroupId is used as the ordering key.
+	MessageGroupId *string
+}
+
+// PublishOutput is the output of Publish.
+type PublishOutput struct {
+	MessageId *string
+}
+
+// Publish publishes a message to a topic.
+func (c *Client) Publish(ctx context.Context, params *PublishInput, optFns ...func(*Options)) (*PublishOutput, error) {
+	if params == nil {
+		params = &PublishInput{}
+	}
+	arn := params.TopicArn
+	if arn == nil {
+		arn = params.TargetArn
+	}
+	name, err := topicName(ctx, arn)
+	if err != nil {
+		return nil, err
+	}
+	if params.Message == nil {
+		return nil, gcp.NewAPIError("InvalidParameter", "missing Message", nil)
+	}
+
+	attrs := map[string]string{}
+	for k, v := range params.MessageAttributes {
+		if aws.ToString(v.DataType) == "Binary" {
+			attrs[k] = base64.StdEncoding.EncodeToString(v.BinaryValue)
+		} else {
+			attrs[k] = aws.ToString(v.StringValue)
+		}
+	}
+	if params.Subject != nil {
+		attrs["Subject"] = *params.Subject
+	}
+
+	type message struct {
+		Data        []byte            `json:"data"`
+		Attributes  map[string]string `json:"attributes,omitempty"`
+		OrderingKey string            `json:"orderingKey,omitempty"`
+	}
+	req := struct {
+		Messages []message `json:"messages"`
+	}{[]message{{
+		Data:        []byte(*params.Message),
+		Attributes:  attrs,
+		OrderingKey: aws.ToString(params.MessageGroupId),
+	}}}
+
+	var res struct {
+		MessageIds []string `json:"messageIds"`
+	}
+	if err := c.invoke(ctx, optFns, "POST", name+":publish", req, &res); err != nil {
+		return nil, err
+	}
+	if len(res.MessageIds) != 1 {
+		return nil, gcp.NewAPIError("InternalError", "unexpected publish response", nil)
+	}
+	return &PublishOutput{MessageId: aws.String(res.MessageIds[0])}, nil
+}
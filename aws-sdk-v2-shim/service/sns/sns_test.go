@@ -0,0 +1,112 @@
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/internal/gcptest"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+func TestMain(m *testing.M) {
+	// A fake metadata server, to authorize requests.
+	metadata := gcptest.NewMetadataServer(nil)
+	code := m.Run()
+	metadata.Close()
+	os.Exit(code)
+}
+
+func TestClient_Publish(t *testing.T) {
+	type message struct {
+		Data        []byte            `json:"data"`
+		Attributes  map[string]string `json:"attributes"`
+		OrderingKey string            `json:"orderingKey"`
+	}
+	var path string
+	var published []message
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []message `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+			return
+		}
+		path = r.URL.Path
+		published = req.Messages
+		w.Write([]byte(`{"messageIds":["42"]}`))
+	}))
+	defer server.Close()
+
+	client := New(Options{BaseEndpoint: aws.String(server.URL + "/v1/")})
+	out, err := client.Publish(context.Background(), &PublishInput{
+		TopicArn:       aws.String("arn:aws:sns:us-east-1:123456789012:orders.fifo"),
+		Message:        aws.String("hello"),
+		Subject:        aws.String("greeting"),
+		MessageGroupId: aws.String("group"),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"kind":  {DataType: aws.String("String"), StringValue: aws.String("test")},
+			"count": {DataType: aws.String("Number"), StringValue: aws.String("3")},
+			"blob":  {DataType: aws.String("Binary"), BinaryValue: []byte{1, 2, 3}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.ToString(out.MessageId) != "42" {
+		t.Errorf("MessageId = %q, want %q", aws.ToString(out.MessageId), "42")
+	}
+
+	if want := "/v1/projects/project/topics/orders.fifo:publish"; path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if len(published) != 1 {
+		t.Fatalf("published %d messages, want 1", len(published))
+	}
+	msg := published[0]
+	if string(msg.Data) != "hello" || msg.OrderingKey != "group" {
+		t.Errorf("published %+v", msg)
+	}
+	want := map[string]string{
+		"kind":    "test",
+		"count":   "3",
+		"blob":    "AQID",
+		"Subject": "greeting",
+	}
+	for k, v := range want {
+		if msg.Attributes[k] != v {
+			t.Errorf("attribute %s = %q, want %q", k, msg.Attributes[k], v)
+		}
+	}
+	if len(msg.Attributes) != len(want) {
+		t.Errorf("attributes = %v, want %v", msg.Attributes, want)
+	}
+}
+
+func TestTopicName(t *testing.T) {
+	tests := []struct{ arn, name string }{
+		{"arn:aws:sns:us-east-1:123456789012:orders", "projects/project/topics/orders"},
+		{"arn:aws:sns:us-east-1:123456789012:a/b", "projects/project/topics/a_b"},
+		{"projects/other/topics/events", "projects/other/topics/events"},
+	}
+	for _, tt := range tests {
+		got, err := topicName(context.Background(), aws.String(tt.arn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.name {
+			t.Errorf("topicName(%q) = %q, want %q", tt.arn, got, tt.name)
+		}
+	}
+
+	_, err := topicName(context.Background(), aws.String("arn:aws:sns"))
+	if e, ok := err.(interface{ ErrorCode() string }); !ok || e.ErrorCode() != "InvalidParameter" {
+		t.Errorf("topicName() = %v, want InvalidParameter", err)
+	}
+}
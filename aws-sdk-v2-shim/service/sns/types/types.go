@@ -0,0 +1,10 @@
+// Package types declares the types used by the SNS API.
+package types
+
+// MessageAttributeValue is the value of a message attribute.
+type MessageAttributeValue struct {
+	// DataType is String, Number, or Binary.
+	DataType    *string
+	StringValue *string
+	BinaryValue []byte
+}
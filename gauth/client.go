@@ -0,0 +1,64 @@
+// Package gauth authenticates service-to-service calls with Google-signed ID tokens:
+// clients attach them with NewClient, servers verify them with a Verifier.
+package gauth
+
+import (
+	"net/http"
+
+	"github.com/ncruces/go-gcp/gmeta"
+)
+
+// NewClient returns an http.Client that attaches ID tokens,
+// with the given audience, to its requests,
+// to call private Cloud Run services, and Cloud Functions.
+// An empty audience uses the scheme and host of each request's URL.
+func NewClient(audience string) *http.Client {
+	return &http.Client{Transport: &Transport{Audience: audience}}
+}
+
+// Transport is an http.RoundTripper that attaches ID tokens to requests.
+// Tokens are fetched from the metadata server, and cached until they expire.
+//
+// Requests that already carry an Authorization header
+// get the ID token in the X-Serverless-Authorization header instead,
+// which Cloud Run checks, and strips, before forwarding the request.
+//
+// Use gtrace.NewHTTPTransport as the Base to trace requests.
+type Transport struct {
+	// Base is the underlying transport, the default is http.DefaultTransport.
+	Base http.RoundTripper
+
+	// Audience is the audience of the ID tokens.
+	// If empty, the scheme and host of each request's URL is used.
+	Audience string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	audience := t.Audience
+	if audience == "" {
+		audience = req.URL.Scheme + "://" + req.URL.Host
+	}
+
+	token, err := gmeta.IdentityToken(req.Context(), audience)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	// RoundTrippers must not modify the request.
+	req = req.Clone(req.Context())
+	if req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.Header.Set("X-Serverless-Authorization", "Bearer "+token)
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
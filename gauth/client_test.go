@@ -0,0 +1,71 @@
+package gauth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/computeMetadata/v1/instance/service-accounts/default/identity" {
+			http.NotFound(w, r)
+			return
+		}
+		claims := fmt.Sprintf(`{"aud":%q,"exp":%d}`, r.URL.Query().Get("audience"), time.Now().Add(time.Hour).Unix())
+		fmt.Fprint(w, "header."+base64.RawURLEncoding.EncodeToString([]byte(claims))+".signature")
+	}))
+	os.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(metadata.URL, "http://"))
+
+	code := m.Run()
+	metadata.Close()
+	os.Exit(code)
+}
+
+func TestNewClient(t *testing.T) {
+	var auth, serverless string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		serverless = r.Header.Get("X-Serverless-Authorization")
+	}))
+	defer server.Close()
+
+	client := NewClient("")
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if claims := tokenClaims(t, auth); !strings.Contains(claims, server.URL) {
+		t.Errorf("audience = %s, want %s", claims, server.URL)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Authorization", "Basic xxx")
+	res, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if auth != "Basic xxx" || serverless == "" {
+		t.Errorf("Authorization = %q, X-Serverless-Authorization = %q", auth, serverless)
+	}
+}
+
+func tokenClaims(t *testing.T, header string) string {
+	t.Helper()
+	parts := strings.Split(strings.TrimPrefix(header, "Bearer "), ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token: %q", header)
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
@@ -0,0 +1,267 @@
+package gauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPClient is used to fetch the public keys that verify tokens.
+// It should be set before first use.
+var HTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// JWKS endpoints of Google ID tokens, and IAP assertions.
+var (
+	googleCerts = newKeySet("https://www.googleapis.com/oauth2/v3/certs")
+	iapCerts    = newKeySet("https://www.gstatic.com/iap/verify/public_key-jwk")
+)
+
+// leeway tolerates clock skew when checking token times.
+const leeway = time.Minute
+
+// Claims are the verified claims of an ID token, or IAP assertion.
+type Claims struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        audience `json:"aud"`
+	Email           string   `json:"email"`
+	EmailVerified   bool     `json:"email_verified"`
+	HostedDomain    string   `json:"hd"`
+	AuthorizedParty string   `json:"azp"`
+	IssuedAt        int64    `json:"iat"`
+	Expires         int64    `json:"exp"`
+}
+
+// audience is a string, or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(buf []byte) error {
+	var s string
+	if json.Unmarshal(buf, &s) == nil {
+		*a = audience{s}
+		return nil
+	}
+	return json.Unmarshal(buf, (*[]string)(a))
+}
+
+// verify verifies the signature, times, issuer, and audience of a JWT.
+func verify(ctx context.Context, token string, keys *keySet, issuers, audiences []string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("gauth: malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("gauth: malformed signature: %w", err)
+	}
+	key, err := keys.get(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(header.Alg, key, hash[:], sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if now.After(time.Unix(claims.Expires, 0).Add(leeway)) {
+		return nil, errors.New("gauth: token expired")
+	}
+	if now.Before(time.Unix(claims.IssuedAt, 0).Add(-leeway)) {
+		return nil, errors.New("gauth: token used before issued")
+	}
+	if !contains(issuers, claims.Issuer) {
+		return nil, fmt.Errorf("gauth: unexpected issuer: %s", claims.Issuer)
+	}
+	if !anyContains(audiences, claims.Audience) {
+		return nil, fmt.Errorf("gauth: unexpected audience: %s", strings.Join(claims.Audience, ", "))
+	}
+	return &claims, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, hash, sig []byte) error {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg == "RS256" && rsa.VerifyPKCS1v15(key, crypto.SHA256, hash, sig) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if alg == "ES256" && len(sig) == 64 {
+			r := new(big.Int).SetBytes(sig[:32])
+			s := new(big.Int).SetBytes(sig[32:])
+			if ecdsa.Verify(key, hash, r, s) {
+				return nil
+			}
+		}
+	}
+	return errors.New("gauth: invalid signature")
+}
+
+func decodeSegment(seg string, v any) error {
+	buf, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("gauth: malformed token: %w", err)
+	}
+	if err := json.Unmarshal(buf, v); err != nil {
+		return fmt.Errorf("gauth: malformed token: %w", err)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func anyContains(list, values []string) bool {
+	for _, v := range values {
+		if contains(list, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// A keySet caches the keys of a JWKS endpoint.
+type keySet struct {
+	url string
+
+	mtx     sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+	expires time.Time
+}
+
+func newKeySet(url string) *keySet {
+	return &keySet{url: url}
+}
+
+// get returns the key with the given ID,
+// refreshing the keys if they expired, or the ID is unknown.
+// Refreshes are attempted at most once a minute, even if they fail,
+// so bogus IDs, or a failing endpoint, can't hammer the endpoint;
+// if a refresh fails, cached keys are still used.
+func (s *keySet) get(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+	key, ok := s.keys[kid]
+	if (!ok || !now.Before(s.expires)) && now.Sub(s.fetched) >= time.Minute {
+		s.fetched = now
+		err := s.refresh(ctx)
+		if err == nil {
+			key, ok = s.keys[kid]
+		} else if !ok {
+			return nil, err
+		}
+	}
+	if ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("gauth: unknown key: %s", kid)
+}
+
+func (s *keySet) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gauth: fetch keys: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("gauth: fetch keys: http status %d: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&jwks); err != nil {
+		return fmt.Errorf("gauth: fetch keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		switch {
+		case k.Kty == "RSA":
+			n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+			e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case k.Kty == "EC" && k.Crv == "P-256":
+			x, err1 := base64.RawURLEncoding.DecodeString(k.X)
+			y, err2 := base64.RawURLEncoding.DecodeString(k.Y)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			}
+		}
+	}
+
+	s.keys = keys
+	s.expires = time.Now().Add(maxAge(res.Header.Get("Cache-Control")))
+	return nil
+}
+
+// maxAge parses the max-age of a Cache-Control header,
+// defaulting to one hour.
+func maxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if age, ok := strings.CutPrefix(directive, "max-age="); ok {
+			if secs, err := strconv.Atoi(age); err == nil {
+				return time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return time.Hour
+}
@@ -0,0 +1,108 @@
+package gauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	googleIssuers = []string{"accounts.google.com", "https://accounts.google.com"}
+	iapIssuers    = []string{"https://cloud.google.com/iap"}
+)
+
+// A Verifier authenticates requests with Google-signed ID tokens,
+// sent as Authorization bearer tokens,
+// or Identity-Aware Proxy (IAP) assertions,
+// sent in the X-Goog-IAP-JWT-Assertion header.
+// Keys are fetched from Google, and cached.
+type Verifier struct {
+	// Audiences are the accepted audiences.
+	// For ID tokens, this is usually the URL of the service;
+	// for IAP, /projects/PROJECT_NUMBER/global/backendServices/SERVICE_ID,
+	// or /projects/PROJECT_NUMBER/apps/PROJECT_ID.
+	// If empty, all requests are rejected.
+	Audiences []string
+
+	// Emails are the accepted identities.
+	// ID tokens must also carry a verified email
+	// (IAP only asserts verified identities).
+	// If empty, any identity is accepted.
+	Emails []string
+}
+
+// NewVerifier returns a Verifier that accepts the given audiences.
+func NewVerifier(audiences ...string) *Verifier {
+	return &Verifier{Audiences: audiences}
+}
+
+// Verify authenticates a request, returning the verified claims.
+// IAP assertions take precedence over ID tokens.
+func (v *Verifier) Verify(r *http.Request) (*Claims, error) {
+	var claims *Claims
+	var err error
+	var verified bool
+	if assertion := r.Header.Get("X-Goog-IAP-JWT-Assertion"); assertion != "" {
+		claims, err = verify(r.Context(), assertion, iapCerts, iapIssuers, v.Audiences)
+		verified = true
+	} else if token, ok := bearerToken(r); ok {
+		claims, err = verify(r.Context(), token, googleCerts, googleIssuers, v.Audiences)
+		verified = err == nil && claims.EmailVerified
+	} else {
+		return nil, ErrNoCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Emails) > 0 && !(verified && contains(v.Emails, claims.Email)) {
+		return nil, ErrForbidden
+	}
+	return claims, nil
+}
+
+// Handler returns an http.Handler that authenticates requests before
+// passing them on to h, with the verified claims in the request's context.
+// Requests without valid credentials get a 401 Unauthorized response,
+// and those with unaccepted identities a 403 Forbidden.
+func (v *Verifier) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := v.Verify(r)
+		switch {
+		case err == nil:
+			h.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+		case errors.Is(err, ErrForbidden):
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		default:
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		}
+	})
+}
+
+// Errors returned by Verify, besides those for invalid tokens.
+var (
+	ErrNoCredentials = errors.New("gauth: no credentials")
+	ErrForbidden     = errors.New("gauth: identity not accepted")
+)
+
+func bearerToken(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:]), true
+	}
+	return "", false
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries claims.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// FromContext returns the verified claims stored in ctx, if any.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
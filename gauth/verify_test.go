@@ -0,0 +1,185 @@
+package gauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVerifier(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []any{map[string]string{
+			"kty": "RSA", "kid": "rsa", "alg": "RS256",
+			"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		}}})
+	}))
+	defer google.Close()
+	iap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []any{map[string]string{
+			"kty": "EC", "kid": "ec", "alg": "ES256", "crv": "P-256",
+			"x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32))),
+		}}})
+	}))
+	defer iap.Close()
+
+	defer func(g, i *keySet) { googleCerts, iapCerts = g, i }(googleCerts, iapCerts)
+	googleCerts, iapCerts = newKeySet(google.URL), newKeySet(iap.URL)
+
+	sign := func(alg, kid string, claims map[string]any) string {
+		header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+		payload, _ := json.Marshal(claims)
+		msg := b64(header) + "." + b64(payload)
+		hash := sha256.Sum256([]byte(msg))
+
+		var sig []byte
+		switch alg {
+		case "RS256":
+			sig, _ = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, hash[:])
+		case "ES256":
+			r, s, _ := ecdsa.Sign(rand.Reader, ecKey, hash[:])
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+		return msg + "." + b64(sig)
+	}
+	claims := func(iss, aud, email string, exp time.Duration) map[string]any {
+		now := time.Now()
+		return map[string]any{
+			"iss": iss, "aud": aud, "sub": "12345", "email": email, "email_verified": true,
+			"iat": now.Unix(), "exp": now.Add(exp).Unix(),
+		}
+	}
+
+	unverified := func(claims map[string]any) map[string]any {
+		delete(claims, "email_verified")
+		return claims
+	}
+
+	verifier := NewVerifier("https://example.run.app", "/projects/1/apps/example")
+	verifier.Emails = []string{"caller@example.iam.gserviceaccount.com", "user@example.com"}
+
+	var got *Claims
+	handler := verifier.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		token  string
+		status int
+	}{
+		{"none", "", "", http.StatusUnauthorized},
+		{"id token", "Authorization",
+			sign("RS256", "rsa", claims("https://accounts.google.com", "https://example.run.app", "caller@example.iam.gserviceaccount.com", time.Hour)),
+			http.StatusOK},
+		{"iap", "X-Goog-IAP-JWT-Assertion",
+			sign("ES256", "ec", claims("https://cloud.google.com/iap", "/projects/1/apps/example", "user@example.com", time.Hour)),
+			http.StatusOK},
+		{"expired", "Authorization",
+			sign("RS256", "rsa", claims("https://accounts.google.com", "https://example.run.app", "caller@example.iam.gserviceaccount.com", -time.Hour)),
+			http.StatusUnauthorized},
+		{"audience", "Authorization",
+			sign("RS256", "rsa", claims("https://accounts.google.com", "https://other.run.app", "caller@example.iam.gserviceaccount.com", time.Hour)),
+			http.StatusUnauthorized},
+		{"issuer", "Authorization",
+			sign("RS256", "rsa", claims("https://cloud.google.com/iap", "https://example.run.app", "caller@example.iam.gserviceaccount.com", time.Hour)),
+			http.StatusUnauthorized},
+		{"algorithm", "X-Goog-IAP-JWT-Assertion",
+			sign("RS256", "rsa", claims("https://cloud.google.com/iap", "/projects/1/apps/example", "user@example.com", time.Hour)),
+			http.StatusUnauthorized},
+		{"key", "Authorization",
+			sign("RS256", "other", claims("https://accounts.google.com", "https://example.run.app", "caller@example.iam.gserviceaccount.com", time.Hour)),
+			http.StatusUnauthorized},
+		{"unverified", "Authorization",
+			sign("RS256", "rsa", unverified(claims("https://accounts.google.com", "https://example.run.app", "caller@example.iam.gserviceaccount.com", time.Hour))),
+			http.StatusForbidden},
+		{"email", "Authorization",
+			sign("RS256", "rsa", claims("https://accounts.google.com", "https://example.run.app", "other@example.com", time.Hour)),
+			http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header == "Authorization" {
+				req.Header.Set(tt.header, "Bearer "+tt.token)
+			} else if tt.header != "" {
+				req.Header.Set(tt.header, tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusOK && (got == nil || got.Subject != "12345") {
+				t.Errorf("claims = %+v", got)
+			}
+		})
+	}
+}
+
+func TestKeySet_get(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	var fetches int
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0")
+		json.NewEncoder(w).Encode(map[string]any{"keys": []any{map[string]string{
+			"kty": "RSA", "kid": "rsa", "alg": "RS256",
+			"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	defer func(c *http.Client) { HTTPClient = c }(HTTPClient)
+	HTTPClient = server.Client()
+	ctx := context.Background()
+	keys := newKeySet(server.URL)
+
+	if _, err := keys.get(ctx, "rsa"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Expired keys are still used if refreshing them fails.
+	failing = true
+	keys.fetched = time.Now().Add(-2 * time.Minute)
+	if _, err := keys.get(ctx, "rsa"); err != nil {
+		t.Errorf("get() = %v, want cached key", err)
+	}
+
+	// Failed refreshes aren't retried for a minute, even for unknown keys.
+	for i := 0; i < 3; i++ {
+		if _, err := keys.get(ctx, "other"); err == nil {
+			t.Error("get() found an unknown key")
+		}
+		if _, err := keys.get(ctx, "rsa"); err != nil {
+			t.Errorf("get() = %v, want cached key", err)
+		}
+	}
+	if fetches != 2 {
+		t.Errorf("got %d fetches, want 2", fetches)
+	}
+}
@@ -0,0 +1,231 @@
+// Package gcache implements a read-through cache on Google Cloud Storage,
+// shared by all instances of a service.
+//
+// Values are stored as JSON objects, with their time-to-live,
+// and kept in memory, in front of Cloud Storage.
+// When an entry expires, a gmutex lock ensures only one instance
+// recomputes it, while others wait for the new value.
+package gcache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex"
+)
+
+// A Cache caches values of type T in a Cloud Storage bucket.
+type Cache[T any] struct {
+	bucket string
+	prefix string
+
+	// LockTTL is the time-to-live of the locks that protect fills,
+	// the default is one minute.
+	// Locks are extended while fills run,
+	// so this only matters if an instance crashes mid-fill.
+	LockTTL time.Duration
+
+	mtx      sync.Mutex
+	memory   map[string]entry[T]
+	inflight map[string]*call[T]
+}
+
+type entry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+type call[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+
+	// invalidated is set, under the Cache's mtx,
+	// if the key is invalidated while the call is in flight.
+	invalidated bool
+}
+
+// New creates a Cache in the given bucket,
+// with entries stored under prefix (like "cache/").
+func New[T any](bucket, prefix string) *Cache[T] {
+	return &Cache[T]{
+		bucket:   bucket,
+		prefix:   prefix,
+		memory:   map[string]entry[T]{},
+		inflight: map[string]*call[T]{},
+	}
+}
+
+// GetOrFill returns the cached value for key,
+// or calls fill to compute it, and caches it for ttl.
+//
+// Only one call to fill, across all instances, runs for a key at a time;
+// concurrent callers wait for, and share, its result.
+// Since its result is shared, fill runs with a context
+// that isn't canceled when the caller that started it gives up.
+// Errors from fill are returned, and not cached.
+func (c *Cache[T]) GetOrFill(ctx context.Context, key string, ttl time.Duration, fill func(ctx context.Context) (T, error)) (T, error) {
+	for {
+		c.mtx.Lock()
+		if e, ok := c.memory[key]; ok {
+			if time.Now().Before(e.expires) {
+				c.mtx.Unlock()
+				return e.value, nil
+			}
+			delete(c.memory, key)
+		}
+		cl, ok := c.inflight[key]
+		if !ok {
+			cl = &call[T]{done: make(chan struct{})}
+			c.inflight[key] = cl
+			go c.fill(context.WithoutCancel(ctx), key, ttl, fill, cl)
+		}
+		invalidated := cl.invalidated
+		c.mtx.Unlock()
+
+		select {
+		case <-cl.done:
+			// An invalidated call may return the old value:
+			// wait for it to clean up, then fill again.
+			if !invalidated {
+				return cl.value, cl.err
+			}
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// fill loads the entry for key, on behalf of all callers waiting for cl,
+// and keeps it in memory, evicting expired entries.
+func (c *Cache[T]) fill(ctx context.Context, key string, ttl time.Duration, fill func(ctx context.Context) (T, error), cl *call[T]) {
+	var expires time.Time
+	cl.value, expires, cl.err = c.load(ctx, key, ttl, fill, cl)
+
+	c.mtx.Lock()
+	if cl.err == nil && !cl.invalidated {
+		now := time.Now()
+		for k, e := range c.memory {
+			if !now.Before(e.expires) {
+				delete(c.memory, k)
+			}
+		}
+		c.memory[key] = entry[T]{cl.value, expires}
+	}
+	delete(c.inflight, key)
+	c.mtx.Unlock()
+	close(cl.done)
+}
+
+// Invalidate removes the entry for key, from memory, and Cloud Storage.
+// A fill in flight on this instance won't cache its value;
+// callers already waiting for it still get it,
+// while later callers fill the entry again.
+func (c *Cache[T]) Invalidate(ctx context.Context, key string) error {
+	c.mtx.Lock()
+	delete(c.memory, key)
+	if cl, ok := c.inflight[key]; ok {
+		cl.invalidated = true
+	}
+	c.mtx.Unlock()
+
+	b, err := gmutex.NewBackend(ctx, c.bucket, c.prefix+key)
+	if err != nil {
+		return err
+	}
+	status, err := b.Delete(ctx, "")
+	if err != nil {
+		return fmt.Errorf("gcache: invalidate %s: %w", key, err)
+	}
+	if status != http.StatusOK && status != http.StatusNoContent && status != http.StatusNotFound {
+		return fmt.Errorf("gcache: invalidate %s: http status %d: %s", key, status, http.StatusText(status))
+	}
+	return nil
+}
+
+// load reads the entry for key from Cloud Storage,
+// filling it under a lock if it's missing, or expired.
+// If cl is invalidated, the value written is deleted.
+func (c *Cache[T]) load(ctx context.Context, key string, ttl time.Duration, fill func(ctx context.Context) (T, error), cl *call[T]) (value T, expires time.Time, err error) {
+	b, err := gmutex.NewBackend(ctx, c.bucket, c.prefix+key)
+	if err != nil {
+		return value, expires, err
+	}
+
+	if value, expires, ok := c.read(ctx, b); ok {
+		return value, expires, nil
+	}
+
+	lockTTL := c.LockTTL
+	if lockTTL <= 0 {
+		lockTTL = time.Minute
+	}
+	m, err := gmutex.New(ctx, c.bucket, c.prefix+key+".lock", lockTTL)
+	if err != nil {
+		return value, expires, err
+	}
+
+	var loaded bool
+	err = gmutex.WithLock(ctx, m, func(ctx context.Context) error {
+		// Another instance may have filled the entry while we waited.
+		if value, expires, loaded = c.read(ctx, b); loaded {
+			return nil
+		}
+
+		value, err = fill(ctx)
+		if err != nil {
+			return err
+		}
+		expires = time.Now().Add(ttl)
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		loaded = true
+
+		// Cache writes are best effort: the value is still returned.
+		status, generation, err := b.Create(ctx, "", gmutex.Attrs{TTL: ttl}, bytes.NewReader(data))
+		if err == nil && status == http.StatusOK {
+			// If invalidated before the write, Invalidate may have missed it.
+			// Delete only this write, not a newer one.
+			c.mtx.Lock()
+			invalidated := cl.invalidated
+			c.mtx.Unlock()
+			if invalidated {
+				b.Delete(ctx, generation)
+			}
+		}
+		return nil
+	})
+	if loaded {
+		// Failing to release the lock doesn't invalidate the value.
+		return value, expires, nil
+	}
+	return value, expires, err
+}
+
+// read reads a fresh entry from Cloud Storage, if there is one.
+// Expiration is determined using server time.
+func (c *Cache[T]) read(ctx context.Context, b gmutex.Backend) (value T, expires time.Time, ok bool) {
+	var buf bytes.Buffer
+	status, attrs, err := b.Inspect(ctx, &buf)
+	if err != nil || status != http.StatusOK || attrs.TTL <= 0 {
+		return value, expires, false
+	}
+
+	remaining := attrs.Modified.Add(attrs.TTL).Sub(attrs.Date)
+	if remaining <= 0 {
+		return value, expires, false
+	}
+	if err := json.Unmarshal(buf.Bytes(), &value); err != nil {
+		return value, expires, false
+	}
+	return value, time.Now().Add(remaining), true
+}
@@ -0,0 +1,193 @@
+package gcache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+)
+
+func TestGetOrFill(t *testing.T) {
+	server := gmutextest.Start(t, "bucket")
+
+	var fills atomic.Int32
+	fill := func(ctx context.Context) (string, error) {
+		n := fills.Add(1)
+		return "value" + strconv.Itoa(int(n)), nil
+	}
+	ctx := context.Background()
+
+	// Concurrent callers, on two instances, share a single fill.
+	a := New[string]("bucket", "cache/")
+	b := New[string]("bucket", "cache/")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		c := a
+		if i%2 != 0 {
+			c = b
+		}
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrFill(ctx, "key", time.Hour, fill)
+			if err != nil || v != "value1" {
+				t.Errorf("GetOrFill() = %q, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := fills.Load(); n != 1 {
+		t.Errorf("got %d fills, want 1", n)
+	}
+
+	// Expired entries are filled again.
+	server.Advance(2 * time.Hour)
+	c := New[string]("bucket", "cache/")
+	if v, err := c.GetOrFill(ctx, "key", time.Hour, fill); err != nil || v != "value2" {
+		t.Errorf("GetOrFill() = %q, %v", v, err)
+	}
+
+	// Errors are not cached.
+	fail := func(ctx context.Context) (string, error) { return "", errors.New("fail") }
+	if _, err := c.GetOrFill(ctx, "other", time.Hour, fail); err == nil {
+		t.Error("want error")
+	}
+
+	if err := c.Invalidate(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.GetOrFill(ctx, "key", time.Hour, fill); err != nil || v != "value3" {
+		t.Errorf("GetOrFill() = %q, %v", v, err)
+	}
+}
+
+func TestGetOrFill_canceled(t *testing.T) {
+	gmutextest.Start(t, "bucket")
+	c := New[string]("bucket", "cache/")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fill := func(ctx context.Context) (string, error) {
+		close(started) // Panics if called twice.
+		select {
+		case <-release:
+			return "value", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	// The caller that starts the fill gives up,
+	// which doesn't fail others waiting for it.
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := c.GetOrFill(ctx, "key", time.Hour, fill)
+		errs <- err
+	}()
+	<-started
+
+	values := make(chan string)
+	go func() {
+		v, err := c.GetOrFill(context.Background(), "key", time.Hour, fill)
+		if err != nil {
+			t.Error(err)
+		}
+		values <- v
+	}()
+
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("GetOrFill() = %v, want context.Canceled", err)
+	}
+	close(release)
+	if v := <-values; v != "value" {
+		t.Errorf("GetOrFill() = %q, want %q", v, "value")
+	}
+}
+
+func TestGetOrFill_evict(t *testing.T) {
+	gmutextest.Start(t, "bucket")
+	c := New[string]("bucket", "cache/")
+	ctx := context.Background()
+	fill := func(ctx context.Context) (string, error) { return "value", nil }
+
+	// Expired entries are evicted from memory when new ones are added.
+	c.memory["stale"] = entry[string]{"stale", time.Now().Add(-time.Second)}
+	if _, err := c.GetOrFill(ctx, "key", time.Hour, fill); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.memory["stale"]; ok || len(c.memory) != 1 {
+		t.Errorf("memory = %v, want only key", c.memory)
+	}
+
+	// And when read, even if filling them again fails.
+	c.memory["stale"] = entry[string]{"stale", time.Now().Add(-time.Second)}
+	fail := func(ctx context.Context) (string, error) { return "", errors.New("fail") }
+	if _, err := c.GetOrFill(ctx, "stale", time.Hour, fail); err == nil {
+		t.Error("want error")
+	}
+	if _, ok := c.memory["stale"]; ok {
+		t.Errorf("memory = %v, want only key", c.memory)
+	}
+}
+
+func TestGetOrFill_invalidate(t *testing.T) {
+	server := gmutextest.Start(t, "bucket")
+	c := New[string]("bucket", "cache/")
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var fills atomic.Int32
+	fill := func(ctx context.Context) (string, error) {
+		if fills.Add(1) == 1 {
+			close(started)
+			<-release
+			return "old", nil
+		}
+		return "new", nil
+	}
+
+	before := make(chan string)
+	go func() {
+		v, _ := c.GetOrFill(ctx, "key", time.Hour, fill)
+		before <- v
+	}()
+	<-started
+
+	// Invalidate while the fill is in flight.
+	if err := c.Invalidate(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	after := make(chan string)
+	go func() {
+		v, _ := c.GetOrFill(ctx, "key", time.Hour, fill)
+		after <- v
+	}()
+	close(release)
+
+	// Callers waiting before get the old value, later ones the new one.
+	if v := <-before; v != "old" {
+		t.Errorf("GetOrFill() = %q, want %q", v, "old")
+	}
+	if v := <-after; v != "new" {
+		t.Errorf("GetOrFill() = %q, want %q", v, "new")
+	}
+
+	// The old value isn't cached, in memory, or Cloud Storage.
+	if data, _, _ := server.Object("bucket", "cache/key"); string(data) != `"new"` {
+		t.Errorf("stored %s, want %q", data, `"new"`)
+	}
+	if v, err := c.GetOrFill(ctx, "key", time.Hour, fill); err != nil || v != "new" {
+		t.Errorf("GetOrFill() = %q, %v", v, err)
+	}
+	if n := fills.Load(); n != 2 {
+		t.Errorf("got %d fills, want 2", n)
+	}
+}
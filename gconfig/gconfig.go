@@ -0,0 +1,211 @@
+// Package gconfig loads typed configuration from environment variables.
+package gconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ncruces/go-gcp/glog"
+	"github.com/ncruces/go-gcp/gmutex"
+	"github.com/ncruces/go-gcp/gsecrets"
+)
+
+// Load populates the struct pointed to by cfg from environment variables,
+// as described by struct tags:
+//
+//	type Config struct {
+//		Port    int           `env:"PORT" default:"8080"`
+//		Timeout time.Duration `env:"TIMEOUT" default:"30s"`
+//		APIKey  string        `env:"API_KEY" required:"true" redact:"true"`
+//		Rules   []byte        `env:"RULES" default:"gs://my-bucket/rules.json"`
+//	}
+//
+// Fields without an env tag are skipped, unless they're structs,
+// which are loaded recursively.
+// Fields for unset variables, without a default, keep their value.
+//
+// Values of the form secret://NAME are resolved with gsecrets.Get,
+// and values of the form gs://BUCKET/OBJECT are read from Cloud Storage.
+//
+// Supported field types are strings, byte slices, booleans, numbers,
+// time.Duration, string slices (comma separated),
+// and types implementing encoding.TextUnmarshaler.
+//
+// After loading, if cfg has a Validate method, it is called.
+// All errors are reported together.
+// On success, the effective configuration is logged with glog,
+// redacting fields tagged redact:"true", and values from secret://.
+func Load(ctx context.Context, cfg any) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return errors.New("gconfig: cfg must be a pointer to a struct")
+	}
+
+	l := loader{ctx: ctx, log: map[string]any{}}
+	l.load(v.Elem())
+
+	if validator, ok := cfg.(interface{ Validate() error }); ok && len(l.errs) == 0 {
+		if err := validator.Validate(); err != nil {
+			l.errs = append(l.errs, fmt.Errorf("gconfig: %w", err))
+		}
+	}
+	if err := errors.Join(l.errs...); err != nil {
+		return err
+	}
+
+	glog.Infoj("gconfig: loaded configuration", l.log)
+	return nil
+}
+
+type loader struct {
+	ctx  context.Context
+	log  map[string]any
+	errs []error
+}
+
+func (l *loader) load(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				l.load(v.Field(i))
+			}
+			continue
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			value, ok = field.Tag.Lookup("default")
+		}
+		if !ok {
+			if field.Tag.Get("required") == "true" {
+				l.errs = append(l.errs, fmt.Errorf("gconfig: %s is required", name))
+			}
+			continue
+		}
+
+		redact := field.Tag.Get("redact") == "true"
+		resolved, err := l.resolve(value)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("gconfig: %s: %w", name, err))
+			continue
+		}
+		if err := setField(v.Field(i), resolved); err != nil {
+			l.errs = append(l.errs, fmt.Errorf("gconfig: %s: %w", name, err))
+			continue
+		}
+
+		if redact || strings.HasPrefix(value, "secret://") {
+			l.log[name] = "[REDACTED]"
+		} else {
+			l.log[name] = value
+		}
+	}
+}
+
+// resolve resolves secret:// and gs:// references.
+func (l *loader) resolve(value string) (string, error) {
+	if name, ok := strings.CutPrefix(value, "secret://"); ok {
+		data, err := gsecrets.Get(l.ctx, name)
+		return string(data), err
+	}
+	if path, ok := strings.CutPrefix(value, "gs://"); ok {
+		bucket, object, ok := strings.Cut(path, "/")
+		if !ok || bucket == "" || object == "" {
+			return "", errors.New("invalid reference: " + value)
+		}
+		b, err := gmutex.NewBackend(l.ctx, bucket, object)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		status, _, err := b.Inspect(l.ctx, &buf)
+		if err != nil {
+			return "", err
+		}
+		if status != http.StatusOK {
+			return "", fmt.Errorf("%s: http status %d: %s", value, status, http.StatusText(status))
+		}
+		return buf.String(), nil
+	}
+	return value, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setField(f reflect.Value, value string) error {
+	if u, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	if f.Type() == durationType {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 0, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 0, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Slice:
+		switch f.Type().Elem().Kind() {
+		case reflect.Uint8:
+			f.SetBytes([]byte(value))
+		case reflect.String:
+			var list []string
+			for _, s := range strings.Split(value, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					list = append(list, s)
+				}
+			}
+			f.Set(reflect.ValueOf(list).Convert(f.Type()))
+		default:
+			return fmt.Errorf("unsupported type: %s", f.Type())
+		}
+	default:
+		return fmt.Errorf("unsupported type: %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package gconfig
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex"
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+)
+
+type config struct {
+	Port    int           `env:"TEST_PORT" default:"8080"`
+	Debug   bool          `env:"TEST_DEBUG"`
+	Timeout time.Duration `env:"TEST_TIMEOUT" default:"30s"`
+	Hosts   []string      `env:"TEST_HOSTS"`
+	IP      net.IP        `env:"TEST_IP" default:"127.0.0.1"`
+	APIKey  string        `env:"TEST_API_KEY" required:"true" redact:"true"`
+	Rules   []byte        `env:"TEST_RULES"`
+	Nested  struct {
+		Ratio float64 `env:"TEST_RATIO"`
+	}
+	ignored string
+}
+
+func (c *config) Validate() error {
+	if c.Port <= 0 {
+		return errors.New("port must be positive")
+	}
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	gmutextest.Start(t, "bucket")
+
+	ctx := context.Background()
+	b, err := gmutex.NewBackend(ctx, "bucket", "rules.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := b.Create(ctx, "0", gmutex.Attrs{}, strings.NewReader(`{"allow":true}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{
+		"TEST_DEBUG":   "true",
+		"TEST_HOSTS":   "a.example.com, b.example.com",
+		"TEST_API_KEY": "s3cr3t",
+		"TEST_RULES":   "gs://bucket/rules.json",
+		"TEST_RATIO":   "0.5",
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+
+	var cfg config
+	if err := Load(ctx, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 8080 || !cfg.Debug || cfg.Timeout != 30*time.Second ||
+		len(cfg.Hosts) != 2 || cfg.Hosts[1] != "b.example.com" ||
+		!cfg.IP.Equal(net.IPv4(127, 0, 0, 1)) || cfg.APIKey != "s3cr3t" ||
+		string(cfg.Rules) != `{"allow":true}` || cfg.Nested.Ratio != 0.5 {
+		t.Errorf("Load() = %+v", cfg)
+	}
+
+	t.Setenv("TEST_API_KEY", "")
+	t.Setenv("TEST_PORT", "x")
+	err = Load(ctx, &config{})
+	if err == nil || !strings.Contains(err.Error(), "TEST_API_KEY is required") || !strings.Contains(err.Error(), "TEST_PORT") {
+		t.Errorf("Load() = %v", err)
+	}
+
+	t.Setenv("TEST_API_KEY", "s3cr3t")
+	t.Setenv("TEST_PORT", "-1")
+	if err := Load(ctx, &config{}); err == nil || !strings.Contains(err.Error(), "port must be positive") {
+		t.Errorf("Load() = %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+// Package gcounter implements distributed counters, and gauges,
+// on Google Cloud Storage, for cross-instance quota tracking,
+// and simple usage metering.
+//
+// Cloud Storage limits writes to an object to about one per second,
+// so values are sharded across multiple objects:
+// each update goes to a random shard (with compare-and-swap, see gkv),
+// and reads add up all shards.
+// Sustained update rates should stay below one per second per shard.
+package gcounter
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+
+	"github.com/ncruces/go-gcp/gkv"
+)
+
+type shards struct {
+	store *gkv.Store
+	n     int
+}
+
+type shard struct {
+	Value int64 `json:"value"`
+}
+
+func newShards(bucket, name string, n int) shards {
+	if n < 1 {
+		n = 1
+	}
+	return shards{store: gkv.New(bucket, name+"/"), n: n}
+}
+
+func (s shards) add(ctx context.Context, delta int64) error {
+	key := strconv.Itoa(rand.Intn(s.n))
+	_, err := gkv.UpdateJSON(ctx, s.store, key, func(v *shard) error {
+		v.Value += delta
+		return nil
+	})
+	return err
+}
+
+func (s shards) read(ctx context.Context) (int64, error) {
+	var mtx sync.Mutex
+	var total int64
+	var errs []error
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.n; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			var v shard
+			_, err := s.store.GetJSON(ctx, key, &v)
+			if errors.Is(err, gkv.ErrNotFound) {
+				err = nil
+			}
+
+			mtx.Lock()
+			defer mtx.Unlock()
+			total += v.Value
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (s shards) reset(ctx context.Context) error {
+	var errs []error
+	for i := 0; i < s.n; i++ {
+		if err := s.store.Delete(ctx, strconv.Itoa(i)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// A Counter is a monotonically increasing distributed counter.
+type Counter struct{ shards }
+
+// NewCounter creates a Counter, stored in the given bucket,
+// under the given name, sharded across n objects.
+func NewCounter(bucket, name string, n int) *Counter {
+	return &Counter{newShards(bucket, name, n)}
+}
+
+// Add increments the counter by delta, which must not be negative.
+func (c *Counter) Add(ctx context.Context, delta int64) error {
+	if delta < 0 {
+		return errors.New("gcounter: counters cannot decrease")
+	}
+	if delta == 0 {
+		return nil
+	}
+	return c.add(ctx, delta)
+}
+
+// Read returns the value of the counter, adding up all shards.
+func (c *Counter) Read(ctx context.Context) (int64, error) {
+	return c.read(ctx)
+}
+
+// Reset resets the counter to zero.
+// Concurrent updates may be lost.
+func (c *Counter) Reset(ctx context.Context) error {
+	return c.reset(ctx)
+}
+
+// A Gauge is a distributed value that can increase, and decrease.
+type Gauge struct{ shards }
+
+// NewGauge creates a Gauge, stored in the given bucket,
+// under the given name, sharded across n objects.
+func NewGauge(bucket, name string, n int) *Gauge {
+	return &Gauge{newShards(bucket, name, n)}
+}
+
+// Add adds delta (which may be negative) to the gauge.
+func (g *Gauge) Add(ctx context.Context, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	return g.add(ctx, delta)
+}
+
+// Read returns the value of the gauge, adding up all shards.
+func (g *Gauge) Read(ctx context.Context) (int64, error) {
+	return g.read(ctx)
+}
+
+// Reset resets the gauge to zero.
+// Concurrent updates may be lost.
+func (g *Gauge) Reset(ctx context.Context) error {
+	return g.reset(ctx)
+}
@@ -0,0 +1,51 @@
+package gcounter
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+)
+
+func TestCounter(t *testing.T) {
+	gmutextest.Start(t, "bucket")
+
+	ctx := context.Background()
+	c := NewCounter("bucket", "requests", 4)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		go func(delta int64) {
+			defer wg.Done()
+			if err := c.Add(ctx, delta); err != nil {
+				t.Error(err)
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	if n, err := c.Read(ctx); err != nil || n != 55 {
+		t.Errorf("Read() = %d, %v; want 55", n, err)
+	}
+	if err := c.Add(ctx, -1); err == nil {
+		t.Error("want error")
+	}
+	if err := c.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c.Read(ctx); err != nil || n != 0 {
+		t.Errorf("Read() = %d, %v; want 0", n, err)
+	}
+
+	g := NewGauge("bucket", "connections", 2)
+	for _, delta := range []int64{5, -2, 3, -4} {
+		if err := g.Add(ctx, delta); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := g.Read(ctx); err != nil || n != 2 {
+		t.Errorf("Read() = %d, %v; want 2", n, err)
+	}
+}
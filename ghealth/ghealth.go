@@ -0,0 +1,191 @@
+// Package ghealth implements liveness and readiness endpoints.
+//
+// Components register checks, which are run on each probe,
+// concurrently, with a Timeout.
+// Probes respond with 200 OK if all checks pass,
+// and 503 Service Unavailable otherwise,
+// describing each check's outcome, and timing, as JSON.
+// Checks that start, or stop, failing are logged.
+package ghealth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ncruces/go-gcp/glog"
+	"github.com/ncruces/go-gcp/gmutex"
+	"github.com/ncruces/go-gcp/gsecrets"
+	"github.com/ncruces/go-gcp/gshutdown"
+)
+
+// Timeout limits how long each check may take.
+var Timeout = 5 * time.Second
+
+// A Check reports whether a component is healthy.
+type Check func(ctx context.Context) error
+
+type check struct {
+	name    string
+	fn      Check
+	failing bool
+}
+
+var (
+	mtx       sync.Mutex
+	liveness  []*check
+	readiness []*check
+)
+
+// AddLiveness registers a liveness check.
+// Liveness checks should only fail if the instance needs restarting.
+func AddLiveness(name string, fn Check) {
+	mtx.Lock()
+	defer mtx.Unlock()
+	liveness = append(liveness, &check{name: name, fn: fn})
+}
+
+// AddReadiness registers a readiness check.
+// Readiness checks fail if the instance can't serve requests,
+// for example, if a dependency is unreachable.
+func AddReadiness(name string, fn Check) {
+	mtx.Lock()
+	defer mtx.Unlock()
+	readiness = append(readiness, &check{name: name, fn: fn})
+}
+
+// Handler returns an http.Handler that serves
+// /healthz (see Liveness), and /readyz (see Readiness).
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", Liveness())
+	mux.Handle("/readyz", Readiness())
+	return mux
+}
+
+// Liveness returns an http.Handler that runs liveness checks.
+func Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, &liveness, false)
+	})
+}
+
+// Readiness returns an http.Handler that runs readiness checks.
+// Readiness also fails once shutdown starts (see gshutdown.Context),
+// so the instance stops receiving traffic while draining.
+func Readiness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, &readiness, true)
+	})
+}
+
+// Result describes the outcome of a check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type response struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+func serve(w http.ResponseWriter, r *http.Request, checks *[]*check, ready bool) {
+	mtx.Lock()
+	list := *checks
+	mtx.Unlock()
+
+	res := response{Status: "ok", Checks: make(map[string]Result, len(list))}
+	results := run(r.Context(), list)
+	for i, c := range list {
+		res.Checks[c.name] = results[i]
+		if results[i].Status != "ok" {
+			res.Status = "failing"
+		}
+	}
+	if ready && gshutdown.Context().Err() != nil {
+		res.Status = "shutting down"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if res.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func run(ctx context.Context, list []*check) []Result {
+	results := make([]Result, len(list))
+
+	var wg sync.WaitGroup
+	for i, c := range list {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, Timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.fn(ctx)
+			duration := time.Since(start)
+
+			results[i] = Result{Status: "ok", Duration: duration.String()}
+			if err != nil {
+				results[i].Status = "failing"
+				results[i].Error = err.Error()
+			}
+			transition(c, err, duration)
+		}(i, c)
+	}
+	wg.Wait()
+	return results
+}
+
+// transition logs checks that start, or stop, failing.
+func transition(c *check, err error, duration time.Duration) {
+	mtx.Lock()
+	changed := c.failing != (err != nil)
+	c.failing = err != nil
+	mtx.Unlock()
+
+	switch {
+	case !changed:
+	case err != nil:
+		glog.Warningw("ghealth: check failing",
+			"check", c.name, "error", err.Error(), "duration", duration.String())
+	default:
+		glog.Noticew("ghealth: check recovered",
+			"check", c.name, "duration", duration.String())
+	}
+}
+
+// MutexCheck returns a Check that pings m (see gmutex.Mutex.Ping),
+// verifying its bucket is reachable with the necessary permissions.
+func MutexCheck(m *gmutex.Mutex) Check {
+	return m.Ping
+}
+
+// BucketCheck returns a Check that verifies a Cloud Storage bucket
+// is reachable with the permissions to use gmutex.
+func BucketCheck(bucket string) Check {
+	return func(ctx context.Context) error {
+		m, err := gmutex.New(ctx, bucket, "ghealth", 0)
+		if err != nil {
+			return err
+		}
+		return m.Ping(ctx)
+	}
+}
+
+// SecretCheck returns a Check that verifies a secret can be loaded
+// (see gsecrets.Get).
+func SecretCheck(name string) Check {
+	return func(ctx context.Context) error {
+		_, err := gsecrets.Get(ctx, name)
+		return err
+	}
+}
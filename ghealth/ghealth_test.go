@@ -0,0 +1,53 @@
+package ghealth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+)
+
+func TestHandler(t *testing.T) {
+	gmutextest.Start(t, "bucket")
+
+	var dbErr error
+	AddLiveness("loop", func(ctx context.Context) error { return nil })
+	AddReadiness("bucket", BucketCheck("bucket"))
+	AddReadiness("db", func(ctx context.Context) error { return dbErr })
+
+	handler := Handler()
+	probe := func(path string, want int) response {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, rec.Code, want)
+		}
+		var res response
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	if res := probe("/healthz", http.StatusOK); res.Checks["loop"].Status != "ok" {
+		t.Errorf("healthz = %+v", res)
+	}
+	if res := probe("/readyz", http.StatusOK); len(res.Checks) != 2 || res.Checks["bucket"].Duration == "" {
+		t.Errorf("readyz = %+v", res)
+	}
+
+	dbErr = errors.New("connection refused")
+	res := probe("/readyz", http.StatusServiceUnavailable)
+	if res.Status != "failing" || res.Checks["db"].Error != "connection refused" || res.Checks["bucket"].Status != "ok" {
+		t.Errorf("readyz = %+v", res)
+	}
+	probe("/healthz", http.StatusOK)
+
+	dbErr = nil
+	probe("/readyz", http.StatusOK)
+}
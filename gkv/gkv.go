@@ -0,0 +1,263 @@
+// Package gkv implements a key/value store on Google Cloud Storage,
+// for small configuration, and state, records.
+//
+// Each key is an object; its generation identifies each write,
+// so updates can be made conditional (compare-and-swap),
+// using the same conditional operations as gmutex.
+// Rate limiting, and other transient errors, are retried with backoff.
+package gkv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ncruces/go-gcp/gmutex"
+)
+
+// Errors returned by Store operations.
+var (
+	ErrNotFound = errors.New("gkv: key not found")
+	ErrConflict = errors.New("gkv: generation mismatch")
+)
+
+// A Store is a key/value store in a Cloud Storage bucket.
+// Keys are object names, relative to a prefix.
+type Store struct {
+	bucket string
+	prefix string
+}
+
+// New creates a Store in the given bucket,
+// with keys stored under prefix (like "config/").
+func New(bucket, prefix string) *Store {
+	return &Store{bucket: bucket, prefix: prefix}
+}
+
+// An Entry describes a key.
+type Entry struct {
+	Key        string
+	Generation string
+}
+
+// Get returns the value, and generation, of a key.
+// Returns ErrNotFound if the key doesn't exist.
+func (s *Store) Get(ctx context.Context, key string) (value []byte, generation string, err error) {
+	b, err := s.backend(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+	var attrs gmutex.Attrs
+	status, err := retry(ctx, func() (status int, err error) {
+		buf.Reset()
+		status, attrs, err = b.Inspect(ctx, &buf)
+		return status, err
+	})
+	if err := s.check("get", key, status, err); err != nil {
+		return nil, "", err
+	}
+	value = buf.Bytes()
+	if value == nil {
+		value = []byte{}
+	}
+	return value, attrs.Generation, nil
+}
+
+// Put sets the value of a key, returning its new generation.
+func (s *Store) Put(ctx context.Context, key string, value []byte) (generation string, err error) {
+	return s.put(ctx, key, value, "")
+}
+
+// PutIf sets the value of a key, if its generation matches,
+// returning its new generation.
+// A generation of "0" means the key must not exist.
+// Returns ErrConflict if the generation doesn't match.
+func (s *Store) PutIf(ctx context.Context, key string, value []byte, generation string) (string, error) {
+	if generation == "" {
+		return "", errors.New("gkv: empty generation")
+	}
+	return s.put(ctx, key, value, generation)
+}
+
+func (s *Store) put(ctx context.Context, key string, value []byte, generation string) (string, error) {
+	b, err := s.backend(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	var gen string
+	status, err := retry(ctx, func() (status int, err error) {
+		status, gen, err = b.Create(ctx, generation, gmutex.Attrs{}, bytes.NewReader(value))
+		return status, err
+	})
+	if err := s.check("put", key, status, err); err != nil {
+		return "", err
+	}
+	return gen, nil
+}
+
+// Delete deletes a key.
+// Deleting a key that doesn't exist is not an error.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	err := s.delete(ctx, key, "")
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// DeleteIf deletes a key, if its generation matches.
+// Returns ErrConflict if the generation doesn't match,
+// and ErrNotFound if the key doesn't exist.
+func (s *Store) DeleteIf(ctx context.Context, key, generation string) error {
+	if generation == "" {
+		return errors.New("gkv: empty generation")
+	}
+	return s.delete(ctx, key, generation)
+}
+
+func (s *Store) delete(ctx context.Context, key, generation string) error {
+	b, err := s.backend(ctx, key)
+	if err != nil {
+		return err
+	}
+	status, err := retry(ctx, func() (int, error) {
+		return b.Delete(ctx, generation)
+	})
+	return s.check("delete", key, status, err)
+}
+
+// Update atomically updates the value of a key:
+// fn is called with the current value (nil, if the key doesn't exist),
+// and its result is stored, if the key wasn't modified concurrently;
+// otherwise, fn is called again with the new value, after backing off.
+// Returns the new generation.
+func (s *Store) Update(ctx context.Context, key string, fn func(value []byte) ([]byte, error)) (string, error) {
+	var backoff gmutex.BackOff
+	for {
+		value, generation, err := s.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			generation = "0"
+		} else if err != nil {
+			return "", err
+		}
+
+		value, err = fn(value)
+		if err != nil {
+			return "", err
+		}
+
+		generation, err = s.PutIf(ctx, key, value, generation)
+		if !errors.Is(err, ErrConflict) {
+			return generation, err
+		}
+		if err := backoff.Wait(ctx); err != nil {
+			return "", err
+		}
+	}
+}
+
+// List lists the keys that start with prefix.
+func (s *Store) List(ctx context.Context, prefix string) ([]Entry, error) {
+	infos, err := gmutex.ListObjects(ctx, s.bucket, s.prefix+prefix)
+	if err != nil {
+		return nil, fmt.Errorf("gkv: %w", err)
+	}
+	entries := make([]Entry, 0, len(infos))
+	for _, info := range infos {
+		entries = append(entries, Entry{
+			Key:        strings.TrimPrefix(info.Object, s.prefix),
+			Generation: info.Generation,
+		})
+	}
+	return entries, nil
+}
+
+// GetJSON decodes the JSON value of a key into v,
+// returning its generation (see Store.Get).
+func (s *Store) GetJSON(ctx context.Context, key string, v any) (generation string, err error) {
+	value, generation, err := s.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(value, v); err != nil {
+		return "", fmt.Errorf("gkv: %s: %w", key, err)
+	}
+	return generation, nil
+}
+
+// PutJSON sets the value of a key to the JSON encoding of v,
+// if its generation matches, or unconditionally if generation is empty
+// (see Store.PutIf).
+func (s *Store) PutJSON(ctx context.Context, key string, v any, generation string) (string, error) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return s.put(ctx, key, value, generation)
+}
+
+// UpdateJSON atomically updates the JSON value of a key (see Store.Update).
+// fn is called with a pointer to the current value
+// (the zero value, if the key doesn't exist), which it should modify.
+func UpdateJSON[T any](ctx context.Context, s *Store, key string, fn func(v *T) error) (string, error) {
+	return s.Update(ctx, key, func(value []byte) ([]byte, error) {
+		var v T
+		if value != nil {
+			if err := json.Unmarshal(value, &v); err != nil {
+				return nil, fmt.Errorf("gkv: %s: %w", key, err)
+			}
+		}
+		if err := fn(&v); err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	})
+}
+
+func (s *Store) backend(ctx context.Context, key string) (gmutex.Backend, error) {
+	if key == "" {
+		return nil, errors.New("gkv: empty key")
+	}
+	return gmutex.NewBackend(ctx, s.bucket, s.prefix+key)
+}
+
+// retry calls op, backing off and retrying
+// while it fails with a transient status.
+func retry(ctx context.Context, op func() (int, error)) (int, error) {
+	var backoff gmutex.BackOff
+	for {
+		status, err := op()
+		if err != nil || !retriable(status) {
+			return status, err
+		}
+		if err := backoff.Wait(ctx); err != nil {
+			return status, err
+		}
+	}
+}
+
+func retriable(status int) bool {
+	return status == http.StatusTooManyRequests ||
+		status == http.StatusServiceUnavailable
+}
+
+func (s *Store) check(op, key string, status int, err error) error {
+	switch {
+	case err != nil:
+		return fmt.Errorf("gkv: %s %s: %w", op, key, err)
+	case status == http.StatusOK, status == http.StatusNoContent:
+		return nil
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusPreconditionFailed:
+		return ErrConflict
+	default:
+		return fmt.Errorf("gkv: %s %s: http status %d: %s", op, key, status, http.StatusText(status))
+	}
+}
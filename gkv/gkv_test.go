@@ -0,0 +1,159 @@
+package gkv
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ncruces/go-gcp/gmutex"
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+)
+
+func newStore(t *testing.T) *Store {
+	gmutextest.Start(t, "bucket")
+	return New("bucket", "kv/")
+}
+
+func TestStore(t *testing.T) {
+	s := newStore(t)
+	ctx := context.Background()
+
+	if _, _, err := s.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() = %v, want ErrNotFound", err)
+	}
+
+	gen, err := s.PutIf(ctx, "a", []byte("1"), "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PutIf(ctx, "a", []byte("2"), "0"); !errors.Is(err, ErrConflict) {
+		t.Errorf("PutIf() = %v, want ErrConflict", err)
+	}
+	if _, err := s.PutIf(ctx, "a", []byte("2"), gen); err != nil {
+		t.Fatal(err)
+	}
+
+	value, _, err := s.Get(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(value) != "2" {
+		t.Errorf("Get() = %q, want %q", value, "2")
+	}
+
+	if _, err := s.Put(ctx, "b/c", nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := s.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Key != "a" || entries[1].Key != "b/c" {
+		t.Errorf("List() = %v", entries)
+	}
+	entries, err = s.List(ctx, "b/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("List(b/) = %v", entries)
+	}
+
+	if err := s.DeleteIf(ctx, "a", gen); !errors.Is(err, ErrConflict) {
+		t.Errorf("DeleteIf() = %v, want ErrConflict", err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateJSON(t *testing.T) {
+	s := newStore(t)
+	ctx := context.Background()
+
+	type counter struct{ N int }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := UpdateJSON(ctx, s, "counter", func(c *counter) error {
+				c.N++
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var c counter
+	if _, err := s.GetJSON(ctx, "counter", &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.N != 5 {
+		t.Errorf("N = %d, want 5", c.N)
+	}
+}
+
+type throttled struct {
+	http.RoundTripper
+	mtx sync.Mutex
+	n   int
+}
+
+func (t *throttled) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mtx.Lock()
+	t.n++
+	n := t.n
+	t.mtx.Unlock()
+	if n%2 == 1 {
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
+
+func TestStore_throttled(t *testing.T) {
+	s := newStore(t)
+	ctx := context.Background()
+
+	// Every other request is rate limited.
+	// gmutextest.Start restores the client when the test ends.
+	client := *gmutex.HTTPClient
+	client.Transport = &throttled{RoundTripper: client.Transport}
+	gmutex.HTTPClient = &client
+
+	for i := 0; i < 3; i++ {
+		_, err := UpdateJSON(ctx, s, "counter", func(n *int) error {
+			*n++
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var n int
+	if _, err := s.GetJSON(ctx, "counter", &n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("n = %d, want 3", n)
+	}
+	if err := s.Delete(ctx, "counter"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -10,13 +10,16 @@ package glog
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
+	"syscall"
 
 	"cloud.google.com/go/functions/metadata"
 	"go.opencensus.io/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 var std Logger = Logger{callers: 1}
@@ -28,6 +31,27 @@ var ProjectID string = os.Getenv("GOOGLE_CLOUD_PROJECT")
 // source code location information with the entry.
 var LogSourceLocation bool = true
 
+// Sync commits logged entries to stable storage,
+// if stdout and stderr are files.
+// Call it before exiting, so the last entries aren't lost.
+func Sync() error {
+	err1 := sync(os.Stdout)
+	err2 := sync(os.Stderr)
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+func sync(f *os.File) error {
+	err := f.Sync()
+	// Pipes, terminals, and the like, can't be synced.
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.ENOTTY) {
+		return nil
+	}
+	return err
+}
+
 // Print logs an entry with no assigned severity level.
 // Arguments are handled in the manner of fmt.Print.
 func Print(v ...any) {
@@ -305,6 +329,7 @@ type Logger struct {
 	spanID      string
 	executionID string
 	request     *httpRequest
+	labels      map[string]string
 }
 
 // ForRequest creates a Logger with metadata from an http.Request.
@@ -323,21 +348,44 @@ func ForRequest(r *http.Request) (l Logger) {
 }
 
 // ForContext creates a Logger with metadata from a context.Context.
+// If ctx carries a Logger (see NewContext), it starts from that Logger.
 func ForContext(ctx context.Context) (l Logger) {
+	l, _ = ctx.Value(loggerKey{}).(Logger)
 	l.SetContext(ctx)
 	return l
 }
 
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx that carries l,
+// so ForContext can recover it (for example, to log request metadata).
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
 // SetContext updates a Logger with metadata from a context.Context.
 func (l *Logger) SetContext(ctx context.Context) {
 	if span := trace.FromContext(ctx); span != nil {
 		l.trace, l.spanID = fromSpanContext(span.SpanContext())
+	} else if sc := oteltrace.SpanContextFromContext(ctx); sc.IsValid() {
+		l.trace, l.spanID = fromOTelSpanContext(sc)
 	}
 	if meta, _ := metadata.FromContext(ctx); meta != nil {
 		l.executionID = meta.EventID
 	}
 }
 
+// SetLabel sets a label on entries logged by l.
+// Cloud Logging indexes labels, so they can be used to filter entries.
+func (l *Logger) SetLabel(key, value string) {
+	labels := make(map[string]string, len(l.labels)+1)
+	for k, v := range l.labels {
+		labels[k] = v
+	}
+	labels[key] = value
+	l.labels = labels
+}
+
 // Print logs an entry with no assigned severity level.
 // Arguments are handled in the manner of fmt.Print.
 func (l Logger) Print(v ...any) {
@@ -673,7 +721,7 @@ func logs(s severity, l Logger, msg string) {
 		SpanID:         l.spanID,
 		HttpRequest:    l.request,
 		SourceLocation: location(4 + l.callers),
-		Labels:         executionLabels(l.executionID),
+		Labels:         l.entryLabels(),
 	}
 	json.NewEncoder(s.File()).Encode(entry)
 }
@@ -719,8 +767,8 @@ func loge(s severity, l Logger, msg string, entry map[string]json.RawMessage) {
 	if v := l.request; v != nil {
 		entry["httpRequest"], _ = json.Marshal(v)
 	}
-	if v := l.executionID; v != "" {
-		entry["labels"], _ = json.Marshal(executionLabels(l.executionID))
+	if v := l.entryLabels(); v != nil {
+		entry["labels"], _ = json.Marshal(v)
 	}
 	if v := location(4 + l.callers); v != nil {
 		entry["logging.googleapis.com/sourceLocation"], _ = json.Marshal(v)
@@ -735,9 +783,9 @@ type entry struct {
 	Trace    string `json:"logging.googleapis.com/trace,omitempty"`
 	SpanID   string `json:"logging.googleapis.com/spanId,omitempty"`
 
-	HttpRequest    *httpRequest    `json:"httpRequest,omitempty"`
-	SourceLocation *sourceLocation `json:"logging.googleapis.com/sourceLocation,omitempty"`
-	Labels         executionLabels `json:"logging.googleapis.com/labels,omitempty"`
+	HttpRequest    *httpRequest      `json:"httpRequest,omitempty"`
+	SourceLocation *sourceLocation   `json:"logging.googleapis.com/sourceLocation,omitempty"`
+	Labels         map[string]string `json:"logging.googleapis.com/labels,omitempty"`
 }
 
 type httpRequest struct {
@@ -749,14 +797,17 @@ type httpRequest struct {
 	Protocol      string `json:"protocol,omitempty"`
 }
 
-type executionLabels string
-
-func (e executionLabels) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		ExecutionID string `json:"execution_id"`
-	}{
-		string(e),
-	})
+// entryLabels returns the labels of entries logged by l.
+func (l Logger) entryLabels() map[string]string {
+	if l.executionID == "" {
+		return l.labels
+	}
+	labels := make(map[string]string, len(l.labels)+1)
+	for k, v := range l.labels {
+		labels[k] = v
+	}
+	labels["execution_id"] = l.executionID
+	return labels
 }
 
 type sourceLocation struct {
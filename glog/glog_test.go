@@ -1,6 +1,10 @@
 package glog_test
 
-import "github.com/ncruces/go-gcp/glog"
+import (
+	"net/http/httptest"
+
+	"github.com/ncruces/go-gcp/glog"
+)
 
 func init() {
 	glog.LogSourceLocation = false
@@ -32,3 +36,12 @@ func ExampleWarningw() {
 	// Output:
 	// {"component":"app","message":"Warning","severity":"WARNING"}
 }
+
+func ExampleNewContext() {
+	req := httptest.NewRequest("GET", "/", nil)
+	ctx := glog.NewContext(req.Context(), glog.ForRequest(req))
+
+	glog.ForContext(ctx).Info("Request")
+	// Output:
+	// {"message":"Request","severity":"INFO","httpRequest":{"requestMethod":"GET","requestUrl":"/","remoteIp":"192.0.2.1:1234","protocol":"HTTP/1.1"}}
+}
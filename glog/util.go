@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"go.opencensus.io/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 type any = interface{}
@@ -38,6 +39,16 @@ func fromSpanContext(spanContext trace.SpanContext) (trace, spanID string) {
 	return
 }
 
+func fromOTelSpanContext(spanContext oteltrace.SpanContext) (trace, spanID string) {
+	if ProjectID == "" {
+		return
+	}
+
+	trace = fmt.Sprintf("projects/%s/traces/%s", ProjectID, spanContext.TraceID())
+	spanID = spanContext.SpanID().String()
+	return
+}
+
 func parseTraceContext(traceContext string) (trace, spanID string) {
 	if traceContext == "" || ProjectID == "" {
 		return
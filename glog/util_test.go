@@ -1,9 +1,11 @@
 package glog
 
 import (
+	"context"
 	"testing"
 
 	"go.opencensus.io/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 func Test_fromSpanContext(t *testing.T) {
@@ -38,6 +40,58 @@ func Test_fromSpanContext(t *testing.T) {
 	}
 }
 
+func Test_fromOTelSpanContext(t *testing.T) {
+	ProjectID = "my-projectid"
+
+	sc := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID: [16]byte{0x01},
+		SpanID:  [8]byte{0x02},
+	})
+	trace, spanID := fromOTelSpanContext(sc)
+	if want := "projects/my-projectid/traces/01000000000000000000000000000000"; trace != want {
+		t.Errorf("fromOTelSpanContext() trace = %q, want %q", trace, want)
+	}
+	if want := "0200000000000000"; spanID != want {
+		t.Errorf("fromOTelSpanContext() spanID = %q, want %q", spanID, want)
+	}
+}
+
+func TestLogger_SetContext(t *testing.T) {
+	ProjectID = "my-projectid"
+
+	otelContext := oteltrace.ContextWithSpanContext(context.Background(),
+		oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+			TraceID: [16]byte{0x03},
+			SpanID:  [8]byte{0x04},
+		}))
+
+	// Without an OpenCensus span, fall back to the OpenTelemetry span context.
+	var l Logger
+	l.SetContext(otelContext)
+	if want := "projects/my-projectid/traces/03000000000000000000000000000000"; l.trace != want {
+		t.Errorf("SetContext() trace = %q, want %q", l.trace, want)
+	}
+	if want := "0400000000000000"; l.spanID != want {
+		t.Errorf("SetContext() spanID = %q, want %q", l.spanID, want)
+	}
+
+	// An OpenCensus span takes precedence.
+	ctx, span := trace.StartSpan(otelContext, "span", trace.WithSampler(trace.AlwaysSample()))
+	defer span.End()
+	l = Logger{}
+	l.SetContext(ctx)
+	if want := span.SpanContext().SpanID.String(); l.spanID != want {
+		t.Errorf("SetContext() spanID = %q, want %q", l.spanID, want)
+	}
+
+	// An invalid span context is ignored.
+	l = Logger{}
+	l.SetContext(oteltrace.ContextWithSpanContext(context.Background(), oteltrace.SpanContext{}))
+	if l.trace != "" || l.spanID != "" {
+		t.Errorf("SetContext() = %q, %q, want empty", l.trace, l.spanID)
+	}
+}
+
 func Test_parseTraceContext(t *testing.T) {
 	ProjectID = "my-projectid"
 
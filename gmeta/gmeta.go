@@ -0,0 +1,123 @@
+// Package gmeta describes the Google Cloud environment,
+// using environment variables, and the metadata server.
+//
+// Values are fetched from the metadata server once, and cached;
+// tokens are cached until shortly before they expire.
+// Off Google Cloud, functions return ErrNotOnGCP,
+// unless environment variables provide the value.
+package gmeta
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/compute/metadata"
+)
+
+// ErrNotOnGCP is returned when the metadata server is not available.
+var ErrNotOnGCP = errors.New("gmeta: not running on Google Cloud")
+
+// Timeout limits requests to the metadata server.
+var Timeout = 5 * time.Second
+
+// OnGCP reports whether the metadata server is available.
+func OnGCP() bool {
+	return metadata.OnGCE()
+}
+
+var (
+	valuesMtx sync.Mutex
+	values    = map[string]string{}
+)
+
+// get fetches a metadata path, caching successful responses.
+func get(suffix string) (string, error) {
+	valuesMtx.Lock()
+	v, ok := values[suffix]
+	valuesMtx.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	if !OnGCP() {
+		return "", ErrNotOnGCP
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	v, err := metadata.GetWithContext(ctx, suffix)
+	if err != nil {
+		return "", err
+	}
+	v = strings.TrimSpace(v)
+
+	valuesMtx.Lock()
+	values[suffix] = v
+	valuesMtx.Unlock()
+	return v, nil
+}
+
+// ProjectID returns the project ID,
+// from the GOOGLE_CLOUD_PROJECT environment variable,
+// or the metadata server.
+func ProjectID() (string, error) {
+	if id := os.Getenv("GOOGLE_CLOUD_PROJECT"); id != "" {
+		return id, nil
+	}
+	return get("project/project-id")
+}
+
+// NumericProjectID returns the project number.
+func NumericProjectID() (string, error) {
+	return get("project/numeric-project-id")
+}
+
+// Zone returns the zone of the instance, like us-central1-a.
+// On Cloud Run, and Cloud Functions, this is the region,
+// followed by -1.
+func Zone() (string, error) {
+	zone, err := get("instance/zone")
+	if err != nil {
+		return "", err
+	}
+	// projects/PROJECT_NUMBER/zones/ZONE
+	return path.Base(zone), nil
+}
+
+// Region returns the region of the instance, like us-central1,
+// from the CLOUD_RUN_REGION, or FUNCTION_REGION, environment variables,
+// or the metadata server.
+func Region() (string, error) {
+	for _, env := range []string{"CLOUD_RUN_REGION", "FUNCTION_REGION"} {
+		if region := os.Getenv(env); region != "" {
+			return region, nil
+		}
+	}
+	// Serverless platforms provide the region.
+	if region, err := get("instance/region"); err == nil {
+		// projects/PROJECT_NUMBER/regions/REGION
+		return path.Base(region), nil
+	}
+	zone, err := Zone()
+	if err != nil {
+		return "", err
+	}
+	if i := strings.LastIndexByte(zone, '-'); i > 0 {
+		return zone[:i], nil
+	}
+	return zone, nil
+}
+
+// InstanceID returns the ID of the instance.
+func InstanceID() (string, error) {
+	return get("instance/id")
+}
+
+// ServiceAccountEmail returns the email of the default service account.
+func ServiceAccountEmail() (string, error) {
+	return get("instance/service-accounts/default/email")
+}
@@ -0,0 +1,106 @@
+package gmeta
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var requests atomic.Int32
+
+func TestMain(m *testing.M) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/computeMetadata/v1/project/project-id":
+			fmt.Fprint(w, "project")
+		case "/computeMetadata/v1/instance/zone":
+			fmt.Fprint(w, "projects/123/zones/europe-west1-b")
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			fmt.Fprint(w, `{"access_token":"access","expires_in":3600}`)
+		case "/computeMetadata/v1/instance/service-accounts/default/identity":
+			claims := fmt.Sprintf(`{"aud":%q,"exp":%d}`, r.URL.Query().Get("audience"), time.Now().Add(time.Hour).Unix())
+			fmt.Fprint(w, "header."+base64.RawURLEncoding.EncodeToString([]byte(claims))+".signature")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	os.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	os.Unsetenv("GOOGLE_CLOUD_PROJECT")
+	os.Unsetenv("CLOUD_RUN_REGION")
+	os.Unsetenv("FUNCTION_REGION")
+
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
+
+func TestProjectID(t *testing.T) {
+	id, err := ProjectID()
+	if err != nil || id != "project" {
+		t.Errorf("ProjectID() = %q, %v", id, err)
+	}
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "env")
+	id, err = ProjectID()
+	if err != nil || id != "env" {
+		t.Errorf("ProjectID() = %q, %v", id, err)
+	}
+}
+
+func TestRegion(t *testing.T) {
+	zone, err := Zone()
+	if err != nil || zone != "europe-west1-b" {
+		t.Errorf("Zone() = %q, %v", zone, err)
+	}
+	region, err := Region()
+	if err != nil || region != "europe-west1" {
+		t.Errorf("Region() = %q, %v", region, err)
+	}
+}
+
+func TestAccessToken(t *testing.T) {
+	ctx := context.Background()
+	tok, err := AccessToken(ctx)
+	if err != nil || tok != "access" {
+		t.Fatalf("AccessToken() = %q, %v", tok, err)
+	}
+
+	n := requests.Load()
+	if _, err := AccessToken(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != n {
+		t.Error("AccessToken() not cached")
+	}
+}
+
+func TestIdentityToken(t *testing.T) {
+	ctx := context.Background()
+	a, err := IdentityToken(ctx, "https://a.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := IdentityToken(ctx, "https://b.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Error("IdentityToken() cached across audiences")
+	}
+
+	n := requests.Load()
+	if _, err := IdentityToken(ctx, "https://a.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != n {
+		t.Error("IdentityToken() not cached")
+	}
+}
@@ -0,0 +1,114 @@
+package gmeta
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/compute/metadata"
+)
+
+// expiryWindow refreshes tokens this long before they expire.
+const expiryWindow = time.Minute
+
+type token struct {
+	value   string
+	expires time.Time
+}
+
+func (t token) valid() bool {
+	return t.value != "" && time.Until(t.expires) > expiryWindow
+}
+
+var (
+	tokensMtx sync.Mutex
+	tokens    = map[string]token{}
+)
+
+// cachedToken returns the cached token for key,
+// or fetches, and caches, a new one.
+func cachedToken(ctx context.Context, key string, fetch func(ctx context.Context) (token, error)) (string, error) {
+	tokensMtx.Lock()
+	defer tokensMtx.Unlock()
+
+	if t := tokens[key]; t.valid() {
+		return t.value, nil
+	}
+	if !OnGCP() {
+		return "", ErrNotOnGCP
+	}
+	t, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	tokens[key] = t
+	return t.value, nil
+}
+
+// AccessToken returns an OAuth2 access token for the default service account,
+// with the given scopes, or the scopes of the instance if none are given.
+func AccessToken(ctx context.Context, scopes ...string) (string, error) {
+	suffix := "instance/service-accounts/default/token"
+	if len(scopes) > 0 {
+		suffix += "?scopes=" + url.QueryEscape(strings.Join(scopes, ","))
+	}
+	return cachedToken(ctx, suffix, func(ctx context.Context) (token, error) {
+		res, err := metadata.GetWithContext(ctx, suffix)
+		if err != nil {
+			return token{}, err
+		}
+		var t struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}
+		if err := json.Unmarshal([]byte(res), &t); err != nil {
+			return token{}, err
+		}
+		return token{
+			value:   t.AccessToken,
+			expires: time.Now().Add(time.Duration(t.ExpiresIn) * time.Second),
+		}, nil
+	})
+}
+
+// IdentityToken returns a Google-signed OpenID Connect ID token
+// for the default service account, with the given audience.
+func IdentityToken(ctx context.Context, audience string) (string, error) {
+	suffix := "instance/service-accounts/default/identity?format=full&audience=" + url.QueryEscape(audience)
+	return cachedToken(ctx, suffix, func(ctx context.Context) (token, error) {
+		res, err := metadata.GetWithContext(ctx, suffix)
+		if err != nil {
+			return token{}, err
+		}
+		res = strings.TrimSpace(res)
+		exp, err := expiration(res)
+		if err != nil {
+			return token{}, err
+		}
+		return token{value: res, expires: exp}, nil
+	})
+}
+
+// expiration returns the expiration time of a JWT, without verifying it.
+func expiration(jwt string) (time.Time, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("gmeta: malformed token")
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(buf, &claims); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(claims.Exp, 0), nil
+}
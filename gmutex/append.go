@@ -0,0 +1,136 @@
+package gmutex
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// AppendData appends data to the data attached to m,
+// extending the expiration time of m,
+// without rewriting previously attached data,
+// so m can keep an ordered journal of progress checkpoints.
+// Returns an error if the lock has already expired,
+// and mutual exclusion can not be ensured.
+// Requires a Mutex backed by Cloud Storage.
+func (m *Mutex) AppendData(ctx context.Context, data io.Reader) error {
+	o, ok := m.backend.(*gcsObject)
+	if !ok {
+		return errors.New("gmutex: append requires Cloud Storage")
+	}
+	body, err := readData(data)
+	if err != nil {
+		return m.hookExtendFailed(fmt.Errorf("append mutex: %w", err))
+	}
+	return m.hookExtendFailed(m.appendData(ctx, o, body))
+}
+
+func (m *Mutex) appendData(ctx context.Context, o *gcsObject, data []byte) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.generation == "" {
+		return fmt.Errorf("append mutex: %w", ErrUnlocked)
+	}
+	if err := m.pace(ctx); err != nil {
+		return fmt.Errorf("append mutex: %w", err)
+	}
+
+	var backoff linBackOff // Linear backoff because we hold the lock.
+
+	for {
+		// Append to the lock object, at the expected generation.
+		sent := time.Now()
+		status, gen, err := m.appendObject(ctx, backoff.attempt(), o, m.generation, data)
+		if status == http.StatusOK {
+			// Appended.
+			m.renewed(gen, sent)
+			m.hookExtended()
+			return nil
+		}
+
+		if status == http.StatusPreconditionFailed || status == http.StatusNotFound {
+			// The lock object exists at another generation, or no longer exists, it's stale.
+			return m.lost(fmt.Errorf("append mutex: %w, abort", ErrStale))
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("append", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return fmt.Errorf("append mutex: %w", err)
+		}
+		return fmt.Errorf("append mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+func (m *Mutex) appendObject(ctx context.Context, try attempt, o *gcsObject, generation string, data []byte) (status int, gen string, err error) {
+	ctx, span := m.startSpan(ctx, "append", try)
+	defer func() { m.endSpan(ctx, span, "append", try, status, err) }()
+
+	status, gen, err = o.append(ctx, generation, m.attrs(), data)
+	if status == http.StatusOK {
+		m.purgeVersion(ctx, generation)
+	}
+	return status, gen, err
+}
+
+// append uploads data to a temporary object,
+// and composes the lock object with it, if the generation matches.
+func (o *gcsObject) append(ctx context.Context, generation string, attrs Attrs, data []byte) (int, string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+
+	// The temporary object must use the same encryption key.
+	tmp := *o
+	tmp.object = o.object + ".append/" + hex.EncodeToString(id[:])
+	status, tmpGen, err := tmp.Create(ctx, "0", Attrs{}, bytes.NewReader(data))
+	if status != http.StatusOK {
+		return status, "", err
+	}
+	defer func() {
+		// Best effort, even if the context is canceled.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		tmp.Delete(ctx, tmpGen)
+	}()
+
+	var buf bytes.Buffer
+	buf.WriteString("<ComposeRequest><Component><Name>")
+	xml.EscapeText(&buf, []byte(o.object))
+	buf.WriteString("</Name><Generation>" + generation + "</Generation></Component><Component><Name>")
+	xml.EscapeText(&buf, []byte(tmp.object))
+	buf.WriteString("</Name></Component></ComposeRequest>")
+
+	// Compose the lock object if the generation matches.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, o.url()+"?compose", &buf)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Cache-Control", "no-store")
+	req.Header.Set("x-goog-if-generation-match", generation)
+	setMetadata(req.Header, attrs)
+	o.setEncryption(req.Header, true)
+
+	res, err := o.do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	res.Body.Close()
+	return res.StatusCode, res.Header.Get("x-goog-generation"), nil
+}
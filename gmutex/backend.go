@@ -0,0 +1,211 @@
+package gmutex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// A Backend stores the lock object of a Mutex.
+// The Mutex implements retries, backoff, and expiration,
+// on top of the conditional operations of the Backend.
+//
+// Backends report outcomes as HTTP status codes:
+// http.StatusOK on success;
+// http.StatusNotFound if the object (or its container) doesn't exist;
+// http.StatusPreconditionFailed if the object's generation doesn't match.
+// Retriable status codes (like http.StatusTooManyRequests),
+// and temporary errors, are retried with backoff.
+//
+// Generations are opaque strings identifying each write of the object.
+// Writes must change the generation.
+// A generation of "0" means the object must not exist.
+type Backend interface {
+	// Create creates, or replaces, the object with data,
+	// if the object's generation matches.
+	// Returns the new generation.
+	Create(ctx context.Context, generation string, attrs Attrs, data io.Reader) (int, string, error)
+
+	// Extend rewrites the object, keeping its data,
+	// if the object's generation matches.
+	// Returns the new generation.
+	Extend(ctx context.Context, generation string, attrs Attrs) (int, string, error)
+
+	// Delete deletes the object,
+	// if the object's generation matches,
+	// or unconditionally if generation is empty.
+	Delete(ctx context.Context, generation string) (int, error)
+
+	// Inspect gets the object's attributes,
+	// and copies its data to data, if not nil.
+	Inspect(ctx context.Context, data io.Writer) (int, Attrs, error)
+}
+
+// Attrs are the attributes of a lock object.
+type Attrs struct {
+	// TTL is the time-to-live of the object, rounded up to the second.
+	// Zero means the object never expires.
+	TTL time.Duration
+
+	// Holder identifies the process holding the lock.
+	Holder string
+
+	// Metadata are custom key/values attached to the object.
+	Metadata map[string]string
+
+	// Generation identifies the current write of the object.
+	// Set by Inspect.
+	Generation string
+
+	// Modified is the server time when the object was last written.
+	// Set by Inspect.
+	Modified time.Time
+
+	// Date is the server time of the Inspect response,
+	// used to determine expiration independently of the local clock.
+	Date time.Time
+
+	// Expiration is the server time after which the object is expired
+	// regardless of TTL (for example, due to lifecycle rules).
+	// Set by Inspect, if applicable.
+	Expiration time.Time
+}
+
+// NewBackend returns the Cloud Storage Backend
+// for the given bucket and object,
+// so its conditional operations can be used directly,
+// to store other objects than locks.
+func NewBackend(ctx context.Context, bucket, object string) (Backend, error) {
+	if err := initClient(ctx, storageScope); err != nil {
+		return nil, err
+	}
+
+	baseUrl, err := defaultEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcsObject{
+		bucket:  bucket,
+		object:  object,
+		baseUrl: baseUrl,
+	}, nil
+}
+
+// NewWithBackend creates a new Mutex stored in the given Backend,
+// with the given time-to-live.
+func NewWithBackend(b Backend, ttl time.Duration) *Mutex {
+	m := Mutex{backend: b, sem: make(chan struct{}, 1)}
+	m.SetTTL(ttl)
+	m.SetHolder("")
+	return &m
+}
+
+func (a Attrs) expired() bool {
+	// Check for expiration using server date.
+	if a.Date.IsZero() {
+		return false
+	}
+	expires := a.expiration()
+	return !expires.IsZero() && expires.Before(a.Date)
+}
+
+func (m *Mutex) attrs() Attrs {
+	return Attrs{TTL: m.TTL(), Holder: m.holder, Metadata: m.metadata}
+}
+
+func (a Attrs) expiration() time.Time {
+	var expires time.Time
+	if a.TTL > 0 && !a.Modified.IsZero() {
+		expires = a.Modified.Add(a.TTL)
+	}
+	if !a.Expiration.IsZero() && (expires.IsZero() || a.Expiration.Before(expires)) {
+		expires = a.Expiration
+	}
+	return expires
+}
+
+func (m *Mutex) createObject(ctx context.Context, try attempt, generation string, data []byte) (status int, gen string, err error) {
+	ctx, span := m.startSpan(ctx, "create", try)
+	defer func() { m.endSpan(ctx, span, "create", try, status, err) }()
+
+	if generation == "" {
+		generation = "0"
+	}
+	// Each attempt reads the data anew.
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	status, gen, err = m.backend.Create(ctx, generation, m.attrs(), body)
+	if status == http.StatusOK {
+		m.purgeVersion(ctx, generation)
+	}
+	return status, gen, err
+}
+
+func (m *Mutex) extendObject(ctx context.Context, try attempt, generation string) (status int, gen string, err error) {
+	ctx, span := m.startSpan(ctx, "extend", try)
+	defer func() { m.endSpan(ctx, span, "extend", try, status, err) }()
+
+	status, gen, err = m.backend.Extend(ctx, generation, m.attrs())
+	if status == http.StatusOK && gen != generation {
+		m.purgeVersion(ctx, generation)
+	}
+	return status, gen, err
+}
+
+func (m *Mutex) deleteObject(ctx context.Context, try attempt, generation string) (status int, err error) {
+	ctx, span := m.startSpan(ctx, "delete", try)
+	defer func() { m.endSpan(ctx, span, "delete", try, status, err) }()
+
+	status, err = m.backend.Delete(ctx, generation)
+	if status == http.StatusOK || status == http.StatusNoContent {
+		m.purgeVersion(ctx, generation)
+	}
+	return status, err
+}
+
+func (m *Mutex) inspectObject(ctx context.Context, try attempt, data io.Writer) (int, string, error) {
+	status, attrs, err := m.inspectAttrs(ctx, try, data)
+	return status, attrs.Generation, err
+}
+
+func (m *Mutex) inspectAttrs(ctx context.Context, try attempt, data io.Writer) (status int, attrs Attrs, err error) {
+	ctx, span := m.startSpan(ctx, "inspect", try)
+	defer func() { m.endSpan(ctx, span, "inspect", try, status, err) }()
+
+	// Buffer data, so it's only copied if the lock isn't expired.
+	var buf *bytes.Buffer
+	var w io.Writer
+	if data != nil {
+		buf = new(bytes.Buffer)
+		w = buf
+	}
+
+	status, attrs, err = m.backend.Inspect(ctx, w)
+
+	// If it exists, but is expired, act as if it didn't.
+	if status == http.StatusOK && attrs.expired() {
+		m.debugw(ctx, "gmutex: lock expired",
+			"generation", attrs.Generation, "holder", attrs.Holder,
+			"modified", attrs.Modified, "ttl", attrs.TTL.String(), "date", attrs.Date)
+		status = http.StatusNotFound
+	}
+	if status == http.StatusOK && data != nil && err == nil {
+		reset(data)
+		_, err = buf.WriteTo(data)
+	}
+	return status, attrs, err
+}
+
+// String returns a description of the lock object.
+func (m *Mutex) String() string {
+	if s, ok := m.backend.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", m.backend)
+}
@@ -15,14 +15,34 @@ const (
 )
 
 type expBackOff struct {
-	time time.Duration
+	time  time.Duration
+	delay time.Duration
+	waits int
+
+	min, max time.Duration // Zero uses the defaults.
 }
 
 type linBackOff struct {
-	time time.Duration
+	time  time.Duration
+	delay time.Duration
+	waits int
+}
+
+// An attempt is a numbered attempt at a storage operation,
+// made after backing off for delay.
+type attempt struct {
+	n     int
+	delay time.Duration
 }
 
+// attempt returns the next attempt, starting from 1.
+func (b *expBackOff) attempt() attempt { return attempt{b.waits + 1, b.delay} }
+
+// attempt returns the next attempt, starting from 1.
+func (b *linBackOff) attempt() attempt { return attempt{b.waits + 1, b.delay} }
+
 func (b *linBackOff) wait(ctx context.Context) error {
+	b.waits++
 	b.time += backOffMin
 	if b.time < backOffMin {
 		b.time = backOffMin
@@ -30,27 +50,60 @@ func (b *linBackOff) wait(ctx context.Context) error {
 	if b.time > backOffMax {
 		b.time = backOffMax
 	}
-	return wait(ctx, time.Duration(rand.Int63n(int64(b.time))))
+	b.delay = time.Duration(rand.Int63n(int64(b.time)))
+	return wait(ctx, b.delay, nil)
 }
 
 func (b *expBackOff) wait(ctx context.Context) error {
+	return b.notify(ctx, nil)
+}
+
+// notify is like wait, but returns early if woken.
+func (b *expBackOff) notify(ctx context.Context, wake <-chan struct{}) error {
+	min, max := b.min, b.max
+	if min <= 0 {
+		min = backOffMin
+	}
+	if max <= 0 {
+		max = backOffMax
+	}
+
+	b.waits++
 	b.time += b.time / 2
-	if b.time < backOffMin {
-		b.time = backOffMin
+	if b.time < min {
+		b.time = min
 	}
-	if b.time > backOffMax {
-		b.time = backOffMax
+	if b.time > max {
+		b.time = max
 	}
-	return wait(ctx, time.Duration(rand.Int63n(int64(b.time))))
+	b.delay = time.Duration(rand.Int63n(int64(b.time)))
+	return wait(ctx, b.delay, wake)
 }
 
-func wait(ctx context.Context, delay time.Duration) error {
+func wait(ctx context.Context, delay time.Duration, wake <-chan struct{}) error {
 	timer := time.NewTimer(delay)
 	select {
 	case <-timer.C:
 		return nil
+	case <-wake:
+		timer.Stop()
+		return nil
 	case <-ctx.Done():
 		timer.Stop()
 		return ctx.Err()
 	}
 }
+
+// A BackOff waits between attempts at a storage operation,
+// with the exponential backoff (and jitter) of a Mutex waiting for a lock,
+// for packages that use a Backend directly.
+// The zero value is ready to use.
+type BackOff struct {
+	b expBackOff
+}
+
+// Wait waits before the next attempt,
+// returning early if the context expires.
+func (b *BackOff) Wait(ctx context.Context) error {
+	return b.b.wait(ctx)
+}
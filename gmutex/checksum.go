@@ -0,0 +1,50 @@
+package gmutex
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"net/http"
+	"strings"
+)
+
+// A ChecksumError is returned when attached data read from a lock object
+// doesn't match the checksum computed when it was stored.
+type ChecksumError struct {
+	Object string // The lock object.
+	Want   string // The stored CRC32C, base64 encoded.
+	Got    string // The CRC32C of the data read, base64 encoded.
+}
+
+func (e *ChecksumError) Error() string {
+	return "checksum mismatch: " + e.Object + ": crc32c " + e.Got + ", want " + e.Want
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// hashes returns the x-goog-hash header value for data,
+// so Cloud Storage rejects corrupted uploads.
+func hashes(data []byte) string {
+	md5 := md5.Sum(data)
+	return "crc32c=" + crc32c(crc32.Checksum(data, castagnoli)) +
+		",md5=" + base64.StdEncoding.EncodeToString(md5[:])
+}
+
+func crc32c(crc uint32) string {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], crc)
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+// storedCRC32C returns the CRC32C from an x-goog-hash header, if any.
+func storedCRC32C(header http.Header) string {
+	for _, v := range header.Values("x-goog-hash") {
+		for _, h := range strings.Split(v, ",") {
+			if crc, ok := strings.CutPrefix(strings.TrimSpace(h), "crc32c="); ok {
+				return crc
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,22 @@
+package gmutex
+
+import (
+	"context"
+
+	"github.com/ncruces/go-gcp/glog"
+)
+
+// SetDebug sets whether m logs DEBUG severity entries, using glog,
+// for each storage operation (with its attempt number and backoff delay,
+// and its outcome, such as precondition failures),
+// and whenever the lock is found to be expired.
+func (m *Mutex) SetDebug(debug bool) {
+	m.debug = debug
+}
+
+func (m *Mutex) debugw(ctx context.Context, msg string, kvs ...any) {
+	if m.debug {
+		kvs = append([]any{"lock", m.String()}, kvs...)
+		glog.ForContext(ctx).Debugw(msg, kvs...)
+	}
+}
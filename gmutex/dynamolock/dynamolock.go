@@ -0,0 +1,385 @@
+// Package dynamolock implements the interface of the DynamoDB lock client
+// (cirello.io/dynamolock) on top of gmutex.
+//
+// Applications using DynamoDB-based distributed locks can migrate to
+// Cloud Storage-based locks by swapping the client constructor:
+// the table becomes a bucket, and partition keys become lock objects.
+package dynamolock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex"
+)
+
+// Defaults used by New.
+const (
+	DefaultLeaseDuration   = 20 * time.Second
+	DefaultHeartbeatPeriod = 5 * time.Second
+)
+
+// ErrClientClosed is returned by operations on a closed Client.
+var ErrClientClosed = errors.New("dynamolock: client already closed")
+
+// ErrLockAlreadyReleased is returned when releasing,
+// or heartbeating, a lock that was already released.
+var ErrLockAlreadyReleased = errors.New("dynamolock: lock is already released")
+
+// LockNotGrantedError is returned by AcquireLock
+// when the lock is held by another owner.
+type LockNotGrantedError struct {
+	msg string
+}
+
+func (e *LockNotGrantedError) Error() string {
+	return e.msg
+}
+
+// A Client acquires, and releases, locks stored in a Cloud Storage bucket.
+type Client struct {
+	bucket          string
+	ownerName       string
+	leaseDuration   time.Duration
+	heartbeatPeriod time.Duration
+
+	mtx    sync.Mutex
+	locks  map[*Lock]struct{}
+	closed bool
+}
+
+// A ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithLeaseDuration sets how long locks are held without a heartbeat.
+// The duration is rounded up to the nearest second.
+func WithLeaseDuration(d time.Duration) ClientOption {
+	return func(c *Client) { c.leaseDuration = d }
+}
+
+// WithHeartbeatPeriod sets how often locks are extended in the background.
+// Zero disables background heartbeats, see SendHeartbeat.
+func WithHeartbeatPeriod(d time.Duration) ClientOption {
+	return func(c *Client) { c.heartbeatPeriod = d }
+}
+
+// WithOwnerName sets the identity recorded on lock objects.
+// The default is the host name and process id.
+func WithOwnerName(s string) ClientOption {
+	return func(c *Client) { c.ownerName = s }
+}
+
+// New creates a Client that stores locks in the given bucket.
+func New(bucket string, opts ...ClientOption) (*Client, error) {
+	c := &Client{
+		bucket:          bucket,
+		leaseDuration:   DefaultLeaseDuration,
+		heartbeatPeriod: DefaultHeartbeatPeriod,
+		locks:           map[*Lock]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.heartbeatPeriod != 0 && c.heartbeatPeriod*2 >= c.leaseDuration {
+		return nil, errors.New("dynamolock: heartbeat period must be no more than half the lease duration")
+	}
+	return c, nil
+}
+
+func (c *Client) mutex(ctx context.Context, key string) (*gmutex.Mutex, error) {
+	m, err := gmutex.New(ctx, c.bucket, key, c.leaseDuration)
+	if err != nil {
+		return nil, err
+	}
+	if c.ownerName != "" {
+		m.SetHolder(c.ownerName)
+	}
+	return m, nil
+}
+
+// A Lock is a lock held, or inspected, by a Client.
+type Lock struct {
+	client *Client
+	key    string
+	m      *gmutex.Mutex
+	lease  *gmutex.Lease
+	stop   chan struct{}
+
+	mtx  sync.Mutex
+	data []byte
+}
+
+// Key returns the lock object.
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Data returns the data attached to the lock.
+func (l *Lock) Data() []byte {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.data
+}
+
+// OwnerName returns the identity recorded on the lock object.
+func (l *Lock) OwnerName() string {
+	if l.m == nil {
+		return ""
+	}
+	return l.m.Holder()
+}
+
+// IsExpired returns true if the lock is no longer held:
+// it was released, it expired, or it was found to be stale.
+func (l *Lock) IsExpired() bool {
+	if l.lease == nil {
+		return true
+	}
+	select {
+	case <-l.lease.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// Close releases the lock.
+func (l *Lock) Close() error {
+	_, err := l.client.ReleaseLock(l)
+	return err
+}
+
+type acquireOptions struct {
+	data           []byte
+	failIfLocked   bool
+	additionalWait time.Duration
+}
+
+// An AcquireLockOption configures AcquireLock.
+type AcquireLockOption func(*acquireOptions)
+
+// WithData attaches data to the lock.
+func WithData(data []byte) AcquireLockOption {
+	return func(o *acquireOptions) { o.data = data }
+}
+
+// FailIfLocked makes AcquireLock fail immediately if the lock is held.
+func FailIfLocked() AcquireLockOption {
+	return func(o *acquireOptions) { o.failIfLocked = true }
+}
+
+// WithAdditionalTimeToWaitForLock extends how long AcquireLock waits
+// for the lock to be available, beyond the lease duration.
+func WithAdditionalTimeToWaitForLock(d time.Duration) AcquireLockOption {
+	return func(o *acquireOptions) { o.additionalWait = d }
+}
+
+// AcquireLock acquires the lock for key,
+// waiting at most the lease duration (plus any additional time)
+// for it to be available.
+func (c *Client) AcquireLock(key string, opts ...AcquireLockOption) (*Lock, error) {
+	return c.AcquireLockWithContext(context.Background(), key, opts...)
+}
+
+// AcquireLockWithContext acquires the lock for key, like AcquireLock.
+func (c *Client) AcquireLockWithContext(ctx context.Context, key string, opts ...AcquireLockOption) (*Lock, error) {
+	if c.isClosed() {
+		return nil, ErrClientClosed
+	}
+
+	var o acquireOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	m, err := c.mutex(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.failIfLocked {
+		locked, err := m.TryLockData(ctx, bytes.NewReader(o.data))
+		if err != nil {
+			return nil, err
+		}
+		if !locked {
+			return nil, &LockNotGrantedError{"dynamolock: " + key + " is held"}
+		}
+	} else {
+		err := m.LockData(ctx, bytes.NewReader(o.data), gmutex.WithMaxWait(c.leaseDuration+o.additionalWait))
+		var timeout *gmutex.TimeoutError
+		if errors.As(err, &timeout) {
+			return nil, &LockNotGrantedError{"dynamolock: " + key + " is held, timed out waiting"}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l := &Lock{
+		client: c,
+		key:    key,
+		m:      m,
+		lease:  m.Lease(),
+		stop:   make(chan struct{}),
+		data:   o.data,
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.closed {
+		m.Unlock(ctx)
+		return nil, ErrClientClosed
+	}
+	c.locks[l] = struct{}{}
+	if c.heartbeatPeriod != 0 {
+		go c.heartbeat(l)
+	}
+	return l, nil
+}
+
+// heartbeat extends l in the background, until it's released or lost.
+func (c *Client) heartbeat(l *Lock) {
+	ticker := time.NewTicker(c.heartbeatPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-l.lease.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), c.heartbeatPeriod)
+			l.m.Extend(ctx)
+			cancel()
+		}
+	}
+}
+
+type heartbeatOptions struct {
+	data []byte
+}
+
+// A SendHeartbeatOption configures SendHeartbeat.
+type SendHeartbeatOption func(*heartbeatOptions)
+
+// WithDataAfterHeartbeat replaces the data attached to the lock.
+func WithDataAfterHeartbeat(data []byte) SendHeartbeatOption {
+	return func(o *heartbeatOptions) { o.data = data }
+}
+
+// SendHeartbeat extends the lock, optionally replacing its data.
+func (c *Client) SendHeartbeat(l *Lock, opts ...SendHeartbeatOption) error {
+	return c.SendHeartbeatWithContext(context.Background(), l, opts...)
+}
+
+// SendHeartbeatWithContext extends the lock, like SendHeartbeat.
+func (c *Client) SendHeartbeatWithContext(ctx context.Context, l *Lock, opts ...SendHeartbeatOption) error {
+	if c.isClosed() {
+		return ErrClientClosed
+	}
+	if l.IsExpired() {
+		return ErrLockAlreadyReleased
+	}
+
+	var o heartbeatOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.data == nil {
+		return l.m.Extend(ctx)
+	}
+	if err := l.m.UpdateData(ctx, bytes.NewReader(o.data)); err != nil {
+		return err
+	}
+	l.mtx.Lock()
+	l.data = o.data
+	l.mtx.Unlock()
+	return nil
+}
+
+// ReleaseLock releases the lock,
+// returning false if it was no longer held.
+func (c *Client) ReleaseLock(l *Lock) (bool, error) {
+	return c.ReleaseLockWithContext(context.Background(), l)
+}
+
+// ReleaseLockWithContext releases the lock, like ReleaseLock.
+func (c *Client) ReleaseLockWithContext(ctx context.Context, l *Lock) (bool, error) {
+	if l == nil || l.m == nil {
+		return false, ErrLockAlreadyReleased
+	}
+
+	c.mtx.Lock()
+	_, ok := c.locks[l]
+	delete(c.locks, l)
+	c.mtx.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	close(l.stop)
+	return l.m.UnlockIfHeld(ctx)
+}
+
+// Get inspects the lock for key, without acquiring it.
+// The returned Lock carries the attached data, and is always expired.
+func (c *Client) Get(key string) (*Lock, error) {
+	return c.GetWithContext(context.Background(), key)
+}
+
+// GetWithContext inspects the lock for key, like Get.
+func (c *Client) GetWithContext(ctx context.Context, key string) (*Lock, error) {
+	if c.isClosed() {
+		return nil, ErrClientClosed
+	}
+
+	m, err := c.mutex(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.InspectData(ctx, &buf); err != nil {
+		return nil, err
+	}
+	return &Lock{client: c, key: key, data: buf.Bytes()}, nil
+}
+
+// Close releases all locks held by c.
+// A closed Client can't be used to acquire locks.
+func (c *Client) Close() error {
+	return c.CloseWithContext(context.Background())
+}
+
+// CloseWithContext releases all locks held by c, like Close.
+func (c *Client) CloseWithContext(ctx context.Context) error {
+	c.mtx.Lock()
+	if c.closed {
+		c.mtx.Unlock()
+		return ErrClientClosed
+	}
+	c.closed = true
+	locks := c.locks
+	c.locks = nil
+	c.mtx.Unlock()
+
+	var errs []error
+	for l := range locks {
+		close(l.stop)
+		if _, err := l.m.UnlockIfHeld(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Client) isClosed() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.closed
+}
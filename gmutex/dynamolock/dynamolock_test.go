@@ -0,0 +1,70 @@
+package dynamolock
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+)
+
+func TestClient(t *testing.T) {
+	gmutextest.Start(t, "bucket")
+
+	a, err := New("bucket", WithOwnerName("a"), WithLeaseDuration(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New("bucket", WithOwnerName("b"), WithLeaseDuration(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := a.AcquireLock("key", WithData([]byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.IsExpired() || lock.OwnerName() != "a" {
+		t.Errorf("got expired=%v, owner=%q", lock.IsExpired(), lock.OwnerName())
+	}
+
+	var notGranted *LockNotGrantedError
+	if _, err := b.AcquireLock("key", FailIfLocked()); !errors.As(err, &notGranted) {
+		t.Errorf("AcquireLock() = %v, want LockNotGrantedError", err)
+	}
+
+	if err := a.SendHeartbeat(lock, WithDataAfterHeartbeat([]byte("more"))); err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Get("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Data()) != "more" {
+		t.Errorf("Get().Data() = %q, want %q", got.Data(), "more")
+	}
+
+	if ok, err := a.ReleaseLock(lock); err != nil || !ok {
+		t.Fatalf("ReleaseLock() = %v, %v, want true", ok, err)
+	}
+	if !lock.IsExpired() {
+		t.Error("released lock not expired")
+	}
+	if ok, err := a.ReleaseLock(lock); err != nil || ok {
+		t.Errorf("ReleaseLock() = %v, %v, want false", ok, err)
+	}
+
+	lock, err = b.AcquireLock("key", FailIfLocked())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !lock.IsExpired() {
+		t.Error("lock not released on Close")
+	}
+	if _, err := b.AcquireLock("key"); err != ErrClientClosed {
+		t.Errorf("AcquireLock() = %v, want ErrClientClosed", err)
+	}
+}
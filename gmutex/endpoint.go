@@ -0,0 +1,54 @@
+package gmutex
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// SetEndpoint sets the Cloud Storage endpoint used to access the lock object,
+// such as a regional endpoint (storage.us-east1.rep.googleapis.com),
+// or a Private Service Connect endpoint,
+// for VPC Service Controls environments where the default host is blocked.
+// The endpoint is a host name, or a URL;
+// host names use HTTPS.
+// An empty endpoint reverts to the default
+// (or to STORAGE_EMULATOR_HOST, if set).
+// Requires a Mutex backed by Cloud Storage.
+func (m *Mutex) SetEndpoint(endpoint string) error {
+	o, ok := m.backend.(*gcsObject)
+	if !ok {
+		return errors.New("gmutex: endpoints require Cloud Storage")
+	}
+
+	baseUrl, err := parseEndpoint(endpoint)
+	if err != nil {
+		return err
+	}
+	o.baseUrl = baseUrl
+	return nil
+}
+
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	switch {
+	case endpoint == "":
+		return defaultEndpoint()
+	case strings.Contains(endpoint, "://"):
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		if u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return nil, errors.New("gmutex: invalid endpoint: " + endpoint)
+		}
+		return &url.URL{Scheme: u.Scheme, Host: u.Host}, nil
+	case strings.ContainsAny(endpoint, "/?#"):
+		return nil, errors.New("gmutex: invalid endpoint: " + endpoint)
+	default:
+		return &url.URL{Scheme: "https", Host: endpoint}, nil
+	}
+}
+
+func defaultEndpoint() (*url.URL, error) {
+	return endpoint("STORAGE_EMULATOR_HOST", "storage.googleapis.com")
+}
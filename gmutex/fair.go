@@ -0,0 +1,266 @@
+package gmutex
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Tickets are refreshed at least this often,
+// and expire if not refreshed for this long.
+const (
+	ticketRefresh = backOffMax
+	ticketTTL     = 3 * backOffMax
+)
+
+// SetFair sets whether m waits in line to acquire the lock.
+//
+// In fair mode, Lock enqueues a ticket object
+// (named after the lock object, with a ".queue/" suffix),
+// and only the waiter holding the oldest ticket
+// tries to acquire the lock once it's released,
+// so heavily contended locks don't starve unlucky waiters.
+// TryLock fails while there are waiters in line.
+// All processes contending for the lock should use fair mode.
+//
+// Tickets of crashed waiters expire after a couple of minutes.
+// Requires a Mutex backed by Cloud Storage.
+func (m *Mutex) SetFair(fair bool) error {
+	if _, ok := m.backend.(*gcsObject); !ok && fair {
+		return errors.New("gmutex: fair mode requires Cloud Storage")
+	}
+	m.fair = fair
+	return nil
+}
+
+// A ticket is a place in line to acquire a lock object.
+type ticket struct {
+	object     gcsObject
+	queue      queue
+	generation string
+	refreshed  time.Time
+}
+
+// A queue is the line of tickets for a lock object.
+type queue struct {
+	object gcsObject // The lock object.
+	prefix string
+}
+
+// lockFair uses exponential backoff because we don't hold the lock.
+func (m *Mutex) lockFair(ctx context.Context, data []byte, backoff expBackOff) error {
+	start := m.hookLockStart()
+	contended := false // Whether the lock was found in use.
+
+	// Don't wait so long that the ticket expires.
+	if backoff.max > ticketRefresh {
+		backoff.max = ticketRefresh
+	}
+
+	// Subscribe before inspecting, so no release notifications are missed.
+	wake := m.notifier.subscribe(m.backend)
+	defer m.notifier.unsubscribe(m.backend, wake)
+
+	t := m.newTicket()
+	defer t.dequeue(ctx)
+
+	for {
+		// Enqueue (or refresh) the ticket, then check whether it's first in line.
+		status, first, err := t.refresh(ctx, m.holder)
+		if status == http.StatusOK && first {
+			// Inspect the lock object, and if it doesn't exist, or has expired, acquire it.
+			var gen string
+			status, gen, err = m.inspectObject(ctx, backoff.attempt(), nil)
+			if status == http.StatusNotFound {
+				sent := time.Now()
+				status, gen, err = m.createObject(ctx, backoff.attempt(), gen, data)
+				if status == http.StatusOK {
+					// Acquired.
+					m.acquired(gen, sent)
+					m.hookLocked(start)
+					return nil
+				}
+				if status == http.StatusNotFound {
+					return fmt.Errorf("lock mutex: %w", ErrBucketNotFound)
+				}
+				if status == http.StatusPreconditionFailed {
+					// The lock object was recreated at another generation, inspect it.
+					continue
+				}
+			}
+		}
+
+		// While waiting in line, and for transient errors, backoff and retry.
+		// Wake early if notified that the lock object was released.
+		if status == http.StatusOK || retriable(status, err) {
+			if status != http.StatusOK {
+				m.hookRetry("lock", status, err)
+			} else if !contended {
+				contended = true
+				m.hookContended()
+			}
+			if err := backoff.notify(ctx, wake); err != nil {
+				return err
+			}
+			continue
+		}
+		if status == http.StatusNotFound {
+			return fmt.Errorf("lock mutex: %w", ErrBucketNotFound)
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return fmt.Errorf("lock mutex: %w", err)
+		}
+		return fmt.Errorf("lock mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+// queued checks whether there are waiters in line for the lock object.
+func (m *Mutex) queued(ctx context.Context) (int, bool, error) {
+	status, list, err := m.queue().list(ctx)
+	return status, len(list) > 0, err
+}
+
+func (m *Mutex) queue() queue {
+	o := m.backend.(*gcsObject)
+	return queue{
+		object: *o,
+		prefix: o.object + ".queue/",
+	}
+}
+
+func (m *Mutex) newTicket() *ticket {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+
+	q := m.queue()
+	return &ticket{
+		queue:  q,
+		object: q.ticket(q.prefix + hex.EncodeToString(id[:])),
+	}
+}
+
+// refresh enqueues the ticket, or keeps it from expiring,
+// and reports whether it's first in line.
+func (t *ticket) refresh(ctx context.Context, holder string) (int, bool, error) {
+	if t.generation == "" {
+		// Create the ticket object, which must not exist.
+		status, gen, err := t.object.Create(ctx, "0", Attrs{TTL: ticketTTL, Holder: holder}, nil)
+		if status != http.StatusOK {
+			return status, false, err
+		}
+		t.generation = gen
+		t.refreshed = time.Now()
+	} else if time.Since(t.refreshed) > ticketRefresh {
+		// Update the ticket's metadata, keeping its generation (and place in line).
+		status, err := t.patch(ctx)
+		if status == http.StatusPreconditionFailed || status == http.StatusNotFound {
+			// The ticket expired, and was removed, get a new one.
+			t.generation = ""
+			return t.refresh(ctx, holder)
+		}
+		if status != http.StatusOK {
+			return status, false, err
+		}
+		t.refreshed = time.Now()
+	}
+
+	status, list, err := t.queue.list(ctx)
+	if status != http.StatusOK {
+		return status, false, err
+	}
+	for _, info := range list {
+		if info.Object == t.object.object {
+			return status, list[0].Generation == t.generation, nil
+		}
+	}
+	// Our ticket expired, and was removed, get a new one.
+	t.generation = ""
+	return t.refresh(ctx, holder)
+}
+
+// list lists the live tickets, first in line first,
+// removing expired tickets along the way.
+func (q queue) list(ctx context.Context) (int, []Info, error) {
+	var live []Info
+	var token string
+	for {
+		status, list, next, err := q.object.listObjects(ctx, q.prefix, token)
+		if status != http.StatusOK {
+			return status, nil, err
+		}
+
+		for _, info := range list {
+			if info.Held {
+				live = append(live, info)
+			} else {
+				// Best effort.
+				expired := q.ticket(info.Object)
+				expired.Delete(ctx, info.Generation)
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	// Order by generation.
+	var first int
+	for i := range live {
+		if generationLess(live[i].Generation, live[first].Generation) {
+			first = i
+		}
+	}
+	if len(live) > 0 {
+		live[0], live[first] = live[first], live[0]
+	}
+	return http.StatusOK, live, nil
+}
+
+// ticket returns the ticket object with the given name.
+func (q queue) ticket(name string) gcsObject {
+	return gcsObject{
+		bucket:      q.object.bucket,
+		object:      name,
+		baseUrl:     q.object.baseUrl,
+		client:      q.object.client,
+		userProject: q.object.userProject,
+	}
+}
+
+// dequeue removes the ticket from the line.
+func (t *ticket) dequeue(ctx context.Context) {
+	if t.generation == "" {
+		return
+	}
+
+	// Best effort, even if the context is canceled.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	t.object.Delete(ctx, t.generation)
+	t.generation = ""
+}
+
+func (t *ticket) patch(ctx context.Context) (int, error) {
+	status, _, err := t.object.updateMetadata(ctx, t.generation, map[string]string{
+		"ttl":       strconv.FormatInt(int64(ticketTTL/time.Second), 10),
+		"refreshed": time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	return status, err
+}
+
+func generationLess(a, b string) bool {
+	x, _ := strconv.ParseInt(a, 10, 64)
+	y, _ := strconv.ParseInt(b, 10, 64)
+	return x < y
+}
@@ -0,0 +1,265 @@
+package gmutex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewFirestore creates a new Mutex at the given Firestore document,
+// with the given time-to-live.
+//
+// The database is a resource name (projects/{project}/databases/{database}),
+// and the document a path relative to it (such as locks/{name}).
+//
+// Compared to Cloud Storage, Firestore offers lower latency,
+// and is not limited to one update per second per lock.
+// A TTL policy on the expireTime field of the collection
+// can be configured to eventually delete expired locks.
+//
+// To use the Firestore emulator, provide the endpoint
+// by setting the environment variable FIRESTORE_EMULATOR_HOST
+// prior to creating the Mutex.
+func NewFirestore(ctx context.Context, database, document string, ttl time.Duration) (*Mutex, error) {
+	if err := initClient(ctx, datastoreScope); err != nil {
+		return nil, err
+	}
+
+	baseUrl, err := endpoint("FIRESTORE_EMULATOR_HOST", "firestore.googleapis.com")
+	if err != nil {
+		return nil, err
+	}
+
+	database = strings.Trim(database, "/")
+	return NewWithBackend(&firestoreDoc{
+		database: database,
+		name:     database + "/documents/" + strings.Trim(document, "/"),
+		baseUrl:  baseUrl,
+	}, ttl), nil
+}
+
+// firestoreDoc is a lock document in Firestore,
+// accessed through the REST API.
+// The document's update time is used as its generation.
+type firestoreDoc struct {
+	database string
+	name     string
+	baseUrl  *url.URL
+}
+
+// A TTL policy may delete expired documents immediately;
+// this margin guards against client clock skew.
+const firestoreTTLMargin = time.Hour
+
+func (d *firestoreDoc) String() string {
+	return d.name
+}
+
+func (d *firestoreDoc) Create(ctx context.Context, generation string, attrs Attrs, data io.Reader) (int, string, error) {
+	fields := firestoreFields(attrs)
+	if data != nil {
+		b, err := io.ReadAll(data)
+		if err != nil {
+			return 0, "", err
+		}
+		fields["data"] = map[string][]byte{"bytesValue": b}
+	}
+
+	// Create/update the lock document if the update time matches.
+	// If the document is expected not to exist, not found means the database doesn't.
+	var missing int
+	var precondition map[string]any
+	if generation == "0" {
+		missing = http.StatusNotFound
+		precondition = map[string]any{"exists": false}
+	} else {
+		missing = http.StatusPreconditionFailed
+		precondition = map[string]any{"updateTime": generation}
+	}
+
+	return d.commit(ctx, missing, map[string]any{
+		"update": map[string]any{
+			"name":   d.name,
+			"fields": fields,
+		},
+		"currentDocument": precondition,
+	})
+}
+
+func (d *firestoreDoc) Extend(ctx context.Context, generation string, attrs Attrs) (int, string, error) {
+	// Extend the lock document if the update time matches, keeping data.
+	return d.commit(ctx, http.StatusPreconditionFailed, map[string]any{
+		"update": map[string]any{
+			"name":   d.name,
+			"fields": firestoreFields(attrs),
+		},
+		"updateMask": map[string]any{
+			"fieldPaths": []string{"ttl", "holder", "metadata", "expireTime"},
+		},
+		"currentDocument": map[string]any{"updateTime": generation},
+	})
+}
+
+func (d *firestoreDoc) Delete(ctx context.Context, generation string) (int, error) {
+	// Delete the lock document if the update time matches.
+	write := map[string]any{"delete": d.name}
+	if generation != "" {
+		write["currentDocument"] = map[string]any{"updateTime": generation}
+	}
+	status, _, err := d.commit(ctx, http.StatusNotFound, write)
+	return status, err
+}
+
+func (d *firestoreDoc) Inspect(ctx context.Context, data io.Writer) (int, Attrs, error) {
+	// Get the lock document's status.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url(""), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	res, err := httpClient(datastoreScope).Do(req)
+	if err != nil {
+		return 0, Attrs{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return res.StatusCode, Attrs{}, nil
+	}
+
+	var doc struct {
+		UpdateTime string `json:"updateTime"`
+		Fields     struct {
+			TTL struct {
+				IntegerValue string `json:"integerValue"`
+			} `json:"ttl"`
+			Holder struct {
+				StringValue string `json:"stringValue"`
+			} `json:"holder"`
+			Metadata struct {
+				MapValue struct {
+					Fields map[string]struct {
+						StringValue string `json:"stringValue"`
+					} `json:"fields"`
+				} `json:"mapValue"`
+			} `json:"metadata"`
+			Data struct {
+				BytesValue []byte `json:"bytesValue"`
+			} `json:"data"`
+		} `json:"fields"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return 0, Attrs{}, err
+	}
+
+	var attrs Attrs
+	attrs.Generation = doc.UpdateTime
+	attrs.Holder = doc.Fields.Holder.StringValue
+	for k, v := range doc.Fields.Metadata.MapValue.Fields {
+		if attrs.Metadata == nil {
+			attrs.Metadata = map[string]string{}
+		}
+		attrs.Metadata[k] = v.StringValue
+	}
+	attrs.Date, _ = http.ParseTime(res.Header.Get("Date"))
+	attrs.Modified, _ = time.Parse(time.RFC3339Nano, doc.UpdateTime)
+	if ttl, err := strconv.ParseInt(doc.Fields.TTL.IntegerValue, 10, 64); err == nil && ttl > 0 {
+		attrs.TTL = time.Duration(ttl) * time.Second
+	}
+	if data != nil {
+		_, err = data.Write(doc.Fields.Data.BytesValue)
+	}
+	return res.StatusCode, attrs, err
+}
+
+func (d *firestoreDoc) commit(ctx context.Context, missing int, write map[string]any) (int, string, error) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(map[string]any{"writes": []any{write}})
+	if err != nil {
+		return 0, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url(":commit"), &buf)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient(datastoreScope).Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		// Map gRPC status codes to their closest Cloud Storage equivalents.
+		var body struct {
+			Error struct {
+				Status string `json:"status"`
+			} `json:"error"`
+		}
+		json.NewDecoder(res.Body).Decode(&body)
+		switch body.Error.Status {
+		case "ALREADY_EXISTS", "FAILED_PRECONDITION":
+			return http.StatusPreconditionFailed, "", nil
+		case "NOT_FOUND":
+			return missing, "", nil
+		case "ABORTED":
+			return http.StatusServiceUnavailable, "", nil
+		}
+		return res.StatusCode, "", nil
+	}
+
+	var body struct {
+		WriteResults []struct {
+			UpdateTime string `json:"updateTime"`
+		} `json:"writeResults"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return 0, "", err
+	}
+	var gen string
+	if len(body.WriteResults) > 0 {
+		gen = body.WriteResults[0].UpdateTime
+	}
+	return res.StatusCode, gen, nil
+}
+
+func (d *firestoreDoc) url(method string) string {
+	path := d.name
+	if method != "" {
+		path = d.database + "/documents" + method
+	}
+	url := url.URL{
+		Scheme: d.baseUrl.Scheme,
+		Host:   d.baseUrl.Host,
+		Path:   "/v1/" + path,
+	}
+	return url.String()
+}
+
+func firestoreFields(attrs Attrs) map[string]any {
+	fields := map[string]any{
+		"ttl": map[string]string{"integerValue": strconv.FormatInt(int64(attrs.TTL/time.Second), 10)},
+	}
+	if attrs.Holder != "" {
+		fields["holder"] = map[string]string{"stringValue": attrs.Holder}
+	}
+	if len(attrs.Metadata) > 0 {
+		metadata := map[string]any{}
+		for k, v := range attrs.Metadata {
+			metadata[k] = map[string]string{"stringValue": v}
+		}
+		fields["metadata"] = map[string]any{"mapValue": map[string]any{"fields": metadata}}
+	}
+	if attrs.TTL > 0 {
+		expires := time.Now().Add(attrs.TTL + firestoreTTLMargin)
+		fields["expireTime"] = map[string]string{"timestampValue": expires.UTC().Format(time.RFC3339Nano)}
+	}
+	return fields
+}
@@ -0,0 +1,228 @@
+package gmutex_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex"
+)
+
+// firestoreServer is a fake Firestore REST API,
+// implementing the document reads and commits used by gmutex.
+type firestoreServer struct {
+	*httptest.Server
+	mtx  sync.Mutex
+	docs map[string]firestoreDocument
+	last time.Time
+	skew time.Duration
+}
+
+type firestoreDocument struct {
+	Name       string                     `json:"name"`
+	Fields     map[string]json.RawMessage `json:"fields"`
+	UpdateTime string                     `json:"updateTime"`
+}
+
+// startFirestore starts a fake Firestore server,
+// and points FIRESTORE_EMULATOR_HOST to it.
+func startFirestore(t *testing.T) *firestoreServer {
+	s := &firestoreServer{docs: map[string]firestoreDocument{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	t.Setenv("FIRESTORE_EMULATOR_HOST", s.URL)
+	return s
+}
+
+// advance moves the server clock forward.
+func (s *firestoreServer) advance(d time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.skew += d
+}
+
+func (s *firestoreServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	w.Header().Set("Date", time.Now().Add(s.skew).UTC().Format(http.TimeFormat))
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case !strings.HasPrefix(path, firestoreDatabase+"/documents"):
+		firestoreError(w, http.StatusNotFound, "NOT_FOUND")
+
+	case r.Method == http.MethodGet:
+		doc, ok := s.docs[path]
+		if !ok {
+			firestoreError(w, http.StatusNotFound, "NOT_FOUND")
+			return
+		}
+		json.NewEncoder(w).Encode(doc)
+
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/documents:commit"):
+		var req struct {
+			Writes []struct {
+				Update     *firestoreDocument `json:"update"`
+				Delete     string             `json:"delete"`
+				UpdateMask *struct {
+					FieldPaths []string `json:"fieldPaths"`
+				} `json:"updateMask"`
+				CurrentDocument *struct {
+					Exists     *bool  `json:"exists"`
+					UpdateTime string `json:"updateTime"`
+				} `json:"currentDocument"`
+			} `json:"writes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Writes) != 1 {
+			firestoreError(w, http.StatusBadRequest, "INVALID_ARGUMENT")
+			return
+		}
+
+		write := req.Writes[0]
+		name := write.Delete
+		if write.Update != nil {
+			name = write.Update.Name
+		}
+		doc, exists := s.docs[name]
+		if pre := write.CurrentDocument; pre != nil {
+			switch {
+			case pre.Exists != nil && *pre.Exists && !exists,
+				pre.UpdateTime != "" && !exists:
+				firestoreError(w, http.StatusNotFound, "NOT_FOUND")
+				return
+			case pre.Exists != nil && !*pre.Exists && exists:
+				firestoreError(w, http.StatusConflict, "ALREADY_EXISTS")
+				return
+			case pre.UpdateTime != "" && pre.UpdateTime != doc.UpdateTime:
+				firestoreError(w, http.StatusBadRequest, "FAILED_PRECONDITION")
+				return
+			}
+		}
+
+		// Update times must be unique, they're used as generations.
+		now := time.Now().Add(s.skew)
+		if !now.After(s.last) {
+			now = s.last.Add(time.Microsecond)
+		}
+		s.last = now
+		updateTime := now.UTC().Format(time.RFC3339Nano)
+
+		if write.Update == nil {
+			delete(s.docs, name)
+		} else {
+			fields := write.Update.Fields
+			if mask := write.UpdateMask; mask != nil {
+				fields = map[string]json.RawMessage{}
+				for k, v := range doc.Fields {
+					fields[k] = v
+				}
+				for _, k := range mask.FieldPaths {
+					if v, ok := write.Update.Fields[k]; ok {
+						fields[k] = v
+					} else {
+						delete(fields, k)
+					}
+				}
+			}
+			s.docs[name] = firestoreDocument{Name: name, Fields: fields, UpdateTime: updateTime}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"writeResults": []any{map[string]string{"updateTime": updateTime}},
+		})
+
+	default:
+		firestoreError(w, http.StatusNotFound, "NOT_FOUND")
+	}
+}
+
+func firestoreError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"code": status, "status": code},
+	})
+}
+
+const firestoreDatabase = "projects/project/databases/(default)"
+
+func TestNewFirestore(t *testing.T) {
+	server := startFirestore(t)
+	ctx := context.Background()
+
+	mtx, err := gmutex.NewFirestore(ctx, firestoreDatabase, "locks/lock", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := gmutex.NewFirestore(ctx, firestoreDatabase, "/locks/lock/", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mtx.String(); got != firestoreDatabase+"/documents/locks/lock" {
+		t.Errorf("String() = %q", got)
+	}
+
+	if err := mtx.SetMetadata(map[string]string{"key": "value"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.LockData(ctx, strings.NewReader("data")); err != nil {
+		t.Fatal(err)
+	}
+	if locked, err := other.TryLock(ctx); err != nil || locked {
+		t.Fatalf("TryLock() = %v, %v, want false", locked, err)
+	}
+
+	// Extend keeps the data.
+	if err := mtx.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if held, err := other.InspectData(ctx, &buf); err != nil || !held {
+		t.Fatalf("InspectData() = %v, %v, want true", held, err)
+	}
+	if buf.String() != "data" {
+		t.Errorf("InspectData() = %q, want %q", buf.String(), "data")
+	}
+	if _, metadata, err := other.InspectMetadata(ctx); err != nil || metadata["key"] != "value" {
+		t.Errorf("InspectMetadata() = %v, %v", metadata, err)
+	}
+
+	if err := mtx.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if locked, err := other.TryLock(ctx); err != nil || !locked {
+		t.Fatalf("TryLock() = %v, %v, want true", locked, err)
+	}
+
+	// Once expired, the lock can be stolen, and the holder can't extend it.
+	server.advance(2 * time.Minute)
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Extend(ctx); err == nil {
+		t.Error("Extend() of a stolen lock succeeded")
+	}
+	if err := mtx.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewFirestore_missing(t *testing.T) {
+	startFirestore(t)
+	ctx := context.Background()
+
+	mtx, err := gmutex.NewFirestore(ctx, "projects/project/databases/missing", "locks/lock", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Commits to a missing database fail as not found.
+	if _, err := mtx.TryLock(ctx); !errors.Is(err, gmutex.ErrBucketNotFound) {
+		t.Errorf("TryLock() = %v, want ErrBucketNotFound", err)
+	}
+}
@@ -0,0 +1,166 @@
+package gmutex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ForceUnlock unlocks m regardless of who holds the lock,
+// deleting any attached data.
+// The previous holder will get ErrStale errors
+// when it tries to extend, update or unlock it.
+//
+// ForceUnlock is meant for operators cleaning up after crashed holders
+// of locks that never expire.
+func (m *Mutex) ForceUnlock(ctx context.Context) error {
+	var backoff expBackOff // Exponential backoff because we don't hold the lock.
+
+	for {
+		// Delete the lock object, at any generation.
+		status, err := m.deleteObject(ctx, backoff.attempt(), "")
+		if status == http.StatusOK || status == http.StatusNoContent || status == http.StatusNotFound {
+			return nil
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("unlock", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return fmt.Errorf("unlock mutex: %w", err)
+		}
+		return fmt.Errorf("unlock mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+// ForceUnlockOlder unlocks m regardless of who holds the lock,
+// if the lock object was last written (locked, extended, or updated)
+// more than age ago, according to server time.
+// Returns true if the lock was forcibly unlocked,
+// false if it wasn't held, or was written more recently.
+func (m *Mutex) ForceUnlockOlder(ctx context.Context, age time.Duration) (bool, error) {
+	var backoff expBackOff // Exponential backoff because we don't hold the lock.
+
+	for {
+		// Inspect the lock object.
+		status, attrs, err := m.inspectAttrs(ctx, backoff.attempt(), nil)
+		if status == http.StatusNotFound {
+			return false, nil
+		}
+		if status == http.StatusOK {
+			if attrs.Date.Sub(attrs.Modified) <= age {
+				return false, nil
+			}
+
+			// Delete the lock object, at the inspected generation.
+			status, err = m.deleteObject(ctx, backoff.attempt(), attrs.Generation)
+			if status == http.StatusOK || status == http.StatusNoContent {
+				return true, nil
+			}
+			if status == http.StatusPreconditionFailed || status == http.StatusNotFound {
+				// The lock object was written or deleted in the meantime, inspect it.
+				continue
+			}
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("unlock", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return false, fmt.Errorf("unlock mutex: %w", err)
+		}
+		return false, fmt.Errorf("unlock mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+// TryLockIfStale tries to lock m, like TryLock,
+// but also takes the lock from its current holder
+// if the lock object was last written (locked, extended, or updated)
+// more than minAge ago, according to server time,
+// even if it hasn't expired.
+// The previous holder will get ErrStale errors
+// when it tries to extend, update or unlock it.
+//
+// TryLockIfStale is meant for workflows with a hard upper bound on runtime.
+func (m *Mutex) TryLockIfStale(ctx context.Context, minAge time.Duration) (bool, error) {
+	// Fail if another goroutine holds m.
+	select {
+	case m.sem <- struct{}{}:
+	default:
+		return false, nil
+	}
+
+	locked, err := m.tryLockIfStale(ctx, minAge)
+	if !locked {
+		<-m.sem
+	}
+	return locked, err
+}
+
+func (m *Mutex) tryLockIfStale(ctx context.Context, minAge time.Duration) (bool, error) {
+	start := m.hookLockStart()
+	var backoff expBackOff // Exponential backoff because we don't hold the lock.
+
+	for {
+		// Inspect the lock object.
+		status, attrs, err := m.inspectAttrs(ctx, backoff.attempt(), nil)
+		if status == http.StatusOK {
+			if attrs.Date.Sub(attrs.Modified) <= minAge {
+				m.hookContended()
+				return false, nil
+			}
+			// Old enough, steal it.
+			status = http.StatusNotFound
+		}
+
+		if status == http.StatusNotFound {
+			// The lock object doesn't exist, has expired, or is too old, acquire it.
+			sent := time.Now()
+			var gen string
+			status, gen, err = m.createObject(ctx, backoff.attempt(), attrs.Generation, nil)
+			if status == http.StatusOK {
+				// Acquired.
+				m.acquired(gen, sent)
+				m.hookLocked(start)
+				return true, nil
+			}
+			if status == http.StatusNotFound {
+				return false, fmt.Errorf("lock mutex: %w", ErrBucketNotFound)
+			}
+			if status == http.StatusPreconditionFailed {
+				// The lock object was recreated at another generation, inspect it.
+				continue
+			}
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("lock", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return false, fmt.Errorf("lock mutex: %w", err)
+		}
+		return false, fmt.Errorf("lock mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
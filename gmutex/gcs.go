@@ -0,0 +1,189 @@
+package gmutex
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gcsObject is a lock object in Google Cloud Storage,
+// accessed through the XML API.
+type gcsObject struct {
+	bucket  string
+	object  string
+	baseUrl *url.URL
+
+	encryptionKey     string
+	encryptionKeyHash string
+	kmsKeyName        string
+
+	client      *http.Client
+	userProject string
+	patch       bool
+}
+
+func (o *gcsObject) String() string {
+	return "gs://" + o.bucket + "/" + o.object
+}
+
+func (o *gcsObject) Create(ctx context.Context, generation string, attrs Attrs, data io.Reader) (int, string, error) {
+	var body []byte
+	if data != nil {
+		var err error
+		body, err = io.ReadAll(data)
+		if err != nil {
+			return 0, "", err
+		}
+	}
+
+	// Create/update the lock object if the generation matches.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, o.url(), bytes.NewReader(body))
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Cache-Control", "no-store")
+	req.Header.Set("x-goog-hash", hashes(body))
+	if generation != "" {
+		req.Header.Set("x-goog-if-generation-match", generation)
+	}
+	setMetadata(req.Header, attrs)
+	o.setEncryption(req.Header, true)
+
+	res, err := o.do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	res.Body.Close()
+	return res.StatusCode, res.Header.Get("x-goog-generation"), nil
+}
+
+func (o *gcsObject) Extend(ctx context.Context, generation string, attrs Attrs) (int, string, error) {
+	if o.patch {
+		return o.patchMetadata(ctx, generation, attrs)
+	}
+
+	// Copy object doesn't update the generation, only the metageneration.
+	// Compose allows us to update the generation in a single request.
+	var buf bytes.Buffer
+	buf.WriteString("<ComposeRequest><Component><Name>")
+	xml.EscapeText(&buf, []byte(o.object))
+	buf.WriteString("</Name></Component></ComposeRequest>")
+
+	// Extend the lock object if the generation matches.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, o.url()+"?compose", &buf)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Cache-Control", "no-store")
+	req.Header.Set("x-goog-if-generation-match", generation)
+	setMetadata(req.Header, attrs)
+	o.setEncryption(req.Header, true)
+
+	res, err := o.do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	res.Body.Close()
+	return res.StatusCode, res.Header.Get("x-goog-generation"), nil
+}
+
+func (o *gcsObject) Delete(ctx context.Context, generation string) (int, error) {
+	// Delete the lock object if the generation matches.
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, o.url(), nil)
+	if err != nil {
+		panic(err)
+	}
+	if generation != "" {
+		req.Header.Set("x-goog-if-generation-match", generation)
+	}
+
+	res, err := o.do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
+
+func (o *gcsObject) Inspect(ctx context.Context, data io.Writer) (int, Attrs, error) {
+	var method string
+	if data == nil {
+		method = http.MethodHead
+	}
+
+	// Get the lock object's status.
+	req, err := http.NewRequestWithContext(ctx, method, o.url(), nil)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Cache-Control", "no-cache")
+	if data != nil {
+		o.setEncryption(req.Header, false)
+	}
+
+	res, err := o.do(req)
+	if err != nil {
+		return 0, Attrs{}, err
+	}
+	defer res.Body.Close()
+
+	attrs := gcsAttrs(res.Header)
+	if res.StatusCode == http.StatusOK && data != nil {
+		// Verify the data read against its stored checksum.
+		crc := crc32.New(castagnoli)
+		_, err = io.Copy(io.MultiWriter(data, crc), res.Body)
+		if want := storedCRC32C(res.Header); err == nil && want != "" {
+			if got := crc32c(crc.Sum32()); got != want {
+				err = &ChecksumError{Object: o.String(), Want: want, Got: got}
+			}
+		}
+	}
+	return res.StatusCode, attrs, err
+}
+
+func (o *gcsObject) url() string {
+	url := url.URL{
+		Scheme: o.baseUrl.Scheme,
+		Host:   o.baseUrl.Host,
+		Path:   o.bucket + "/" + o.object,
+	}
+	return url.String()
+}
+
+func gcsAttrs(header http.Header) Attrs {
+	var attrs Attrs
+	attrs.Generation = header.Get("x-goog-generation")
+	attrs.Date, _ = http.ParseTime(header.Get("Date"))
+	attrs.Modified, _ = http.ParseTime(header.Get("Last-Modified"))
+	attrs.Expiration, _ = http.ParseTime(header.Get("x-goog-expiration"))
+	attrs.Holder = header.Get("x-goog-meta-holder")
+	for k := range header {
+		if k, ok := strings.CutPrefix(strings.ToLower(k), "x-goog-meta-"); ok && !reservedMetadata(k) {
+			if attrs.Metadata == nil {
+				attrs.Metadata = map[string]string{}
+			}
+			attrs.Metadata[k] = header.Get("x-goog-meta-" + k)
+		}
+	}
+	if ttl, err := strconv.ParseInt(header.Get("x-goog-meta-ttl"), 10, 64); err == nil && ttl > 0 {
+		attrs.TTL = time.Duration(ttl) * time.Second
+	}
+	return attrs
+}
+
+func setMetadata(header http.Header, attrs Attrs) {
+	header.Set("x-goog-meta-ttl", strconv.FormatInt(int64(attrs.TTL/time.Second), 10))
+	if attrs.Holder != "" {
+		header.Set("x-goog-meta-holder", attrs.Holder)
+	}
+	for k, v := range attrs.Metadata {
+		header.Set("x-goog-meta-"+k, v)
+	}
+}
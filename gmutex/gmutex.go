@@ -3,7 +3,6 @@ package gmutex
 import (
 	"bytes"
 	"context"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -16,68 +15,79 @@ import (
 	"time"
 )
 
+// ErrStale is returned when a lock is found to be stale:
+// it expired, was stolen, or was forcibly unlocked,
+// and mutual exclusion was not ensured.
+var ErrStale = errors.New("stale lock")
+
+// ErrUnlocked is returned when extending, or updating, an unlocked mutex;
+// for example, after another goroutine unlocked it.
+var ErrUnlocked = errors.New("unlocked mutex")
+
 // A Mutex is a global, mutual exclusion lock
 // that uses an object in Google Cloud Storage
 // to serialize computations across the internet.
 //
 // A Mutex can optionally have data attached to it while it is held.
-// While there is no limit to the size of this data,
-// it is best kept small.
-// Provided data must be of type *bytes.Buffer, *bytes.Reader,
-// or *strings.Reader.
+// Provided data is buffered in memory, up to MaxDataSize bytes,
+// so it can be read from any io.Reader (files, pipes, etc),
+// but it is best kept small.
 //
 // Given the latency and scalability properties of Google Cloud Storage,
 // a Mutex is best used to serialize long-running, high-latency
 // compute processes.
 // Critical sections should span seconds.
 // Expect an uncontended mutex to take tens to hundreds of milliseconds
-// to acquire, and a contended one multiple seconds after release.
+// to acquire, and a contended one multiple seconds after release
+// (unless a Notifier is used).
 //
 // An instance of Mutex is not associated with a particular goroutine
 // (it is allowed for one goroutine to lock a Mutex
-// and then arrange for another goroutine to unlock it),
-// but it is not safe for concurrent use by multiple goroutines.
+// and then arrange for another goroutine to unlock it).
+// A Mutex is safe for concurrent use by multiple goroutines:
+// goroutines of the same process contend for the lock locally,
+// and a keep-alive goroutine can Extend the lock
+// while another goroutine Unlocks it.
+// Configure the Mutex (SetTTL, SetHooks, etc) before sharing it.
+//
+// A Mutex can also be backed by a Firestore document (see NewFirestore),
+// or any other Backend (see NewWithBackend).
 //
 // To use an API-compatible alternative to Google Cloud Storage
 // (such as fake-gcs-server or similar), provide the endpoint
 // by setting the environment variable STORAGE_EMULATOR_HOST
 // prior to creating the Mutex.
 type Mutex struct {
-	_          noCopy
-	bucket     string
-	object     string
+	_        noCopy
+	backend  Backend
+	ttl      int64
+	holder   string
+	metadata map[string]string
+	fair     bool
+	notifier *Notifier
+	hooks    *Hooks
+	stats    stats
+	debug    bool
+	purge    bool
+	usage    bool
+
+	sem chan struct{} // Held by the goroutine holding the lock.
+	mtx sync.Mutex    // Guards the following, and serializes operations on the lock.
+
 	generation string
-	ttl        int64
-	baseUrl    *url.URL
+	lease      *Lease
+	locked     time.Time
+	mutated    time.Time
 }
 
 // New creates a new Mutex at the given bucket and object,
 // with the given time-to-live.
 func New(ctx context.Context, bucket, object string, ttl time.Duration) (*Mutex, error) {
-	if err := initClient(ctx); err != nil {
+	b, err := NewBackend(ctx, bucket, object)
+	if err != nil {
 		return nil, err
 	}
-
-	var baseUrl *url.URL
-	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host == "" {
-		baseUrl = &url.URL{Scheme: "https", Host: "storage.googleapis.com"}
-	} else if strings.Contains(host, "://") {
-		h, err := url.Parse(host)
-		if err != nil {
-			return nil, err
-		}
-		baseUrl = h
-	} else {
-		baseUrl = &url.URL{Scheme: "http", Host: host}
-	}
-
-	m := Mutex{
-		bucket:  bucket,
-		object:  object,
-		baseUrl: baseUrl,
-	}
-	m.SetTTL(ttl)
-	return &m, nil
+	return NewWithBackend(b, ttl), nil
 }
 
 // TTL gets the time-to-live to use when the mutex is
@@ -99,6 +109,23 @@ func (m *Mutex) SetTTL(ttl time.Duration) {
 	}
 }
 
+// Holder gets the identity recorded on the lock object,
+// when the mutex is locked, extended, or updated.
+func (m *Mutex) Holder() string {
+	return m.holder
+}
+
+// SetHolder sets the identity recorded on the lock object,
+// when the mutex is locked, extended, or updated.
+// An empty identity resets the default: the host name and process id.
+func (m *Mutex) SetHolder(id string) {
+	if id == "" {
+		host, _ := os.Hostname()
+		id = host + ":" + strconv.Itoa(os.Getpid())
+	}
+	m.holder = id
+}
+
 // Locker gets a Locker that uses context.Background to call Lock and Unlock,
 // and panics on error.
 func (m *Mutex) Locker() sync.Locker {
@@ -110,8 +137,11 @@ func (m *Mutex) Locker() sync.Locker {
 // the calling goroutine blocks until the mutex is available,
 // or the context expires.
 // Returns nil if the lock was taken successfully.
-func (m *Mutex) Lock(ctx context.Context) error {
-	return m.LockData(ctx, nil)
+//
+// Options (such as WithMaxWait, or WithBackoff)
+// override the defaults for this call only.
+func (m *Mutex) Lock(ctx context.Context, opts ...LockOption) error {
+	return m.LockData(ctx, nil, opts...)
 }
 
 // LockData locks m with attached data.
@@ -120,39 +150,81 @@ func (m *Mutex) Lock(ctx context.Context) error {
 // or the context expires.
 // Returns nil if the lock was taken successfully
 // (and the attached data stored).
-func (m *Mutex) LockData(ctx context.Context, data io.Reader) error {
-	if m.generation != "" {
-		panic("gmutex: lock of locked mutex")
+func (m *Mutex) LockData(ctx context.Context, data io.Reader, opts ...LockOption) error {
+	body, err := readData(data)
+	if err != nil {
+		return fmt.Errorf("lock mutex: %w", err)
 	}
-	if !rewindable(data) {
-		panic("gmutex: data not rewindable")
+
+	o := newLockOptions(opts)
+	if o.maxWait > 0 {
+		tctx, cancel := context.WithTimeout(ctx, o.maxWait)
+		defer cancel()
+		return timeoutError(ctx, tctx, o.maxWait, m.lockData(tctx, body, o))
 	}
+	return m.lockData(ctx, body, o)
+}
 
-	generation := ""       // Initially, we expect the lock not to exist.
-	var backoff expBackOff // Exponential backoff because we don't hold the lock.
+func (m *Mutex) lockData(ctx context.Context, data []byte, o lockOptions) (err error) {
+	// Wait for other goroutines to unlock m.
+	select {
+	case m.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if m.fair {
+		err = m.lockFair(ctx, data, o.backoff())
+	} else {
+		err = m.lock(ctx, data, o.backoff())
+	}
+	if err != nil {
+		<-m.sem
+	}
+	return err
+}
+
+// lock uses exponential backoff because we don't hold the lock.
+func (m *Mutex) lock(ctx context.Context, data []byte, backoff expBackOff) error {
+	start := m.hookLockStart()
+	generation := ""   // Initially, we expect the lock not to exist.
+	contended := false // Whether the lock was found in use.
+
+	// Subscribe before inspecting, so no release notifications are missed.
+	wake := m.notifier.subscribe(m.backend)
+	defer m.notifier.unsubscribe(m.backend, wake)
 
 	for {
 		// Create the lock object, at the expected generation.
-		status, gen, err := m.createObject(ctx, generation, data)
+		sent := time.Now()
+		status, gen, err := m.createObject(ctx, backoff.attempt(), generation, data)
 		if status == http.StatusOK {
 			// Acquired.
-			m.generation = gen
+			m.acquired(gen, sent)
+			m.hookLocked(start)
 			return nil
 		}
 		if status == http.StatusNotFound {
-			return errors.New("lock mutex: bucket does not exist")
+			return fmt.Errorf("lock mutex: %w", ErrBucketNotFound)
 		}
 
 		if status == http.StatusPreconditionFailed {
 			// The lock object exists at another generation, inspect it.
-			status, gen, err = m.inspectObject(ctx, nil)
+			status, gen, err = m.inspectObject(ctx, backoff.attempt(), nil)
 		}
 		// While the lock object exists, and for transient errors, backoff and retry.
+		// Wake early if notified that the lock object was released.
 		for status == http.StatusOK || retriable(status, err) {
-			if err := backoff.wait(ctx); err != nil {
+			if status != http.StatusOK {
+				m.hookRetry("lock", status, err)
+			} else if !contended {
+				contended = true
+				m.hookContended()
+			}
+			if err := backoff.notify(ctx, wake); err != nil {
 				return err
 			}
-			status, gen, err = m.inspectObject(ctx, nil)
+			status, gen, err = m.inspectObject(ctx, backoff.attempt(), nil)
 		}
 		if status == http.StatusNotFound {
 			// The lock object no longer exists, or has expired, acquire it.
@@ -180,34 +252,75 @@ func (m *Mutex) TryLock(ctx context.Context) (bool, error) {
 // (and the attached data stored).
 // Returns false if the lock is already in use,
 // fetching attached data if data satisfies io.Writer.
+// If another goroutine of this process holds m,
+// returns false without reading or writing data.
 func (m *Mutex) TryLockData(ctx context.Context, data io.Reader) (bool, error) {
-	if m.generation != "" {
-		panic("gmutex: lock of locked mutex")
+	locked, _, err := m.tryLockData(ctx, data)
+	return locked, err
+}
+
+// tryLockData implements TryLockData,
+// also reporting whether the holder's data was fetched.
+func (m *Mutex) tryLockData(ctx context.Context, data io.Reader) (locked, fetched bool, err error) {
+	// Fail if another goroutine holds m,
+	// before consuming data.
+	select {
+	case m.sem <- struct{}{}:
+	default:
+		return false, false, nil
 	}
-	if !rewindable(data) {
-		panic("gmutex: data not rewindable")
+
+	body, err := readData(data)
+	if err != nil {
+		<-m.sem
+		return false, false, fmt.Errorf("lock mutex: %w", err)
 	}
 
 	buffer, _ := data.(io.Writer)
+	locked, fetched, err = m.tryLock(ctx, body, buffer)
+	if !locked {
+		<-m.sem
+	}
+	return locked, fetched, err
+}
+
+func (m *Mutex) tryLock(ctx context.Context, data []byte, buffer io.Writer) (locked, fetched bool, err error) {
+	start := m.hookLockStart()
 	var backoff expBackOff // Exponential backoff because we don't hold the lock.
 
 	for {
 		// Inspect the lock object.
-		status, gen, err := m.inspectObject(ctx, buffer)
+		status, gen, err := m.inspectObject(ctx, backoff.attempt(), buffer)
 		if status == http.StatusOK {
-			return false, nil
+			m.hookContended()
+			return false, buffer != nil && err == nil, nil
+		}
+
+		if status == http.StatusNotFound && m.fair {
+			// In fair mode, waiters in line go first.
+			var queued bool
+			status, queued, err = m.queued(ctx)
+			if status == http.StatusOK && queued {
+				m.hookContended()
+				return false, false, nil
+			}
+			if status == http.StatusOK {
+				status = http.StatusNotFound
+			}
 		}
 
 		if status == http.StatusNotFound {
 			// The lock object doesn't exist, or has expired, acquire it.
-			status, gen, err = m.createObject(ctx, gen, data)
+			sent := time.Now()
+			status, gen, err = m.createObject(ctx, backoff.attempt(), gen, data)
 			if status == http.StatusOK {
 				// Acquired.
-				m.generation = gen
-				return true, nil
+				m.acquired(gen, sent)
+				m.hookLocked(start)
+				return true, false, nil
 			}
 			if status == http.StatusNotFound {
-				return false, errors.New("lock mutex: bucket does not exist")
+				return false, false, fmt.Errorf("lock mutex: %w", ErrBucketNotFound)
 			}
 			if status == http.StatusPreconditionFailed {
 				// The lock object was recreated at another generation, inspect it.
@@ -217,17 +330,18 @@ func (m *Mutex) TryLockData(ctx context.Context, data io.Reader) (bool, error) {
 
 		// For transient errors, backoff and retry.
 		if retriable(status, err) {
+			m.hookRetry("lock", status, err)
 			if err := backoff.wait(ctx); err != nil {
-				return false, err
+				return false, false, err
 			}
 			continue
 		}
 
 		// Can't recover, give up.
 		if err != nil {
-			return false, fmt.Errorf("lock mutex: %w", err)
+			return false, false, fmt.Errorf("lock mutex: %w", err)
 		}
-		return false, fmt.Errorf("lock mutex: http status %d: %s", status, http.StatusText(status))
+		return false, false, fmt.Errorf("lock mutex: http status %d: %s", status, http.StatusText(status))
 	}
 }
 
@@ -235,27 +349,58 @@ func (m *Mutex) TryLockData(ctx context.Context, data io.Reader) (bool, error) {
 // Returns an error if the lock had already expired,
 // and mutual exclusion was not ensured.
 func (m *Mutex) Unlock(ctx context.Context) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
 	if m.generation == "" {
 		panic("gmutex: unlock of unlocked mutex")
 	}
+	return m.unlock(ctx)
+}
 
+// UnlockIfHeld unlocks m if it's locked, like Unlock,
+// but is a no-op if m is unlocked, or its lock is found to be stale.
+// Returns true if the lock was released,
+// so it can be deferred in code with multiple exit paths.
+func (m *Mutex) UnlockIfHeld(ctx context.Context) (bool, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.generation == "" {
+		return false, nil
+	}
+
+	err := m.unlock(ctx)
+	if errors.Is(err, ErrStale) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlock must be called with m.mtx held.
+func (m *Mutex) unlock(ctx context.Context) error {
 	var backoff linBackOff // Linear backoff because we hold the lock.
 
 	for {
 		// Delete the lock object, at the expected generation.
-		status, err := m.deleteObject(ctx, m.generation)
+		status, err := m.deleteObject(ctx, backoff.attempt(), m.generation)
 		if status == http.StatusOK || status == http.StatusNoContent {
-			m.generation = ""
+			locked := m.locked
+			held := time.Since(locked)
+			m.released()
+			m.hookUnlocked(held)
+			m.recordUsage(ctx, locked, held)
 			return nil
 		}
 
 		if status == http.StatusPreconditionFailed || status == http.StatusNotFound {
 			// The lock object exists at another generation, or no longer exists, it's stale.
-			return errors.New("unlock mutex: stale lock")
+			err := m.lost(fmt.Errorf("unlock mutex: %w", ErrStale))
+			m.released()
+			return err
 		}
 
 		// For transient errors, backoff and retry.
 		if retriable(status, err) {
+			m.hookRetry("unlock", status, err)
 			if err := backoff.wait(ctx); err != nil {
 				return err
 			}
@@ -273,32 +418,45 @@ func (m *Mutex) Unlock(ctx context.Context) error {
 // Extend extends the expiration time of m, keeping any attached data.
 // Returns an error if the lock has already expired,
 // and mutual exclusion can not be ensured.
+//
+// Cloud Storage limits writes to the lock object to about one per second:
+// Extend, and UpdateData, wait for that long after the last write,
+// or return ErrRateLimited if ctx would expire first.
 func (m *Mutex) Extend(ctx context.Context) error {
+	return m.hookExtendFailed(m.extend(ctx))
+}
+
+func (m *Mutex) extend(ctx context.Context) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
 	if m.generation == "" {
-		panic("gmutex: extend of unlocked mutex")
+		return fmt.Errorf("extend mutex: %w", ErrUnlocked)
+	}
+	if err := m.pace(ctx); err != nil {
+		return fmt.Errorf("extend mutex: %w", err)
 	}
 
 	var backoff linBackOff // Linear backoff because we hold the lock.
 
 	for {
 		// Extend the lock object, at the expected generation.
-		status, gen, err := m.extendObject(ctx, m.generation)
+		sent := time.Now()
+		status, gen, err := m.extendObject(ctx, backoff.attempt(), m.generation)
 		if status == http.StatusOK {
 			// Extended.
-			m.generation = gen
+			m.renewed(gen, sent)
+			m.hookExtended()
 			return nil
 		}
-		if status == http.StatusNotFound {
-			return errors.New("mutex: bucket does not exist")
-		}
 
 		if status == http.StatusPreconditionFailed || status == http.StatusNotFound {
 			// The lock object exists at another generation, or no longer exists, it's stale.
-			return errors.New("extend mutex: stale lock, abort")
+			return m.lost(fmt.Errorf("extend mutex: %w, abort", ErrStale))
 		}
 
 		// For transient errors, backoff and retry.
 		if retriable(status, err) {
+			m.hookRetry("extend", status, err)
 			if err := backoff.wait(ctx); err != nil {
 				return err
 			}
@@ -317,34 +475,43 @@ func (m *Mutex) Extend(ctx context.Context) error {
 // Returns an error if the lock has already expired,
 // and mutual exclusion can not be ensured.
 func (m *Mutex) UpdateData(ctx context.Context, data io.Reader) error {
+	body, err := readData(data)
+	if err != nil {
+		return m.hookExtendFailed(fmt.Errorf("update mutex: %w", err))
+	}
+	return m.hookExtendFailed(m.updateData(ctx, body))
+}
+
+func (m *Mutex) updateData(ctx context.Context, data []byte) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
 	if m.generation == "" {
-		panic("gmutex: update of unlocked mutex")
+		return fmt.Errorf("update mutex: %w", ErrUnlocked)
 	}
-	if !rewindable(data) {
-		panic("gmutex: data not rewindable")
+	if err := m.pace(ctx); err != nil {
+		return fmt.Errorf("update mutex: %w", err)
 	}
 
 	var backoff linBackOff // Linear backoff because we hold the lock.
 
 	for {
 		// Update the lock object, at the expected generation.
-		status, gen, err := m.createObject(ctx, m.generation, data)
+		sent := time.Now()
+		status, gen, err := m.createObject(ctx, backoff.attempt(), m.generation, data)
 		if status == http.StatusOK {
 			// Updated.
-			m.generation = gen
+			m.renewed(gen, sent)
+			m.hookExtended()
 			return nil
 		}
-		if status == http.StatusNotFound {
-			return errors.New("lock mutex: bucket does not exist")
-		}
-
 		if status == http.StatusPreconditionFailed || status == http.StatusNotFound {
 			// The lock object exists at another generation, or no longer exists, it's stale.
-			return errors.New("update mutex: stale lock, abort")
+			return m.lost(fmt.Errorf("update mutex: %w, abort", ErrStale))
 		}
 
 		// For transient errors, backoff and retry.
 		if retriable(status, err) {
+			m.hookRetry("update", status, err)
 			if err := backoff.wait(ctx); err != nil {
 				return err
 			}
@@ -359,13 +526,58 @@ func (m *Mutex) UpdateData(ctx context.Context, data io.Reader) error {
 	}
 }
 
+// GetData reads the data attached to the lock held by m,
+// checking that m still holds it.
+// Returns an error if the lock has already expired,
+// and mutual exclusion can not be ensured.
+func (m *Mutex) GetData(ctx context.Context, data io.Writer) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.generation == "" {
+		return fmt.Errorf("get mutex: %w", ErrUnlocked)
+	}
+
+	var backoff linBackOff // Linear backoff because we hold the lock.
+
+	for {
+		// Read the lock object, and check it's at the expected generation.
+		var buf bytes.Buffer
+		status, attrs, err := m.inspectAttrs(ctx, backoff.attempt(), &buf)
+		if status == http.StatusOK && err == nil && attrs.Generation == m.generation {
+			reset(data)
+			_, err := buf.WriteTo(data)
+			return err
+		}
+
+		if status == http.StatusOK && err == nil || status == http.StatusNotFound {
+			// The lock object exists at another generation, or no longer exists, it's stale.
+			return m.lost(fmt.Errorf("get mutex: %w, abort", ErrStale))
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("get", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return fmt.Errorf("get mutex: %w", err)
+		}
+		return fmt.Errorf("get mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
 // InspectData inspects m, returning its locked state and fetching attached data.
 func (m *Mutex) InspectData(ctx context.Context, data io.Writer) (bool, error) {
 	var backoff expBackOff // Exponential backoff because we don't hold the lock.
 
 	for {
 		// Inspect the lock object.
-		status, _, err := m.inspectObject(ctx, data)
+		status, _, err := m.inspectObject(ctx, backoff.attempt(), data)
 		if status == http.StatusOK {
 			return true, nil
 		}
@@ -375,6 +587,7 @@ func (m *Mutex) InspectData(ctx context.Context, data io.Writer) (bool, error) {
 
 		// For transient errors, backoff and retry.
 		if retriable(status, err) {
+			m.hookRetry("inspect", status, err)
 			if err := backoff.wait(ctx); err != nil {
 				return false, err
 			}
@@ -389,150 +602,213 @@ func (m *Mutex) InspectData(ctx context.Context, data io.Writer) (bool, error) {
 	}
 }
 
+// Remaining returns how long until the lock held by m expires,
+// according to the lock object's modification time, its time-to-live,
+// and the server's clock.
+// Returns zero if the lock never expires,
+// ErrUnlocked if m is unlocked, and ErrStale if the lock was lost.
+func (m *Mutex) Remaining(ctx context.Context) (time.Duration, error) {
+	m.mtx.Lock()
+	generation := m.generation
+	m.mtx.Unlock()
+	if generation == "" {
+		return 0, fmt.Errorf("remaining mutex: %w", ErrUnlocked)
+	}
+
+	var backoff linBackOff // Linear backoff because we hold the lock.
+
+	for {
+		// Inspect the lock object's metadata.
+		status, attrs, err := m.inspectAttrs(ctx, backoff.attempt(), nil)
+		if status == http.StatusOK && attrs.Generation == generation {
+			exp := attrs.expiration()
+			if exp.IsZero() || attrs.Date.IsZero() {
+				return 0, nil
+			}
+			return exp.Sub(attrs.Date), nil
+		}
+		if status == http.StatusOK || status == http.StatusNotFound {
+			// Expired, stolen, or forcibly unlocked.
+			err := fmt.Errorf("remaining mutex: %w", ErrStale)
+			m.mtx.Lock()
+			defer m.mtx.Unlock()
+			if m.generation == generation {
+				m.lost(err)
+			}
+			return 0, err
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("remaining", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return 0, fmt.Errorf("remaining mutex: %w", err)
+		}
+		return 0, fmt.Errorf("remaining mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+// WaitUnlocked waits for m to be unlocked (by its holder, or by expiring),
+// without trying to lock it.
+// Returns nil once m is found unlocked.
+func (m *Mutex) WaitUnlocked(ctx context.Context) error {
+	var backoff expBackOff // Exponential backoff because we don't hold the lock.
+
+	// Subscribe before inspecting, so no release notifications are missed.
+	wake := m.notifier.subscribe(m.backend)
+	defer m.notifier.unsubscribe(m.backend, wake)
+
+	for {
+		// Inspect the lock object.
+		status, _, err := m.inspectObject(ctx, backoff.attempt(), nil)
+		if status == http.StatusNotFound {
+			return nil
+		}
+
+		// While the lock object exists, and for transient errors, backoff and retry.
+		// Wake early if notified that the lock object was released.
+		if status == http.StatusOK || retriable(status, err) {
+			if status != http.StatusOK {
+				m.hookRetry("inspect", status, err)
+			}
+			if err := backoff.notify(ctx, wake); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return fmt.Errorf("inspect mutex: %w", err)
+		}
+		return fmt.Errorf("inspect mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
 // Abandon abandons m, returning a lock id that can be used to call Adopt.
 func (m *Mutex) Abandon() string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
 	if m.generation == "" {
 		panic("gmutex: abandon of unlocked mutex")
 	}
 
 	gen := m.generation
-	m.generation = ""
+	m.released()
 	return gen
 }
 
 // Adopt adopts an abandoned lock into m,
 // and calls Extend to ensure mutual exclusion.
 func (m *Mutex) Adopt(ctx context.Context, id string) error {
-	if m.generation != "" {
-		panic("gmutex: adopt on locked mutex")
-	}
 	if id == "" || id == "0" {
 		panic("gmutex: adopt of invalid lock")
 	}
 
+	// Wait for other goroutines to unlock m.
+	select {
+	case m.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	m.mtx.Lock()
 	m.generation = id
+	m.lease = newLease(m, id)
+	m.locked = time.Now()
+	m.mtx.Unlock()
 	return m.Extend(ctx)
 }
 
 // AdoptData adopts an abandoned lock into m,
 // and calls UpdateData to ensure mutual exclusion.
 func (m *Mutex) AdoptData(ctx context.Context, id string, data io.Reader) error {
-	if m.generation != "" {
-		panic("gmutex: adopt on locked mutex")
-	}
 	if id == "" || id == "0" {
 		panic("gmutex: adopt of invalid lock")
 	}
 
-	m.generation = id
-	return m.UpdateData(ctx, data)
-}
-
-func (m *Mutex) createObject(ctx context.Context, generation string, data io.Reader) (int, string, error) {
-	if generation == "" {
-		generation = "0"
+	// Wait for other goroutines to unlock m.
+	select {
+	case m.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
-	// Create/update the lock object if the generation matches.
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, m.url(), data)
-	if err != nil {
-		panic(err)
-	}
-	req.Header.Set("Cache-Control", "no-store")
-	req.Header.Set("x-goog-if-generation-match", generation)
-	req.Header.Set("x-goog-meta-ttl", strconv.FormatInt(m.ttl, 10))
-
-	res, err := HTTPClient.Do(req)
-	if err != nil {
-		return 0, "", err
-	}
-	res.Body.Close()
-	return res.StatusCode, res.Header.Get("x-goog-generation"), nil
-}
-
-func (m *Mutex) extendObject(ctx context.Context, generation string) (int, string, error) {
-	// Copy object doesn't update the generation, only the metageneration.
-	// Compose allows us to update the generation in a single request.
-	var buf bytes.Buffer
-	buf.WriteString("<ComposeRequest><Component><Name>")
-	xml.EscapeText(&buf, []byte(m.object))
-	buf.WriteString("</Name></Component></ComposeRequest>")
-
-	// Extend the lock object if the generation matches.
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, m.url()+"?compose", &buf)
-	if err != nil {
-		panic(err)
-	}
-	req.Header.Set("Cache-Control", "no-store")
-	req.Header.Set("x-goog-if-generation-match", generation)
-	req.Header.Set("x-goog-meta-ttl", strconv.FormatInt(m.ttl, 10))
-
-	res, err := HTTPClient.Do(req)
-	if err != nil {
-		return 0, "", err
-	}
-	res.Body.Close()
-	return res.StatusCode, res.Header.Get("x-goog-generation"), nil
+	m.mtx.Lock()
+	m.generation = id
+	m.lease = newLease(m, id)
+	m.locked = time.Now()
+	m.mtx.Unlock()
+	return m.UpdateData(ctx, data)
 }
 
-func (m *Mutex) deleteObject(ctx context.Context, generation string) (int, error) {
-	// Delete the lock object if the generation matches.
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, m.url(), nil)
-	if err != nil {
-		panic(err)
-	}
-	req.Header.Set("x-goog-if-generation-match", generation)
-
-	res, err := HTTPClient.Do(req)
-	if err != nil {
-		return 0, err
+// AdoptVerify adopts an abandoned lock into m,
+// checking that it's still held, without modifying the lock object.
+// Unlike Adopt, the lock isn't extended:
+// the lease expires when the lock object does.
+// Returns ErrStale if the lock is no longer held.
+func (m *Mutex) AdoptVerify(ctx context.Context, id string) error {
+	if id == "" || id == "0" {
+		panic("gmutex: adopt of invalid lock")
 	}
-	res.Body.Close()
-	return res.StatusCode, nil
-}
 
-func (m *Mutex) inspectObject(ctx context.Context, data io.Writer) (int, string, error) {
-	var method string
-	if data == nil {
-		method = http.MethodHead
+	// Wait for other goroutines to unlock m.
+	select {
+	case m.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
-	// Get the lock object's status.
-	req, err := http.NewRequestWithContext(ctx, method, m.url(), nil)
-	if err != nil {
-		panic(err)
-	}
-	req.Header.Set("Cache-Control", "no-cache")
+	var backoff expBackOff // Exponential backoff because we don't hold the lock.
 
-	res, err := HTTPClient.Do(req)
-	if err != nil {
-		return 0, "", err
-	}
-	defer res.Body.Close()
+	for {
+		// Inspect the lock object's metadata.
+		sent := time.Now()
+		status, attrs, err := m.inspectAttrs(ctx, backoff.attempt(), nil)
+		if status == http.StatusOK && attrs.Generation == id {
+			// Still held, the lease expires with the lock object.
+			var ttl time.Duration
+			if exp := attrs.expiration(); !exp.IsZero() && !attrs.Date.IsZero() {
+				ttl = exp.Sub(attrs.Date)
+			}
+			m.mtx.Lock()
+			m.generation = id
+			m.lease = newLease(m, id)
+			m.lease.renew(sent, ttl)
+			m.locked = time.Now()
+			m.mtx.Unlock()
+			return nil
+		}
+		if status == http.StatusOK || status == http.StatusNotFound {
+			// Expired, stolen, or forcibly unlocked.
+			<-m.sem
+			return fmt.Errorf("adopt mutex: %w", ErrStale)
+		}
 
-	// If it exists, but is expired, act as if it didn't.
-	if res.StatusCode == http.StatusOK && expired(res) {
-		res.StatusCode = http.StatusNotFound
-	}
-	if res.StatusCode == http.StatusOK && data != nil {
-		switch b := data.(type) {
-		case *strings.Builder:
-			b.Reset()
-		case *bytes.Buffer:
-			b.Reset()
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("adopt", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				<-m.sem
+				return err
+			}
+			continue
 		}
-		_, err = io.Copy(data, res.Body)
-	}
-	return res.StatusCode, res.Header.Get("x-goog-generation"), err
-}
 
-func (m *Mutex) url() string {
-	url := url.URL{
-		Scheme: m.baseUrl.Scheme,
-		Host:   m.baseUrl.Host,
-		Path:   m.bucket + "/" + m.object,
+		// Can't recover, give up.
+		<-m.sem
+		if err != nil {
+			return fmt.Errorf("adopt mutex: %w", err)
+		}
+		return fmt.Errorf("adopt mutex: http status %d: %s", status, http.StatusText(status))
 	}
-	return url.String()
 }
 
 func retriable(status int, err error) bool {
@@ -549,33 +825,36 @@ func retriable(status int, err error) bool {
 		status == http.StatusGatewayTimeout
 }
 
-func rewindable(body io.Reader) bool {
-	switch body.(type) {
-	case nil, *bytes.Buffer, *bytes.Reader, *strings.Reader:
-		return true
-	default:
-		return body == http.NoBody
+func reset(data io.Writer) {
+	// Discard previous contents, in case of retries.
+	switch b := data.(type) {
+	case *strings.Builder:
+		b.Reset()
+	case *bytes.Buffer:
+		b.Reset()
 	}
 }
 
-func expired(res *http.Response) bool {
-	// Check for expiration using server date.
-	now, err := http.ParseTime(res.Header.Get("Date"))
-	if err != nil {
-		return false
+// MaxDataSize is the maximum size of data attached to a Mutex.
+var MaxDataSize int64 = 16 << 20
+
+// ErrDataTooLarge is returned when attached data exceeds MaxDataSize.
+var ErrDataTooLarge = errors.New("data too large")
+
+func readData(data io.Reader) ([]byte, error) {
+	// Buffer data, so it can be sent on every attempt.
+	if data == nil {
+		return nil, nil
 	}
-	modified, err := http.ParseTime(res.Header.Get("Last-Modified"))
+	b, err := io.ReadAll(io.LimitReader(data, MaxDataSize+1))
 	if err != nil {
-		return false
+		return nil, err
 	}
-	lifecycle, err := http.ParseTime(res.Header.Get("x-goog-expiration"))
-	if err == nil && lifecycle.Before(now) {
-		return true
+	if int64(len(b)) > MaxDataSize {
+		return nil, ErrDataTooLarge
 	}
-	ttl, err := strconv.ParseInt(res.Header.Get("x-goog-meta-ttl"), 10, 64)
-	if err != nil || ttl <= 0 {
-		return false
+	if b == nil {
+		b = []byte{}
 	}
-	expires := modified.Add(time.Duration(ttl) * time.Second)
-	return expires.Before(now)
+	return b, nil
 }
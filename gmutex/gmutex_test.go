@@ -3,13 +3,20 @@ package gmutex_test
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/ncruces/go-gcp/gmutex"
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
 )
 
 var bucket = os.Getenv("BUCKET")
@@ -17,12 +24,19 @@ var object = os.Getenv("OBJECT")
 
 func TestMain(m *testing.M) {
 	gmutex.HTTPClient = http.DefaultClient
+	if bucket == "" || object == "" {
+		// Without a bucket, test against a fake server.
+		server := gmutextest.NewServer("bucket")
+		os.Setenv("STORAGE_EMULATOR_HOST", server.URL)
+		bucket, object = "bucket", "object"
+		code := m.Run()
+		server.Close()
+		os.Exit(code)
+	}
 	if os.Getenv("STORAGE_EMULATOR_HOST") != "" {
 		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
-	if bucket != "" && object != "" {
-		os.Exit(m.Run())
-	}
+	os.Exit(m.Run())
 }
 
 func TestMutex_contention(t *testing.T) {
@@ -173,3 +187,1003 @@ func TestMutex_SetTTL(t *testing.T) {
 		})
 	}
 }
+
+func TestMutex_SetMetadata(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.SetMetadata(map[string]string{"TTL": "1"}); err == nil {
+		t.Error("SetMetadata() accepted reserved key")
+	}
+	if err := mtx.SetMetadata(map[string]string{"Job": "42"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer mtx.Unlock(ctx)
+
+	other, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked, md, err := other.InspectMetadata(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !locked || md["job"] != "42" {
+		t.Errorf("InspectMetadata() = %v, %v, want true, job=42", locked, md)
+	}
+}
+
+func TestMutex_SetPurgeVersions(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.SetPurgeVersions(true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if locked, err := mtx.InspectData(ctx, nil); err != nil || !locked {
+		t.Fatalf("InspectData() = %v, %v, want true", locked, err)
+	}
+	if err := mtx.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunExclusive(t *testing.T) {
+	ctx := context.Background()
+
+	var ran bool
+	err := gmutex.RunExclusive(ctx, bucket, object, time.Minute, func(ctx context.Context) error {
+		ran = true
+		err := gmutex.RunExclusive(ctx, bucket, object, time.Minute, func(ctx context.Context) error {
+			t.Error("overlapping run")
+			return nil
+		})
+		var held *gmutex.HeldError
+		if !errors.As(err, &held) {
+			t.Errorf("RunExclusive() = %v, want *HeldError", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Fatal("didn't run")
+	}
+
+	run, ok, err := gmutex.LastRun(ctx, bucket, object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || run.Finished.IsZero() || run.Error != "" {
+		t.Errorf("LastRun() = %+v, %v", run, ok)
+	}
+}
+
+func TestMutex_WaitUnlocked(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		other, err := gmutex.New(ctx, bucket, object, time.Minute)
+		if err == nil {
+			err = other.WaitUnlocked(ctx)
+		}
+		done <- err
+	}()
+
+	time.Sleep(time.Second)
+	select {
+	case err := <-done:
+		t.Fatalf("WaitUnlocked() = %v, before unlock", err)
+	default:
+	}
+
+	if err := mtx.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutex_LockData(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		io.WriteString(w, "hello")
+		w.Close()
+	}()
+	if err := mtx.LockData(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+	defer mtx.Unlock(ctx)
+
+	var buf strings.Builder
+	if locked, err := mtx.InspectData(ctx, &buf); err != nil || !locked {
+		t.Fatalf("InspectData() = %v, %v, want true", locked, err)
+	}
+	if got := buf.String(); got != "hello" {
+		t.Errorf("InspectData() = %q, want %q", got, "hello")
+	}
+
+	// Extending composes a new object, which must still verify.
+	if err := mtx.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if locked, err := mtx.InspectData(ctx, &buf); err != nil || !locked {
+		t.Fatalf("InspectData() = %v, %v, want true", locked, err)
+	}
+	if got := buf.String(); got != "hello" {
+		t.Errorf("InspectData() = %q, want %q", got, "hello")
+	}
+}
+
+func TestMutex_MarshalJSON(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.SetMetadata(map[string]string{"job": "marshal"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.SetPatchExtend(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := json.Marshal(mtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var restored gmutex.Mutex
+	if err := json.Unmarshal(state, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.TTL() != time.Minute {
+		t.Errorf("TTL() = %v, want %v", restored.TTL(), time.Minute)
+	}
+	if got, want := restored.Lease().Deadline(), mtx.Lease().Deadline(); !got.Equal(want) {
+		t.Errorf("Deadline() = %v, want %v", got, want)
+	}
+	if again, err := json.Marshal(&restored); err != nil || string(again) != string(state) {
+		t.Errorf("MarshalJSON() = %s, want %s", again, state)
+	}
+	if err := restored.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Extend(ctx); !errors.Is(err, gmutex.ErrStale) {
+		t.Errorf("Extend() = %v, want ErrStale", err)
+	}
+
+	if err := mtx.SetEncryptionKey(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := json.Marshal(mtx); err == nil {
+		t.Error("MarshalJSON() with encryption key succeeded")
+	}
+}
+
+func TestMutex_AdoptVerify(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	id := mtx.Abandon()
+
+	if err := mtx.AdoptVerify(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if lease := mtx.Lease(); lease == nil || lease.Token() != id {
+		t.Fatalf("Lease() = %v, want token %q", lease, id)
+	}
+	if err := mtx.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.AdoptVerify(ctx, id); !errors.Is(err, gmutex.ErrStale) {
+		t.Errorf("AdoptVerify() = %v, want ErrStale", err)
+	}
+}
+
+func TestMutex_Extend_forceUnlocked(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.ForceUnlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Extend(ctx); !errors.Is(err, gmutex.ErrStale) {
+		t.Errorf("Extend() = %v, want ErrStale", err)
+	}
+	mtx.Unlock(ctx)
+}
+
+func TestMutex_UpdateData_forceUnlocked(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.ForceUnlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.UpdateData(ctx, strings.NewReader("data")); !errors.Is(err, gmutex.ErrStale) {
+		t.Errorf("UpdateData() = %v, want ErrStale", err)
+	}
+	mtx.Unlock(ctx)
+}
+
+func TestMutex_Remaining(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mtx.Remaining(ctx); !errors.Is(err, gmutex.ErrUnlocked) {
+		t.Errorf("Remaining() = %v, want ErrUnlocked", err)
+	}
+
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer mtx.Unlock(ctx)
+
+	remaining, err := mtx.Remaining(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining <= 0 || remaining > time.Minute+time.Second {
+		t.Errorf("Remaining() = %v, want about %v", remaining, time.Minute)
+	}
+}
+
+func TestMutex_Inspect(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := mtx.Inspect(ctx); err != nil || info.Held {
+		t.Fatalf("Inspect() = %+v, %v, want not held", info, err)
+	}
+
+	mtx.SetHolder("inspect")
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer mtx.Unlock(ctx)
+
+	info, err := mtx.Inspect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Held || info.Holder != "inspect" || info.TTL != time.Minute ||
+		info.Object != object || info.Generation != mtx.Lease().Token() {
+		t.Errorf("Inspect() = %+v", info)
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = gmutex.WithLock(ctx, mtx, func(ctx context.Context) error {
+		// Outlive the time-to-live, relying on auto-extension.
+		select {
+		case <-time.After(4 * time.Second):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mtx.Lease() != nil {
+		t.Error("WithLock() didn't unlock")
+	}
+}
+
+type headerRecorder struct {
+	mtx     sync.Mutex
+	project []string
+}
+
+func (r *headerRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mtx.Lock()
+	r.project = append(r.project, req.Header.Get("x-goog-user-project"))
+	r.mtx.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestMutex_SetUserProject(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rec headerRecorder
+	if err := mtx.SetHTTPClient(&http.Client{Transport: &rec}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.SetUserProject("project"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.project) == 0 {
+		t.Fatal("custom client not used")
+	}
+	for _, p := range rec.project {
+		if p != "project" {
+			t.Errorf("x-goog-user-project = %q, want %q", p, "project")
+		}
+	}
+}
+
+func TestMutex_Extend_pacing(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer mtx.Unlock(ctx)
+
+	short, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := mtx.Extend(short); !errors.Is(err, gmutex.ErrRateLimited) {
+		t.Errorf("Extend() = %v, want ErrRateLimited", err)
+	}
+
+	start := time.Now()
+	if err := mtx.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Extend() twice took %v, want paced", elapsed)
+	}
+}
+
+func TestMutex_Lock_options(t *testing.T) {
+	ctx := context.Background()
+	holder, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Unlock(ctx)
+
+	start := time.Now()
+	err = waiter.Lock(ctx,
+		gmutex.WithMaxWait(500*time.Millisecond),
+		gmutex.WithBackoff(10*time.Millisecond, 100*time.Millisecond))
+	var timeout *gmutex.TimeoutError
+	if !errors.As(err, &timeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lock() = %v, want TimeoutError", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Lock() took %v, want about 500ms", elapsed)
+	}
+}
+
+func TestMutex_SetEndpoint(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.SetEndpoint("https://storage.googleapis.com/path"); err == nil {
+		t.Error("SetEndpoint() accepted a path")
+	}
+	if err := mtx.SetEndpoint("storage.us-east1.rep.googleapis.com"); err != nil {
+		t.Error(err)
+	}
+	if err := mtx.SetEndpoint(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutex_SetHooks_lost(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lost := make(chan error, 2)
+	mtx.SetHooks(&gmutex.Hooks{
+		Lost: func(m *gmutex.Mutex, err error) { lost <- err },
+	})
+	lease, err := mtx.LockLease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Let the lease expire, without extending it.
+	select {
+	case err := <-lost:
+		if !errors.Is(err, gmutex.ErrLeaseExpired) {
+			t.Errorf("Lost(%v), want ErrLeaseExpired", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Lost not called")
+	}
+	select {
+	case <-lease.Done():
+	default:
+		t.Error("lease not done")
+	}
+
+	// The loss is reported once.
+	mtx.Extend(ctx)
+	mtx.Unlock(ctx)
+	if len(lost) != 0 {
+		t.Errorf("Lost called %d more times", len(lost))
+	}
+}
+
+func TestMutex_GetJSON(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state struct{ Step int }
+	if err := mtx.GetJSON(ctx, &state); !errors.Is(err, gmutex.ErrUnlocked) {
+		t.Errorf("GetJSON() = %v, want ErrUnlocked", err)
+	}
+
+	state.Step = 1
+	if err := mtx.LockJSON(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+	defer mtx.Unlock(ctx)
+
+	state.Step = 0
+	if err := mtx.GetJSON(ctx, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Step != 1 {
+		t.Errorf("GetJSON() = %+v, want step 1", state)
+	}
+}
+
+func TestMutex_TryLockIfStale(t *testing.T) {
+	ctx := context.Background()
+	holder, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thief, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if locked, err := thief.TryLockIfStale(ctx, time.Hour); err != nil || locked {
+		t.Fatalf("TryLockIfStale() = %v, %v, want false", locked, err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if locked, err := thief.TryLockIfStale(ctx, 0); err != nil || !locked {
+		t.Fatalf("TryLockIfStale() = %v, %v, want true", locked, err)
+	}
+	if err := holder.Unlock(ctx); !errors.Is(err, gmutex.ErrStale) {
+		t.Errorf("Unlock() = %v, want ErrStale", err)
+	}
+	if err := thief.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutex_SetUsageTracking(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.SetUsageTracking(true); err != nil {
+		t.Fatal(err)
+	}
+	mtx.SetHolder("usage")
+
+	before, _, err := gmutex.GetUsage(ctx, bucket, object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := mtx.Lock(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := mtx.Unlock(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Usage is recorded in the background.
+	var usage gmutex.Usage
+	var ok bool
+	for i := 0; i < 50; i++ {
+		usage, ok, err = gmutex.GetUsage(ctx, bucket, object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok && usage.Acquisitions == before.Acquisitions+2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !ok || usage.Acquisitions != before.Acquisitions+2 || usage.LastHolder != "usage" {
+		t.Errorf("GetUsage() = %+v, %v", usage, ok)
+	}
+}
+
+func TestMutex_SetPatchExtend(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.SetPatchExtend(true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mtx.LockData(ctx, strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	defer mtx.Unlock(ctx)
+	token := mtx.Lease().Token()
+
+	if err := mtx.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := mtx.Lease().Token(); got != token {
+		t.Errorf("Token() = %q, want %q", got, token)
+	}
+
+	var buf strings.Builder
+	if err := mtx.GetData(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "hello" {
+		t.Errorf("GetData() = %q, want %q", got, "hello")
+	}
+}
+
+func TestMutex_UnlockIfHeld(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unlocked, err := mtx.UnlockIfHeld(ctx); err != nil || unlocked {
+		t.Errorf("UnlockIfHeld() = %v, %v, want false", unlocked, err)
+	}
+
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if unlocked, err := mtx.UnlockIfHeld(ctx); err != nil || !unlocked {
+		t.Errorf("UnlockIfHeld() = %v, %v, want true", unlocked, err)
+	}
+
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.ForceUnlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if unlocked, err := mtx.UnlockIfHeld(ctx); err != nil || unlocked {
+		t.Errorf("UnlockIfHeld() = %v, %v, want false", unlocked, err)
+	}
+	if mtx.Lease() != nil {
+		t.Error("UnlockIfHeld() of stale lock didn't release it")
+	}
+}
+
+func TestStandby(t *testing.T) {
+	ctx := context.Background()
+	a, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active := gmutex.NewStandby(a)
+	standby := gmutex.NewStandby(b)
+
+	started := make(chan struct{})
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- active.Run(ctx, func(ctx context.Context) error {
+			close(started)
+			<-stop
+			return nil
+		})
+	}()
+	<-started
+
+	short, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	err = standby.Run(short, func(ctx context.Context) error {
+		t.Error("standby instance ran")
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() = %v, want DeadlineExceeded", err)
+	}
+
+	rec := httptest.NewRecorder()
+	active.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK || !active.Active() || standby.Active() {
+		t.Errorf("ServeHTTP() = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if active.Active() {
+		t.Error("Active() after Run returned")
+	}
+}
+
+func TestMutex_AppendData(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.LockData(ctx, strings.NewReader("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	defer mtx.Unlock(ctx)
+
+	if err := mtx.AppendData(ctx, strings.NewReader("two\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	if err := mtx.GetData(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "one\ntwo\n" {
+		t.Errorf("GetData() = %q, want %q", got, "one\ntwo\n")
+	}
+}
+
+func TestMutex_TryLockJSON_shared(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type state struct{ Step int }
+
+	// Goroutines sharing mtx race for it: one wins,
+	// the others fail without error, and without touching their state.
+	var wg sync.WaitGroup
+	var won sync.Map
+	for i := 1; i <= 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v := state{Step: i}
+			locked, err := mtx.TryLockJSON(ctx, &v)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if locked {
+				won.Store(i, true)
+			} else if v.Step != i {
+				t.Errorf("TryLockJSON() = %+v, want step %d", v, i)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var winner int
+	won.Range(func(k, _ any) bool {
+		if winner != 0 {
+			t.Errorf("TryLockJSON() succeeded for %d and %d", winner, k)
+		}
+		winner = k.(int)
+		return true
+	})
+	if winner == 0 {
+		t.Fatal("TryLockJSON() never succeeded")
+	}
+	defer mtx.Unlock(ctx)
+
+	// A different Mutex asks the server, and fetches the holder's state.
+	other, err := gmutex.NewOf[state](ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked, held, err := other.TryLock(ctx, state{Step: 42})
+	if locked || err != nil {
+		t.Fatalf("TryLock() = %v, %v, want false", locked, err)
+	}
+	if held.Step != winner {
+		t.Errorf("TryLock() = %+v, want step %d", held, winner)
+	}
+}
+
+func TestMutex_Lock_shared(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Goroutines sharing mtx take turns holding it.
+	var running, count atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := mtx.Lock(ctx); err != nil {
+				t.Error(err)
+				return
+			}
+			if running.Add(1) != 1 {
+				t.Error("Lock() held by two goroutines")
+			}
+			count.Add(1)
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			if err := mtx.Unlock(ctx); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := count.Load(); n != 8 {
+		t.Errorf("Lock() succeeded %d times, want 8", n)
+	}
+}
+
+func TestOf_TryLock_shared(t *testing.T) {
+	ctx := context.Background()
+	type state struct{ Step int }
+
+	mtx, err := gmutex.NewOf[state](ctx, bucket, object, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Lock(ctx, state{Step: 1}); err != nil {
+		t.Fatal(err)
+	}
+	defer mtx.Unlock(ctx)
+
+	// Another goroutine sharing mtx fails without asking the server.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		locked, held, err := mtx.TryLock(ctx, state{Step: 2})
+		if locked || err != nil {
+			t.Errorf("TryLock() = %v, %v, want false", locked, err)
+		}
+		if held != (state{}) {
+			t.Errorf("TryLock() = %+v, want zero state", held)
+		}
+	}()
+	<-done
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	mtx, err := gmutex.New(ctx, bucket, "list/lock", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mtx.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer mtx.Unlock(ctx)
+
+	// Objects that support the lock.
+	for _, name := range []string{"list/lock.usage", "list/lock.last", "list/lock.queue/1"} {
+		b, err := gmutex.NewBackend(ctx, bucket, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := b.Create(ctx, "", gmutex.Attrs{}, strings.NewReader("{}")); err != nil {
+			t.Fatal(err)
+		}
+		defer b.Delete(ctx, "")
+	}
+
+	locks, err := gmutex.List(ctx, bucket, "list/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locks) != 1 || locks[0].Object != "list/lock" || !locks[0].Held {
+		t.Errorf("List() = %+v", locks)
+	}
+
+	objects, err := gmutex.ListObjects(ctx, bucket, "list/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 4 {
+		t.Errorf("ListObjects() = %+v", objects)
+	}
+}
+
+func TestMutex_SetFair(t *testing.T) {
+	ctx := context.Background()
+	const name = "fair/lock"
+
+	newFair := func() *gmutex.Mutex {
+		mtx, err := gmutex.New(ctx, bucket, name, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := mtx.SetFair(true); err != nil {
+			t.Fatal(err)
+		}
+		return mtx
+	}
+
+	holder := newFair()
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Waiters get in line one at a time, so their order is known.
+	var mtx sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 1; i <= 3; i++ {
+		waiter := newFair()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := waiter.Lock(ctx); err != nil {
+				t.Error(err)
+				return
+			}
+			mtx.Lock()
+			order = append(order, i)
+			mtx.Unlock()
+			if err := waiter.Unlock(ctx); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		waitInLine(t, name, i)
+	}
+
+	// TryLock doesn't jump the line.
+	late := newFair()
+	if locked, err := late.TryLock(ctx); err != nil || locked {
+		t.Fatalf("TryLock() = %v, %v, want false", locked, err)
+	}
+
+	if err := holder.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Errorf("acquired in order %v, want [1 2 3]", order)
+	}
+
+	// Once the line is empty, TryLock succeeds.
+	waitInLine(t, name, 0)
+	if locked, err := late.TryLock(ctx); err != nil || !locked {
+		t.Fatalf("TryLock() = %v, %v, want true", locked, err)
+	}
+	if err := late.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitInLine waits until there are n tickets in line for the lock object.
+func waitInLine(t *testing.T, object string, n int) {
+	t.Helper()
+	ctx := context.Background()
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(10 * time.Millisecond) {
+		tickets, err := gmutex.ListObjects(ctx, bucket, object+".queue/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tickets) == n {
+			return
+		}
+	}
+	t.Fatalf("waiting for %d tickets in line", n)
+}
@@ -0,0 +1,58 @@
+// Package gmutexprom exports gmutex statistics to Prometheus.
+package gmutexprom
+
+import (
+	"github.com/ncruces/go-gcp/gmutex"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	acquisitions = prometheus.NewDesc("gmutex_acquisitions_total",
+		"Locks acquired.", []string{"lock"}, nil)
+	contentions = prometheus.NewDesc("gmutex_contentions_total",
+		"Lock attempts that found the lock in use.", []string{"lock"}, nil)
+	waitTime = prometheus.NewDesc("gmutex_wait_seconds_total",
+		"Time spent acquiring locks.", []string{"lock"}, nil)
+	retries = prometheus.NewDesc("gmutex_retries_total",
+		"Requests retried after transient errors.", []string{"lock"}, nil)
+	extendFailures = prometheus.NewDesc("gmutex_extend_failures_total",
+		"Extends and updates that failed.", []string{"lock"}, nil)
+	lost = prometheus.NewDesc("gmutex_lost_total",
+		"Locks found to be stale: expired or stolen.", []string{"lock"}, nil)
+)
+
+// A Collector collects the statistics of a set of Mutexes,
+// labeled by their lock objects.
+type Collector struct {
+	mutexes []*gmutex.Mutex
+}
+
+// NewCollector creates a Collector for the given Mutexes.
+// Register it with prometheus.Register.
+func NewCollector(mutexes ...*gmutex.Mutex) *Collector {
+	return &Collector{mutexes: mutexes}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- acquisitions
+	ch <- contentions
+	ch <- waitTime
+	ch <- retries
+	ch <- extendFailures
+	ch <- lost
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.mutexes {
+		s := m.Stats()
+		l := m.String()
+		ch <- prometheus.MustNewConstMetric(acquisitions, prometheus.CounterValue, float64(s.Acquisitions), l)
+		ch <- prometheus.MustNewConstMetric(contentions, prometheus.CounterValue, float64(s.Contentions), l)
+		ch <- prometheus.MustNewConstMetric(waitTime, prometheus.CounterValue, s.WaitTime.Seconds(), l)
+		ch <- prometheus.MustNewConstMetric(retries, prometheus.CounterValue, float64(s.Retries), l)
+		ch <- prometheus.MustNewConstMetric(extendFailures, prometheus.CounterValue, float64(s.ExtendFailures), l)
+		ch <- prometheus.MustNewConstMetric(lost, prometheus.CounterValue, float64(s.Lost), l)
+	}
+}
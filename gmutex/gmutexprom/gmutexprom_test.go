@@ -0,0 +1,96 @@
+package gmutexprom
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex"
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	server := gmutextest.Start(t, "bucket")
+	ctx := context.Background()
+
+	a, err := gmutex.New(ctx, "bucket", "a", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := gmutex.New(ctx, "bucket", "b", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := gmutex.New(ctx, "bucket", "b", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thief, err := gmutex.New(ctx, "bucket", "b", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a is acquired, extended, and released.
+	if err := a.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// b is contended, acquired once the other holder expires,
+	// then lost when it expires in turn.
+	if err := other.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if locked, err := b.TryLock(ctx); err != nil || locked {
+		t.Fatalf("TryLock() = %v, %v", locked, err)
+	}
+	server.Advance(2 * time.Minute)
+	if err := b.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	server.Advance(2 * time.Minute)
+	if err := thief.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Extend(ctx); err == nil {
+		t.Fatal("Extend() of a stolen lock succeeded")
+	}
+
+	c := NewCollector(a, b)
+	if n := testutil.CollectAndCount(c); n != 12 {
+		t.Errorf("CollectAndCount() = %d, want 12", n)
+	}
+
+	la, lb := a.String(), b.String()
+	want := `
+# HELP gmutex_acquisitions_total Locks acquired.
+# TYPE gmutex_acquisitions_total counter
+gmutex_acquisitions_total{lock="` + la + `"} 1
+gmutex_acquisitions_total{lock="` + lb + `"} 1
+# HELP gmutex_contentions_total Lock attempts that found the lock in use.
+# TYPE gmutex_contentions_total counter
+gmutex_contentions_total{lock="` + la + `"} 0
+gmutex_contentions_total{lock="` + lb + `"} 1
+# HELP gmutex_extend_failures_total Extends and updates that failed.
+# TYPE gmutex_extend_failures_total counter
+gmutex_extend_failures_total{lock="` + la + `"} 0
+gmutex_extend_failures_total{lock="` + lb + `"} 1
+# HELP gmutex_lost_total Locks found to be stale: expired or stolen.
+# TYPE gmutex_lost_total counter
+gmutex_lost_total{lock="` + la + `"} 0
+gmutex_lost_total{lock="` + lb + `"} 1
+`
+	err = testutil.CollectAndCompare(c, strings.NewReader(want),
+		"gmutex_acquisitions_total", "gmutex_contentions_total",
+		"gmutex_extend_failures_total", "gmutex_lost_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
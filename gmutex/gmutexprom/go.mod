@@ -0,0 +1,31 @@
+module github.com/ncruces/go-gcp/gmutex/gmutexprom
+
+go 1.22.7
+
+require (
+	github.com/ncruces/go-gcp v0.0.0
+	github.com/prometheus/client_golang v1.20.5
+)
+
+require (
+	cloud.google.com/go/compute/metadata v0.6.0 // indirect
+	cloud.google.com/go/functions v1.19.2 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/golang/groupcache v0.0.0-20241129210726-2c02b8208cf8 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	go.opencensus.io v0.24.0 // indirect
+	go.opentelemetry.io/otel v1.33.0 // indirect
+	go.opentelemetry.io/otel/trace v1.33.0 // indirect
+	golang.org/x/oauth2 v0.24.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	google.golang.org/protobuf v1.36.0 // indirect
+)
+
+replace github.com/ncruces/go-gcp => ../../
+
+replace github.com/aws/aws-sdk-go => github.com/ncruces/go-gcp/aws-sdk-shim v1.0.0
@@ -0,0 +1,364 @@
+// Package gmutextest implements a fake Google Cloud Storage server,
+// for testing code that uses gmutex without a real bucket.
+//
+// The fake implements the subset of the Cloud Storage XML API
+// used by gmutex (generations, preconditions, compose, metadata),
+// as well as object listing, metadata patching, and permission testing,
+// through the JSON API.
+package gmutextest
+
+import (
+	cryptomd5 "crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex"
+)
+
+// A Server is a fake Cloud Storage server.
+//
+// To use it with gmutex, call Start from a test;
+// or set the environment variable STORAGE_EMULATOR_HOST
+// to the server's URL, and gmutex.HTTPClient to its Client,
+// before creating Mutexes.
+type Server struct {
+	*httptest.Server
+
+	mtx     sync.Mutex
+	buckets map[string]map[string]*object
+	gen     int64
+	skew    time.Duration
+}
+
+type object struct {
+	composite      bool
+	data           []byte
+	metadata       map[string]string
+	generation     int64
+	metageneration int64
+	modified       time.Time
+}
+
+// NewServer starts a fake Cloud Storage server with the given buckets.
+// The caller should call Close when finished, to shut it down.
+func NewServer(buckets ...string) *Server {
+	s := &Server{buckets: map[string]map[string]*object{}}
+	for _, b := range buckets {
+		s.buckets[b] = map[string]*object{}
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Start starts a fake Cloud Storage server with the given buckets,
+// and points gmutex to it, until the test ends:
+// it sets STORAGE_EMULATOR_HOST, and gmutex.HTTPClient,
+// and restores them, and shuts down the server, on cleanup.
+// Tests using Start can't run in parallel.
+func Start(t testing.TB, buckets ...string) *Server {
+	s := NewServer(buckets...)
+	client := gmutex.HTTPClient
+	t.Cleanup(func() {
+		gmutex.HTTPClient = client
+		s.Close()
+	})
+	t.Setenv("STORAGE_EMULATOR_HOST", s.URL)
+	gmutex.HTTPClient = s.Client()
+	return s
+}
+
+// CreateBucket creates an empty bucket, if it doesn't exist.
+func (s *Server) CreateBucket(name string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.buckets[name] == nil {
+		s.buckets[name] = map[string]*object{}
+	}
+}
+
+// Advance advances the server's clock,
+// so lock objects expire without waiting.
+func (s *Server) Advance(d time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.skew += d
+}
+
+// Object returns the data, and metadata, of an object.
+// Returns false if the object doesn't exist.
+func (s *Server) Object(bucket, name string) (data []byte, metadata map[string]string, ok bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	o := s.buckets[bucket][name]
+	if o == nil {
+		return nil, nil, false
+	}
+	metadata = map[string]string{}
+	for k, v := range o.metadata {
+		metadata[k] = v
+	}
+	return append([]byte(nil), o.data...), metadata, true
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.skew).Truncate(time.Second)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	w.Header().Set("Date", s.now().UTC().Format(http.TimeFormat))
+
+	if path, ok := strings.CutPrefix(r.URL.Path, "/storage/v1/b/"); ok {
+		s.serveJSON(w, r, path)
+	} else {
+		s.serveXML(w, r, strings.TrimPrefix(r.URL.Path, "/"))
+	}
+}
+
+func (s *Server) serveXML(w http.ResponseWriter, r *http.Request, path string) {
+	bucket, name, _ := strings.Cut(path, "/")
+	objects := s.buckets[bucket]
+	if objects == nil {
+		xmlError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if name == "" {
+		xmlError(w, http.StatusNotImplemented, "NotImplemented")
+		return
+	}
+
+	o := objects[name]
+	match := r.Header.Get("x-goog-if-generation-match")
+	if gen := r.URL.Query().Get("generation"); gen != "" && !matches(o, gen) {
+		// Versioning is not supported, only the live generation exists.
+		o = nil
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if o == nil {
+			xmlError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		writeHeaders(w, o)
+		w.Header().Set("Content-Length", strconv.Itoa(len(o.data)))
+		if r.Method == http.MethodGet {
+			w.Write(o.data)
+		}
+
+	case http.MethodDelete:
+		if o == nil {
+			xmlError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		if !matches(o, match) {
+			xmlError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		delete(objects, name)
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodPut:
+		if !matches(o, match) {
+			xmlError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+
+		var data []byte
+		_, compose := r.URL.Query()["compose"]
+		if compose {
+			var req struct {
+				Components []struct {
+					Name string `xml:"Name"`
+				} `xml:"Component"`
+			}
+			if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+				xmlError(w, http.StatusBadRequest, "MalformedXML")
+				return
+			}
+			for _, c := range req.Components {
+				src := objects[c.Name]
+				if src == nil {
+					xmlError(w, http.StatusNotFound, "NoSuchKey")
+					return
+				}
+				data = append(data, src.data...)
+			}
+		} else {
+			var err error
+			data, err = io.ReadAll(r.Body)
+			if err != nil {
+				xmlError(w, http.StatusBadRequest, "IncompleteBody")
+				return
+			}
+			for _, h := range strings.Split(r.Header.Get("x-goog-hash"), ",") {
+				if h := strings.TrimSpace(h); h != "" && !strings.Contains(hashes(data, true), h) {
+					xmlError(w, http.StatusBadRequest, "BadDigest")
+					return
+				}
+			}
+		}
+
+		s.gen++
+		o = &object{
+			composite:      compose,
+			data:           data,
+			metadata:       map[string]string{},
+			generation:     s.gen,
+			metageneration: 1,
+			modified:       s.now(),
+		}
+		for k, v := range r.Header {
+			if k, ok := strings.CutPrefix(strings.ToLower(k), "x-goog-meta-"); ok {
+				o.metadata[k] = v[0]
+			}
+		}
+		objects[name] = o
+		writeHeaders(w, o)
+
+	default:
+		xmlError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s *Server) serveJSON(w http.ResponseWriter, r *http.Request, path string) {
+	bucket, path, _ := strings.Cut(path, "/")
+	objects := s.buckets[bucket]
+	if objects == nil {
+		jsonError(w, http.StatusNotFound, "The specified bucket does not exist.")
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		writeJSON(w, map[string]any{"name": bucket, "versioning": map[string]bool{"enabled": false}})
+
+	case path == "iam/testPermissions" && r.Method == http.MethodGet:
+		writeJSON(w, map[string]any{"permissions": query["permissions"]})
+
+	case path == "o" && r.Method == http.MethodGet:
+		var items []any
+		for name, o := range objects {
+			if strings.HasPrefix(name, query.Get("prefix")) {
+				items = append(items, resource(name, o))
+			}
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].(map[string]any)["name"].(string) < items[j].(map[string]any)["name"].(string)
+		})
+		writeJSON(w, map[string]any{"items": items})
+
+	case strings.HasPrefix(path, "o/") && r.Method == http.MethodPatch:
+		name := strings.TrimPrefix(path, "o/")
+		o := objects[name]
+		if o == nil {
+			jsonError(w, http.StatusNotFound, "No such object.")
+			return
+		}
+		if !matches(o, query.Get("ifGenerationMatch")) {
+			jsonError(w, http.StatusPreconditionFailed, "Precondition failed.")
+			return
+		}
+
+		var req struct {
+			Metadata map[string]*string `json:"metadata"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid JSON.")
+			return
+		}
+		for k, v := range req.Metadata {
+			if v == nil {
+				delete(o.metadata, k)
+			} else {
+				o.metadata[k] = *v
+			}
+		}
+		o.metageneration++
+		o.modified = s.now()
+		writeJSON(w, resource(name, o))
+
+	default:
+		jsonError(w, http.StatusNotImplemented, "Not implemented.")
+	}
+}
+
+func matches(o *object, generation string) bool {
+	switch generation {
+	case "":
+		return true
+	case "0":
+		return o == nil
+	default:
+		return o != nil && generation == strconv.FormatInt(o.generation, 10)
+	}
+}
+
+func resource(name string, o *object) map[string]any {
+	return map[string]any{
+		"name":           name,
+		"generation":     strconv.FormatInt(o.generation, 10),
+		"metageneration": strconv.FormatInt(o.metageneration, 10),
+		"size":           strconv.Itoa(len(o.data)),
+		"updated":        o.modified.UTC().Format(time.RFC3339Nano),
+		"metadata":       o.metadata,
+	}
+}
+
+func writeHeaders(w http.ResponseWriter, o *object) {
+	h := w.Header()
+	h.Set("x-goog-generation", strconv.FormatInt(o.generation, 10))
+	h.Set("x-goog-metageneration", strconv.FormatInt(o.metageneration, 10))
+	h.Set("Last-Modified", o.modified.UTC().Format(http.TimeFormat))
+	h.Set("x-goog-hash", hashes(o.data, !o.composite))
+	for k, v := range o.metadata {
+		h.Set("x-goog-meta-"+k, v)
+	}
+}
+
+// hashes returns the x-goog-hash of data.
+// Composite objects have no MD5 hash.
+func hashes(data []byte, md5 bool) string {
+	var crc [4]byte
+	binary.BigEndian.PutUint32(crc[:], crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)))
+	h := "crc32c=" + base64.StdEncoding.EncodeToString(crc[:])
+	if md5 {
+		sum := cryptomd5.Sum(data)
+		h += ",md5=" + base64.StdEncoding.EncodeToString(sum[:])
+	}
+	return h
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func jsonError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"code": status, "message": message},
+	})
+}
+
+func xmlError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte("<?xml version='1.0' encoding='UTF-8'?><Error><Code>" + code + "</Code></Error>"))
+}
@@ -0,0 +1,85 @@
+package gmutextest_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex"
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+)
+
+func TestServer(t *testing.T) {
+	server := gmutextest.Start(t, "bucket")
+
+	ctx := context.Background()
+	a, err := gmutex.New(ctx, "bucket", "object", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := gmutex.New(ctx, "bucket", "object", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := server.Object("bucket", "object"); !ok {
+		t.Error("lock object not found")
+	}
+	if locked, err := b.TryLock(ctx); err != nil || locked {
+		t.Fatalf("TryLock() = %v, %v, want false", locked, err)
+	}
+
+	server.Advance(2 * time.Minute)
+	if locked, err := b.TryLock(ctx); err != nil || !locked {
+		t.Fatalf("TryLock() = %v, %v, want true", locked, err)
+	}
+	if err := a.Extend(ctx); err == nil {
+		t.Error("Extend() of stolen lock succeeded")
+	}
+	if err := b.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := server.Object("bucket", "object"); ok {
+		t.Error("lock object not deleted")
+	}
+}
+
+func TestServer_missingBucket(t *testing.T) {
+	gmutextest.Start(t)
+
+	ctx := context.Background()
+	m, err := gmutex.New(ctx, "bucket", "object", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Ping(ctx); !errors.Is(err, gmutex.ErrBucketNotFound) {
+		t.Errorf("Ping() = %v, want ErrBucketNotFound", err)
+	}
+}
+
+func TestStart(t *testing.T) {
+	client := gmutex.HTTPClient
+
+	var server *gmutextest.Server
+	t.Run("test", func(t *testing.T) {
+		server = gmutextest.Start(t, "bucket")
+		if gmutex.HTTPClient != server.Client() {
+			t.Error("gmutex.HTTPClient not set")
+		}
+		if got := os.Getenv("STORAGE_EMULATOR_HOST"); got != server.URL {
+			t.Errorf("STORAGE_EMULATOR_HOST = %q, want %q", got, server.URL)
+		}
+	})
+
+	if gmutex.HTTPClient != client {
+		t.Error("gmutex.HTTPClient not restored")
+	}
+	if got := os.Getenv("STORAGE_EMULATOR_HOST"); got == server.URL {
+		t.Error("STORAGE_EMULATOR_HOST not restored")
+	}
+}
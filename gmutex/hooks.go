@@ -0,0 +1,110 @@
+package gmutex
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Hooks are called on Mutex lifecycle events,
+// and can be used to instrument lock behavior.
+//
+// Any function field may be nil.
+// Hooks are called synchronously, and should return quickly.
+// Some hooks are called while m is busy,
+// so hooks must not call methods of m that operate on the lock.
+type Hooks struct {
+	// LockStart is called when acquiring the lock begins.
+	LockStart func(m *Mutex)
+
+	// Locked is called when the lock is acquired,
+	// with the time spent acquiring it,
+	// including any time spent waiting for it to be released.
+	Locked func(m *Mutex, wait time.Duration)
+
+	// Contended is called when the lock is found in use.
+	Contended func(m *Mutex)
+
+	// Retry is called before backing off after a transient error.
+	Retry func(m *Mutex, op string, err error)
+
+	// Extended is called when the lock is extended, or its data updated.
+	Extended func(m *Mutex)
+
+	// Unlocked is called when the lock is released,
+	// with the time it was held.
+	Unlocked func(m *Mutex, held time.Duration)
+
+	// Lost is called when the lock is found to be stale
+	// (it expired, or was stolen, and mutual exclusion was not ensured),
+	// or when its Lease expires without being extended,
+	// as soon as that happens, so side effects can be halted.
+	// It's called at most once per lock, possibly from another goroutine,
+	// and the Lease is done by the time it's called.
+	Lost func(m *Mutex, err error)
+}
+
+// SetHooks sets the Hooks called on lifecycle events of m.
+func (m *Mutex) SetHooks(h *Hooks) {
+	m.hooks = h
+}
+
+func (m *Mutex) hookLockStart() time.Time {
+	if h := m.hooks; h != nil && h.LockStart != nil {
+		h.LockStart(m)
+	}
+	return time.Now()
+}
+
+func (m *Mutex) hookLocked(start time.Time) {
+	wait := time.Since(start)
+	m.stats.acquisitions.Add(1)
+	m.stats.waitTime.Add(int64(wait))
+	if h := m.hooks; h != nil && h.Locked != nil {
+		h.Locked(m, wait)
+	}
+}
+
+func (m *Mutex) hookContended() {
+	m.stats.contentions.Add(1)
+	if h := m.hooks; h != nil && h.Contended != nil {
+		h.Contended(m)
+	}
+}
+
+func (m *Mutex) hookRetry(op string, status int, err error) {
+	m.stats.retries.Add(1)
+	if h := m.hooks; h != nil && h.Retry != nil {
+		if err == nil {
+			err = fmt.Errorf("http status %d: %s", status, http.StatusText(status))
+		}
+		h.Retry(m, op, err)
+	}
+}
+
+func (m *Mutex) hookExtendFailed(err error) error {
+	if err != nil {
+		m.stats.extendFailures.Add(1)
+	}
+	return err
+}
+
+func (m *Mutex) hookExtended() {
+	if h := m.hooks; h != nil && h.Extended != nil {
+		h.Extended(m)
+	}
+}
+
+func (m *Mutex) hookUnlocked(held time.Duration) {
+	if h := m.hooks; h != nil && h.Unlocked != nil {
+		h.Unlocked(m, held)
+	}
+}
+
+func (m *Mutex) hookLost(err error) error {
+	m.stats.lost.Add(1)
+	if h := m.hooks; h != nil && h.Lost != nil {
+		h.Lost(m, err)
+	}
+	return err
+}
@@ -4,23 +4,66 @@ package gmutex
 import (
 	"context"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 	"sync"
 
 	"golang.org/x/oauth2/google"
 )
 
-// HTTPClient should be set to an http.Client before first use.
-// If unset google.DefaultClient will be used.
+// HTTPClient should be set to an http.Client before first use,
+// authorized for the features in use.
+// If unset google.DefaultClient will be used,
+// requesting only the scopes of the features in use:
+// Cloud Storage for locks, Pub/Sub for notifications (see NewNotifier),
+// and Datastore for Firestore locks.
 var HTTPClient *http.Client
 
-var initMtx sync.Mutex
+const (
+	storageScope   = "https://www.googleapis.com/auth/devstorage.read_write"
+	pubsubScope    = "https://www.googleapis.com/auth/pubsub"
+	datastoreScope = "https://www.googleapis.com/auth/datastore"
+)
+
+var (
+	initMtx        sync.Mutex
+	defaultClients = map[string]*http.Client{}
+)
 
-func initClient(ctx context.Context) (err error) {
+// initClient initializes the default client for scope,
+// unless HTTPClient is set.
+func initClient(ctx context.Context, scope string) (err error) {
 	initMtx.Lock()
 	defer initMtx.Unlock()
-	if HTTPClient == nil {
-		const scope = "https://www.googleapis.com/auth/devstorage.read_write"
-		HTTPClient, err = google.DefaultClient(ctx, scope)
+	if HTTPClient == nil && defaultClients[scope] == nil {
+		defaultClients[scope], err = google.DefaultClient(ctx, scope)
 	}
 	return err
 }
+
+// httpClient returns HTTPClient, if set,
+// or the default client for scope.
+func httpClient(scope string) *http.Client {
+	initMtx.Lock()
+	defer initMtx.Unlock()
+	if HTTPClient != nil {
+		return HTTPClient
+	}
+	if c := defaultClients[scope]; c != nil {
+		return c
+	}
+	return http.DefaultClient
+}
+
+// endpoint returns the base URL for a service,
+// or its emulator if the environment variable is set.
+func endpoint(env, host string) (*url.URL, error) {
+	if emulator := os.Getenv(env); emulator == "" {
+		return &url.URL{Scheme: "https", Host: host}, nil
+	} else if strings.Contains(emulator, "://") {
+		return url.Parse(emulator)
+	} else {
+		return &url.URL{Scheme: "http", Host: emulator}, nil
+	}
+}
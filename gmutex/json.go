@@ -20,7 +20,8 @@ func (m *Mutex) LockJSON(ctx context.Context, v any) error {
 
 // TryLockJSON calls TryLockData with the JSON encoding of v.
 // Parses JSON-encoded data into the value pointed to by v,
-// if the lock is already in use and v is a pointer.
+// if the lock is already in use, v is a pointer,
+// and the holder's data was fetched.
 func (m *Mutex) TryLockJSON(ctx context.Context, v any) (bool, error) {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -32,8 +33,8 @@ func (m *Mutex) TryLockJSON(ctx context.Context, v any) (bool, error) {
 	}
 
 	buf := bytes.NewBuffer(b)
-	locked, err := m.TryLockData(ctx, buf)
-	if locked || err != nil {
+	locked, fetched, err := m.tryLockData(ctx, buf)
+	if locked || !fetched || err != nil {
 		return locked, err
 	}
 	return false, json.Unmarshal(buf.Bytes(), v)
@@ -57,6 +58,17 @@ func (m *Mutex) AdoptJSON(ctx context.Context, id string, v any) error {
 	return m.AdoptData(ctx, id, bytes.NewReader(b))
 }
 
+// GetJSON calls GetData.
+// Parses JSON-encoded data into the value pointed to by v.
+func (m *Mutex) GetJSON(ctx context.Context, v any) error {
+	var buf bytes.Buffer
+	err := m.GetData(ctx, &buf)
+	if err == nil {
+		err = json.Unmarshal(buf.Bytes(), v)
+	}
+	return err
+}
+
 // InspectJSON calls InspectData.
 // Parses JSON-encoded data into the value pointed to by v.
 func (m *Mutex) InspectJSON(ctx context.Context, v any) (bool, error) {
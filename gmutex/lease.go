@@ -0,0 +1,179 @@
+package gmutex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Errors reported by Lease.Err.
+var (
+	ErrLeaseExpired  = errors.New("gmutex: lease expired")
+	ErrLeaseReleased = errors.New("gmutex: lease released")
+)
+
+// A Lease represents a lock held by a Mutex.
+//
+// A Lease is done when the lock is released (or abandoned),
+// when it expires without being extended,
+// or when it is found to be stale.
+// Long-running critical sections should watch Done,
+// and abort promptly when mutual exclusion is no longer ensured.
+//
+// Expiration is estimated using the local clock,
+// conservatively from the time requests to acquire,
+// or extend, the lock were sent.
+type Lease struct {
+	m     *Mutex
+	token string
+	done  chan struct{}
+
+	mtx      sync.Mutex
+	err      error
+	timer    *time.Timer
+	deadline time.Time
+}
+
+// LockLease locks m, like Lock, and returns the Lease for the lock.
+func (m *Mutex) LockLease(ctx context.Context) (*Lease, error) {
+	if err := m.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return m.Lease(), nil
+}
+
+// Lease returns the Lease for the lock held by m,
+// or nil if m is unlocked.
+func (m *Mutex) Lease() *Lease {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.lease
+}
+
+// Token returns a fencing token for the lease:
+// the generation of the lock object when the lock was acquired.
+// For Mutexes backed by Cloud Storage, tokens are integers
+// that increase monotonically with every acquisition of the lock.
+func (l *Lease) Token() string {
+	return l.token
+}
+
+// Done returns a channel that's closed when the lease is done.
+func (l *Lease) Done() <-chan struct{} {
+	return l.done
+}
+
+// Err returns nil if the lease is not yet done,
+// otherwise an error explaining why.
+func (l *Lease) Err() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.err
+}
+
+// Deadline returns the estimated time when the lease expires,
+// or the zero time if it never does.
+func (l *Lease) Deadline() time.Time {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.deadline
+}
+
+// Release unlocks the Mutex that holds the lease.
+func (l *Lease) Release(ctx context.Context) error {
+	if l.m.Lease() != l {
+		return l.Err()
+	}
+	return l.m.Unlock(ctx)
+}
+
+func (m *Mutex) acquired(gen string, sent time.Time) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.generation = gen
+	m.lease = newLease(m, gen)
+	m.lease.renew(sent, m.TTL())
+	m.locked = time.Now()
+	m.mutated = sent
+}
+
+// renewed must be called with m.mtx held.
+func (m *Mutex) renewed(gen string, sent time.Time) {
+	m.generation = gen
+	m.lease.renew(sent, m.TTL())
+	m.mutated = sent
+}
+
+// released must be called with m.mtx held.
+// It lets other goroutines lock m.
+func (m *Mutex) released() {
+	if m.generation == "" {
+		return
+	}
+	m.generation = ""
+	m.lease.end(ErrLeaseReleased)
+	m.lease = nil
+	<-m.sem
+}
+
+// lost ends the lease, reporting the loss once per lease.
+func (m *Mutex) lost(err error) error {
+	if m.lease.end(err) {
+		m.hookLost(err)
+	}
+	return err
+}
+
+func newLease(m *Mutex, token string) *Lease {
+	return &Lease{
+		m:     m,
+		token: token,
+		done:  make(chan struct{}),
+	}
+}
+
+// renew restarts the expiration timer, from the time the request was sent.
+func (l *Lease) renew(sent time.Time, ttl time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.err != nil {
+		return
+	}
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	if ttl <= 0 {
+		l.deadline = time.Time{}
+		return
+	}
+	l.deadline = sent.Add(ttl)
+	l.timer = time.AfterFunc(time.Until(l.deadline), func() {
+		if l.end(ErrLeaseExpired) {
+			l.m.hookLost(ErrLeaseExpired)
+		}
+	})
+}
+
+// end ends the lease, with the given error.
+// Returns false if the lease had already ended.
+func (l *Lease) end(err error) bool {
+	if l == nil {
+		return false
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.err != nil {
+		return false
+	}
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	l.err = err
+	close(l.done)
+	return true
+}
@@ -0,0 +1,225 @@
+package gmutex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Info describes a lock object.
+type Info struct {
+	Object     string            // The lock object's name.
+	Holder     string            // The process holding the lock, if known.
+	Metadata   map[string]string // Custom key/values attached to the lock object.
+	Generation string            // The lock object's generation.
+	TTL        time.Duration     // The lock's time-to-live, zero if it never expires.
+	Age        time.Duration     // The time since the lock object was last written.
+	Expiration time.Time         // The time the lock expires, zero if it never does.
+	Held       bool              // Whether the lock is held (not expired).
+}
+
+func (a Attrs) info(object string) Info {
+	return Info{
+		Object:     object,
+		Holder:     a.Holder,
+		Metadata:   a.Metadata,
+		Generation: a.Generation,
+		TTL:        a.TTL,
+		Age:        a.Date.Sub(a.Modified),
+		Expiration: a.expiration(),
+		Held:       !a.expired(),
+	}
+}
+
+// Inspect describes the lock object of m, without locking it.
+// If the lock object doesn't exist, the returned Info isn't held,
+// and only has its Object set.
+// Expiration and age are determined using server time.
+func (m *Mutex) Inspect(ctx context.Context) (Info, error) {
+	object := m.String()
+	if o, ok := m.backend.(*gcsObject); ok {
+		object = o.object
+	}
+
+	var backoff expBackOff // Exponential backoff because we don't hold the lock.
+
+	for {
+		// Inspect the lock object, even if expired.
+		status, attrs, err := m.inspectInfo(ctx, backoff.attempt())
+		if status == http.StatusOK {
+			return attrs.info(object), nil
+		}
+		if status == http.StatusNotFound {
+			return Info{Object: object}, nil
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("inspect", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return Info{}, err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return Info{}, fmt.Errorf("inspect mutex: %w", err)
+		}
+		return Info{}, fmt.Errorf("inspect mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+func (m *Mutex) inspectInfo(ctx context.Context, try attempt) (status int, attrs Attrs, err error) {
+	ctx, span := m.startSpan(ctx, "inspect", try)
+	defer func() { m.endSpan(ctx, span, "inspect", try, status, err) }()
+	return m.backend.Inspect(ctx, nil)
+}
+
+// List lists the lock objects in a Cloud Storage bucket
+// whose names start with prefix.
+//
+// Expired lock objects are listed, but not held.
+// Expiration and age are determined using server time.
+// Objects that support locks (like the usage records of SetUsageTracking,
+// the last runs of RunExclusive, and the queues of SetFair) aren't listed.
+func List(ctx context.Context, bucket, prefix string) ([]Info, error) {
+	list, err := ListObjects(ctx, bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	locks := list[:0]
+	for _, info := range list {
+		if !helperObject(info.Object) {
+			locks = append(locks, info)
+		}
+	}
+	return locks, nil
+}
+
+// ListObjects lists every object in a Cloud Storage bucket
+// whose names start with prefix, like List,
+// but including the objects that support locks,
+// so it can be used to list objects stored through a Backend.
+func ListObjects(ctx context.Context, bucket, prefix string) ([]Info, error) {
+	if err := initClient(ctx, storageScope); err != nil {
+		return nil, err
+	}
+
+	baseUrl, err := defaultEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	o := gcsObject{bucket: bucket, baseUrl: baseUrl}
+	var backoff expBackOff // Exponential backoff for transient errors.
+
+	var res []Info
+	var token string
+	for {
+		status, list, next, err := o.listObjects(ctx, prefix, token)
+		if status == http.StatusOK {
+			backoff = expBackOff{}
+			res = append(res, list...)
+			if next == "" {
+				return res, nil
+			}
+			token = next
+			continue
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			if err := backoff.wait(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return nil, fmt.Errorf("list mutexes: %w", err)
+		}
+		return nil, fmt.Errorf("list mutexes: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+// listObjects lists objects in the bucket of o whose names start with prefix.
+func (o *gcsObject) listObjects(ctx context.Context, prefix, token string) (int, []Info, string, error) {
+	query := url.Values{}
+	query.Set("prefix", prefix)
+	query.Set("fields", "items(name,generation,updated,timeDeleted,metadata),nextPageToken")
+	if token != "" {
+		query.Set("pageToken", token)
+	}
+	url := url.URL{
+		Scheme:   o.baseUrl.Scheme,
+		Host:     o.baseUrl.Host,
+		Path:     "/storage/v1/b/" + o.bucket + "/o",
+		RawPath:  "/storage/v1/b/" + url.PathEscape(o.bucket) + "/o",
+		RawQuery: query.Encode(),
+	}
+
+	// List the lock objects through the JSON API.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	res, err := o.do(req)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return res.StatusCode, nil, "", nil
+	}
+
+	var body struct {
+		NextPageToken string `json:"nextPageToken"`
+		Items         []struct {
+			Name       string            `json:"name"`
+			Generation string            `json:"generation"`
+			Updated    time.Time         `json:"updated"`
+			Deleted    time.Time         `json:"timeDeleted"`
+			Metadata   map[string]string `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return 0, nil, "", err
+	}
+
+	date, _ := http.ParseTime(res.Header.Get("Date"))
+	list := make([]Info, 0, len(body.Items))
+	for _, item := range body.Items {
+		if !item.Deleted.IsZero() {
+			// Noncurrent versions aren't locks.
+			continue
+		}
+		attrs := Attrs{
+			Holder:     item.Metadata["holder"],
+			Metadata:   customMetadata(item.Metadata),
+			Generation: item.Generation,
+			Modified:   item.Updated,
+			Date:       date,
+		}
+		if ttl, err := strconv.ParseInt(item.Metadata["ttl"], 10, 64); err == nil && ttl > 0 {
+			attrs.TTL = time.Duration(ttl) * time.Second
+		}
+		list = append(list, attrs.info(item.Name))
+	}
+	return res.StatusCode, list, body.NextPageToken, nil
+}
+
+// helperObject reports whether object supports a lock object,
+// rather than being one.
+func helperObject(object string) bool {
+	return strings.HasSuffix(object, ".usage") ||
+		strings.HasSuffix(object, ".last") ||
+		strings.Contains(object, ".queue/")
+}
@@ -0,0 +1,81 @@
+package gmutex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SetMetadata sets custom key/values to attach to the lock object,
+// when the mutex is locked, extended, or updated,
+// to store small operational context (job id, trigger, version, etc).
+// Keys are case-insensitive, and stored in lower case.
+// The ttl and holder keys are reserved.
+func (m *Mutex) SetMetadata(metadata map[string]string) error {
+	var md map[string]string
+	for k, v := range metadata {
+		k = strings.ToLower(k)
+		if reservedMetadata(k) {
+			return fmt.Errorf("gmutex: reserved metadata key: %s", k)
+		}
+		if k == "" || strings.ContainsAny(k, " :\t\r\n") {
+			return fmt.Errorf("gmutex: invalid metadata key: %q", k)
+		}
+		if md == nil {
+			md = map[string]string{}
+		}
+		md[k] = v
+	}
+	m.metadata = md
+	return nil
+}
+
+// InspectMetadata inspects m, returning its locked state,
+// and the custom key/values attached to the lock object.
+func (m *Mutex) InspectMetadata(ctx context.Context) (bool, map[string]string, error) {
+	var backoff expBackOff // Exponential backoff because we don't hold the lock.
+
+	for {
+		// Inspect the lock object.
+		status, attrs, err := m.inspectAttrs(ctx, backoff.attempt(), nil)
+		if status == http.StatusOK {
+			return true, attrs.Metadata, nil
+		}
+		if status == http.StatusNotFound {
+			return false, nil, nil
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("inspect", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return false, nil, err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return false, nil, fmt.Errorf("inspect mutex: %w", err)
+		}
+		return false, nil, fmt.Errorf("inspect mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+func reservedMetadata(key string) bool {
+	return key == "ttl" || key == "holder"
+}
+
+func customMetadata(metadata map[string]string) map[string]string {
+	var md map[string]string
+	for k, v := range metadata {
+		if !reservedMetadata(k) {
+			if md == nil {
+				md = map[string]string{}
+			}
+			md[k] = v
+		}
+	}
+	return md
+}
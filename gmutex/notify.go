@@ -0,0 +1,225 @@
+package gmutex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// A Notifier receives Cloud Storage Pub/Sub notifications,
+// and wakes Mutexes waiting for their lock objects to be released.
+//
+// The bucket should be configured to publish OBJECT_DELETE
+// (and, for versioned buckets, OBJECT_ARCHIVE) notifications to a topic,
+// and each process should pull from its own subscription to that topic.
+// Without notifications (or if they're delayed or lost)
+// Mutexes fall back to exponential backoff.
+//
+// To use the Pub/Sub emulator, provide the endpoint
+// by setting the environment variable PUBSUB_EMULATOR_HOST
+// prior to creating the Notifier.
+type Notifier struct {
+	_            noCopy
+	subscription string
+	baseUrl      *url.URL
+
+	mtx     sync.Mutex
+	waiters map[string]map[chan struct{}]struct{}
+}
+
+// NewNotifier creates a new Notifier that pulls from the given
+// subscription (in the form projects/{project}/subscriptions/{subscription}).
+// Call Run to start receiving notifications.
+func NewNotifier(ctx context.Context, subscription string) (*Notifier, error) {
+	if err := initClient(ctx, pubsubScope); err != nil {
+		return nil, err
+	}
+
+	baseUrl, err := endpoint("PUBSUB_EMULATOR_HOST", "pubsub.googleapis.com")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notifier{
+		subscription: subscription,
+		baseUrl:      baseUrl,
+		waiters:      map[string]map[chan struct{}]struct{}{},
+	}, nil
+}
+
+// Run pulls notifications until the context expires,
+// or an unrecoverable error occurs.
+func (n *Notifier) Run(ctx context.Context) error {
+	var backoff expBackOff // Exponential backoff for transient errors.
+
+	for {
+		status, ids, err := n.pull(ctx)
+		if status == http.StatusOK {
+			backoff = expBackOff{}
+			if len(ids) == 0 {
+				continue
+			}
+			status, err = n.acknowledge(ctx, ids)
+			if status == http.StatusOK {
+				continue
+			}
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			if err := backoff.wait(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return fmt.Errorf("notifier: %w", err)
+		}
+		return fmt.Errorf("notifier: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+// SetNotifier sets the Notifier used to wake m
+// while it waits for the lock to be released.
+// A nil Notifier disables notifications.
+func (m *Mutex) SetNotifier(n *Notifier) {
+	m.notifier = n
+}
+
+func (n *Notifier) subscribe(b Backend) chan struct{} {
+	// Only Cloud Storage sends notifications.
+	o, ok := b.(*gcsObject)
+	if n == nil || !ok {
+		return nil
+	}
+
+	key := o.bucket + "/" + o.object
+	wake := make(chan struct{}, 1)
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if n.waiters[key] == nil {
+		n.waiters[key] = map[chan struct{}]struct{}{}
+	}
+	n.waiters[key][wake] = struct{}{}
+	return wake
+}
+
+func (n *Notifier) unsubscribe(b Backend, wake chan struct{}) {
+	o, ok := b.(*gcsObject)
+	if n == nil || !ok {
+		return
+	}
+
+	key := o.bucket + "/" + o.object
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	delete(n.waiters[key], wake)
+	if len(n.waiters[key]) == 0 {
+		delete(n.waiters, key)
+	}
+}
+
+func (n *Notifier) notify(bucket, object string) {
+	key := bucket + "/" + object
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	for wake := range n.waiters[key] {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (n *Notifier) pull(ctx context.Context) (int, []string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url("pull"),
+		strings.NewReader(`{"maxMessages":100}`))
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient(pubsubScope).Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return res.StatusCode, nil, nil
+	}
+
+	var body struct {
+		ReceivedMessages []struct {
+			AckID   string `json:"ackId"`
+			Message struct {
+				Attributes map[string]string `json:"attributes"`
+			} `json:"message"`
+		} `json:"receivedMessages"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return 0, nil, err
+	}
+
+	ids := make([]string, 0, len(body.ReceivedMessages))
+	for _, msg := range body.ReceivedMessages {
+		ids = append(ids, msg.AckID)
+		if released(msg.Message.Attributes) {
+			n.notify(msg.Message.Attributes["bucketId"], msg.Message.Attributes["objectId"])
+		}
+	}
+	return res.StatusCode, ids, nil
+}
+
+func (n *Notifier) acknowledge(ctx context.Context, ids []string) (int, error) {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(map[string][]string{"ackIds": ids})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url("acknowledge"), &buf)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient(pubsubScope).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
+
+func (n *Notifier) url(method string) string {
+	url := url.URL{
+		Scheme: n.baseUrl.Scheme,
+		Host:   n.baseUrl.Host,
+		Path:   "/v1/" + n.subscription + ":" + method,
+	}
+	return url.String()
+}
+
+func released(attrs map[string]string) bool {
+	// Overwrites (extending or updating the lock) also send OBJECT_DELETE,
+	// but don't release the lock.
+	if _, ok := attrs["overwrittenByGeneration"]; ok {
+		return false
+	}
+	switch attrs["eventType"] {
+	case "OBJECT_DELETE", "OBJECT_ARCHIVE":
+		return true
+	default:
+		return false
+	}
+}
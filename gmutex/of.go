@@ -0,0 +1,118 @@
+package gmutex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Of is a Mutex with attached data of type T,
+// stored as JSON.
+type Of[T any] struct {
+	m *Mutex
+}
+
+// NewOf creates a new Of at the given bucket and object,
+// with the given time-to-live.
+func NewOf[T any](ctx context.Context, bucket, object string, ttl time.Duration) (*Of[T], error) {
+	m, err := New(ctx, bucket, object, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &Of[T]{m}, nil
+}
+
+// Mutex returns the underlying Mutex.
+func (m *Of[T]) Mutex() *Mutex {
+	return m.m
+}
+
+// Lock locks m with attached data v.
+// If the lock is already in use,
+// the calling goroutine blocks until the mutex is available,
+// or the context expires.
+func (m *Of[T]) Lock(ctx context.Context, v T) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return m.m.LockData(ctx, bytes.NewReader(b))
+}
+
+// TryLock tries to lock m with attached data v.
+// Returns true if the lock was taken successfully.
+// Returns false, and the attached data of the current holder,
+// if the lock is already in use.
+// The data is the zero value if another goroutine of this process holds m,
+// as the server isn't asked.
+func (m *Of[T]) TryLock(ctx context.Context, v T) (bool, T, error) {
+	var held T
+	b, err := json.Marshal(v)
+	if err != nil {
+		return false, held, err
+	}
+
+	buf := bytes.NewBuffer(b)
+	locked, fetched, err := m.m.tryLockData(ctx, buf)
+	if locked || !fetched || err != nil {
+		return locked, held, err
+	}
+	held, err = decode[T](buf.Bytes())
+	return false, held, err
+}
+
+// Update updates attached data, extending the expiration time of m.
+func (m *Of[T]) Update(ctx context.Context, v T) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return m.m.UpdateData(ctx, bytes.NewReader(b))
+}
+
+// Inspect inspects m, returning its locked state and attached data.
+func (m *Of[T]) Inspect(ctx context.Context) (bool, T, error) {
+	var buf bytes.Buffer
+	locked, err := m.m.InspectData(ctx, &buf)
+	if !locked || err != nil {
+		var zero T
+		return locked, zero, err
+	}
+	data, err := decode[T](buf.Bytes())
+	return true, data, err
+}
+
+// Unlock unlocks m, deleting any attached data.
+func (m *Of[T]) Unlock(ctx context.Context) error {
+	return m.m.Unlock(ctx)
+}
+
+// Extend extends the expiration time of m, keeping any attached data.
+func (m *Of[T]) Extend(ctx context.Context) error {
+	return m.m.Extend(ctx)
+}
+
+// Abandon abandons m, returning a lock id that can be used to call Adopt.
+func (m *Of[T]) Abandon() string {
+	return m.m.Abandon()
+}
+
+// Adopt adopts an abandoned lock into m, with attached data v.
+func (m *Of[T]) Adopt(ctx context.Context, id string, v T) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return m.m.AdoptData(ctx, id, bytes.NewReader(b))
+}
+
+func decode[T any](b []byte) (T, error) {
+	var v T
+	// A lock taken without data holds the zero value.
+	if len(b) == 0 {
+		return v, nil
+	}
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
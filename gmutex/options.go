@@ -0,0 +1,43 @@
+package gmutex
+
+import "time"
+
+// A LockOption overrides the default behavior of Lock, and LockData,
+// for a single call.
+type LockOption func(*lockOptions)
+
+type lockOptions struct {
+	maxWait    time.Duration
+	backOffMin time.Duration
+	backOffMax time.Duration
+}
+
+// WithMaxWait limits how long Lock waits for the mutex to be available,
+// independently of the context's deadline (like LockFor).
+// If the lock isn't acquired in time, Lock returns a *TimeoutError.
+func WithMaxWait(d time.Duration) LockOption {
+	return func(o *lockOptions) { o.maxWait = d }
+}
+
+// WithBackoff sets the bounds of the exponential backoff
+// between attempts to acquire the lock, while it is in use,
+// or after transient errors.
+// Zero bounds use the defaults.
+func WithBackoff(min, max time.Duration) LockOption {
+	return func(o *lockOptions) {
+		o.backOffMin = min
+		o.backOffMax = max
+	}
+}
+
+func newLockOptions(opts []LockOption) lockOptions {
+	var o lockOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o lockOptions) backoff() expBackOff {
+	return expBackOff{min: o.backOffMin, max: o.backOffMax}
+}
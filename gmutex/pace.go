@@ -0,0 +1,36 @@
+package gmutex
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrRateLimited is returned when extending, or updating, a mutex
+// faster than Cloud Storage allows, if the context expires first.
+var ErrRateLimited = errors.New("rate limited")
+
+// Cloud Storage limits writes to the same object to about one per second.
+const mutationInterval = time.Second
+
+// pace waits until the lock object can be written again,
+// rather than have Cloud Storage reject the write.
+// It must be called with m.mtx held.
+func (m *Mutex) pace(ctx context.Context) error {
+	wait := time.Until(m.mutated.Add(mutationInterval))
+	if wait <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return ErrRateLimited
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
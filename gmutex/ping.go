@@ -0,0 +1,133 @@
+package gmutex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Errors reported by Ping (and, where applicable, other operations).
+var (
+	ErrBucketNotFound    = errors.New("bucket does not exist")
+	ErrUnauthenticated   = errors.New("invalid credentials")
+	ErrPermissionDenied  = errors.New("permission denied")
+	ErrInsufficientScope = errors.New("insufficient scope")
+)
+
+// Ping checks that m can be used:
+// that its bucket exists, and that credentials are valid,
+// with the necessary scopes and permissions.
+// It doesn't lock, or otherwise modify, the lock object.
+//
+// Ping is meant to be called at startup,
+// so misconfiguration is caught early, rather than under load.
+// Errors wrap ErrBucketNotFound, ErrUnauthenticated,
+// ErrPermissionDenied, or ErrInsufficientScope, as appropriate.
+func (m *Mutex) Ping(ctx context.Context) error {
+	var backoff expBackOff // Exponential backoff for transient errors.
+
+	for {
+		var status int
+		var missing []string
+		var header http.Header
+		var err error
+		if o, ok := m.backend.(*gcsObject); ok {
+			status, header, missing, err = o.testPermissions(ctx, m.fair)
+		} else {
+			// Inspect the lock object, which may or may not exist.
+			status, _, err = m.inspectAttrs(ctx, backoff.attempt(), nil)
+			if status == http.StatusNotFound {
+				status = http.StatusOK
+			}
+		}
+
+		switch {
+		case status == http.StatusOK && len(missing) == 0:
+			return nil
+		case status == http.StatusOK:
+			return fmt.Errorf("ping mutex: %w: missing %s", ErrPermissionDenied, strings.Join(missing, ", "))
+		case status == http.StatusNotFound:
+			return fmt.Errorf("ping mutex: %w", ErrBucketNotFound)
+		case status == http.StatusUnauthorized:
+			return fmt.Errorf("ping mutex: %w", ErrUnauthenticated)
+		case status == http.StatusForbidden && strings.Contains(header.Get("WWW-Authenticate"), "insufficient_scope"):
+			return fmt.Errorf("ping mutex: %w", ErrInsufficientScope)
+		case status == http.StatusForbidden:
+			return fmt.Errorf("ping mutex: %w", ErrPermissionDenied)
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("ping", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return fmt.Errorf("ping mutex: %w", err)
+		}
+		return fmt.Errorf("ping mutex: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+// testPermissions checks which permissions needed to lock the object
+// are missing on its bucket.
+func (o *gcsObject) testPermissions(ctx context.Context, list bool) (int, http.Header, []string, error) {
+	permissions := []string{
+		"storage.objects.create",
+		"storage.objects.delete",
+		"storage.objects.get",
+	}
+	if list {
+		permissions = append(permissions, "storage.objects.list")
+	}
+
+	query := url.Values{"permissions": permissions}
+	url := url.URL{
+		Scheme:   o.baseUrl.Scheme,
+		Host:     o.baseUrl.Host,
+		Path:     "/storage/v1/b/" + o.bucket + "/iam/testPermissions",
+		RawPath:  "/storage/v1/b/" + url.PathEscape(o.bucket) + "/iam/testPermissions",
+		RawQuery: query.Encode(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	res, err := o.do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return res.StatusCode, res.Header, nil, nil
+	}
+
+	var body struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return 0, nil, nil, err
+	}
+
+	granted := map[string]bool{}
+	for _, p := range body.Permissions {
+		granted[p] = true
+	}
+	var missing []string
+	for _, p := range permissions {
+		if !granted[p] {
+			missing = append(missing, p)
+		}
+	}
+	return res.StatusCode, res.Header, missing, nil
+}
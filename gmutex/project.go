@@ -0,0 +1,48 @@
+package gmutex
+
+import (
+	"errors"
+	"net/http"
+)
+
+// SetUserProject sets the project billed for requests on the lock object,
+// and whose quota they count against,
+// so a bucket owned by a central project can be used from other projects.
+// The caller needs the serviceusage.services.use permission on the project.
+// An empty project bills the bucket's owner.
+// Requires a Mutex backed by Cloud Storage.
+func (m *Mutex) SetUserProject(project string) error {
+	o, ok := m.backend.(*gcsObject)
+	if !ok {
+		return errors.New("gmutex: user projects require Cloud Storage")
+	}
+	o.userProject = project
+	return nil
+}
+
+// SetHTTPClient sets the http.Client used to access the lock object,
+// overriding HTTPClient for m.
+// Use it to provide credentials specific to m,
+// such as those of an impersonated service account.
+// A nil client reverts to HTTPClient.
+// Requires a Mutex backed by Cloud Storage.
+func (m *Mutex) SetHTTPClient(client *http.Client) error {
+	o, ok := m.backend.(*gcsObject)
+	if !ok {
+		return errors.New("gmutex: custom clients require Cloud Storage")
+	}
+	o.client = client
+	return nil
+}
+
+// do sends a request for the lock object,
+// with its client, and billing the user project.
+func (o *gcsObject) do(req *http.Request) (*http.Response, error) {
+	if o.userProject != "" {
+		req.Header.Set("x-goog-user-project", o.userProject)
+	}
+	if o.client != nil {
+		return o.client.Do(req)
+	}
+	return httpClient(storageScope).Do(req)
+}
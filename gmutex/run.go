@@ -0,0 +1,208 @@
+package gmutex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// A Run describes an execution of RunExclusive.
+type Run struct {
+	Execution string    `json:"execution"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// A HeldError is returned by RunExclusive
+// when another execution holds the lock.
+type HeldError struct {
+	Object string // The lock object.
+	Run    Run    // The execution holding the lock, if known.
+}
+
+func (e *HeldError) Error() string {
+	if e.Run.Execution == "" {
+		return "run exclusive: " + e.Object + " is held"
+	}
+	return "run exclusive: " + e.Object + " is held by " + e.Run.Execution +
+		" since " + e.Run.Started.Format(time.RFC3339)
+}
+
+// RunExclusive runs fn, unless another execution is already running,
+// protecting Cloud Run Jobs, and Cloud Scheduler targets, from overlap.
+//
+// RunExclusive tries to lock the given object, with the given time-to-live,
+// and returns a *HeldError, without running fn, if the lock is held.
+// While fn runs, the lock is extended in the background,
+// and the context passed to fn is canceled if the lock is lost.
+// A Run record, describing the execution, is attached to the lock,
+// and its outcome is recorded in a sibling object (see LastRun).
+//
+// Returns the error returned by fn, if any,
+// otherwise an error if the lock could not be released.
+func RunExclusive(ctx context.Context, bucket, object string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	m, err := New(ctx, bucket, object, ttl)
+	if err != nil {
+		return err
+	}
+
+	run := Run{Execution: execution(m), Started: time.Now().UTC()}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	buf := bytes.NewBuffer(data)
+	locked, err := m.TryLockData(ctx, buf)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		held := HeldError{Object: m.String()}
+		json.Unmarshal(buf.Bytes(), &held.Run)
+		return &held
+	}
+
+	err = m.run(ctx, fn)
+
+	// Record the outcome, best effort.
+	run.Finished = time.Now().UTC()
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if data, merr := json.Marshal(run); merr == nil {
+		last := m.lastRun()
+		last.Create(context.WithoutCancel(ctx), "", Attrs{}, bytes.NewReader(data))
+	}
+
+	uerr := m.Unlock(context.WithoutCancel(ctx))
+	if err != nil {
+		return err
+	}
+	return uerr
+}
+
+// WithLock locks m, runs fn, and unlocks m when fn returns.
+//
+// While fn runs, the lock is extended in the background,
+// and the context passed to fn is canceled if the lock is lost.
+//
+// Returns the error returned by fn, if any,
+// otherwise an error if the lock could not be released.
+func WithLock(ctx context.Context, m *Mutex, fn func(ctx context.Context) error) error {
+	if err := m.Lock(ctx); err != nil {
+		return err
+	}
+
+	err := m.run(ctx, fn)
+	uerr := m.Unlock(context.WithoutCancel(ctx))
+	if err != nil {
+		return err
+	}
+	return uerr
+}
+
+// run runs fn while keeping the lock held by m alive.
+func (m *Mutex) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.keepAlive(ctx, cancel)
+	}()
+
+	err := fn(ctx)
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// LastRun returns the last recorded execution of RunExclusive
+// for the given lock object.
+// Returns false if no execution was recorded.
+func LastRun(ctx context.Context, bucket, object string) (Run, bool, error) {
+	m, err := New(ctx, bucket, object, 0)
+	if err != nil {
+		return Run{}, false, err
+	}
+
+	var run Run
+	var buf bytes.Buffer
+	status, _, err := m.lastRun().Inspect(ctx, &buf)
+	if status == http.StatusOK && err == nil {
+		err = json.Unmarshal(buf.Bytes(), &run)
+		return run, err == nil, err
+	}
+	if status == http.StatusNotFound {
+		return run, false, nil
+	}
+	if err != nil {
+		return run, false, fmt.Errorf("last run: %w", err)
+	}
+	return run, false, fmt.Errorf("last run: http status %d: %s", status, http.StatusText(status))
+}
+
+// keepAlive extends m until ctx is done,
+// calling cancel if the lock is lost.
+func (m *Mutex) keepAlive(ctx context.Context, cancel context.CancelFunc) {
+	lease := m.Lease()
+	if lease == nil {
+		cancel()
+		return
+	}
+
+	var tick <-chan time.Time
+	if ttl := m.TTL(); ttl > 0 {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-lease.Done():
+			cancel()
+			return
+		case <-tick:
+			// Other failures are retried on the next tick, until the lease expires.
+			if err := m.keepExtend(ctx); errors.Is(err, ErrStale) {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// keepExtend extends m, without abandoning the request if ctx is canceled,
+// which could leave m unaware of its lock object's new generation.
+func (m *Mutex) keepExtend(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.TTL()/3)
+	defer cancel()
+	return m.Extend(ctx)
+}
+
+func (m *Mutex) lastRun() *gcsObject {
+	o := *m.backend.(*gcsObject)
+	o.object += ".last"
+	return &o
+}
+
+func execution(m *Mutex) string {
+	if id := os.Getenv("CLOUD_RUN_EXECUTION"); id != "" {
+		if task := os.Getenv("CLOUD_RUN_TASK_INDEX"); task != "" {
+			return id + "/" + task
+		}
+		return id
+	}
+	return m.Holder()
+}
@@ -0,0 +1,68 @@
+package gmutex
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+)
+
+// A Standby runs background work on a single active instance,
+// with other instances standing by to take over,
+// such as Cloud Run services with more than one minimum instance.
+type Standby struct {
+	m      *Mutex
+	active atomic.Bool
+}
+
+// NewStandby creates a Standby that uses m to elect the active instance.
+func NewStandby(m *Mutex) *Standby {
+	return &Standby{m: m}
+}
+
+// Run waits to lock m, and then runs fn while holding it,
+// extending the lock in the background.
+// If the lock is lost, the context passed to fn is canceled,
+// and once fn returns, Run waits to lock m again.
+//
+// Run returns when fn returns while holding the lock,
+// or when ctx is done.
+func (s *Standby) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	for {
+		if err := s.m.Lock(ctx); err != nil {
+			return err
+		}
+
+		lease := s.m.Lease()
+		s.active.Store(true)
+		err := s.m.run(ctx, fn)
+		s.active.Store(false)
+
+		lost := lease.Err() != nil
+		uerr := s.m.Unlock(context.WithoutCancel(ctx))
+		switch {
+		case !lost && err != nil:
+			return err
+		case !lost || !errors.Is(uerr, ErrStale) && uerr != nil:
+			return uerr
+		case ctx.Err() != nil:
+			return ctx.Err()
+		}
+		// The lock was lost, stand by.
+	}
+}
+
+// Active reports whether this instance is the active one.
+func (s *Standby) Active() bool {
+	return s.active.Load()
+}
+
+// ServeHTTP serves a readiness probe:
+// OK if this instance is active, Service Unavailable otherwise.
+func (s *Standby) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Active() {
+		http.Error(w, "active", http.StatusOK)
+	} else {
+		http.Error(w, "standby", http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,124 @@
+package gmutex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// mutexState is the serialized form of a Mutex.
+type mutexState struct {
+	Bucket      string            `json:"bucket"`
+	Object      string            `json:"object"`
+	TTL         int64             `json:"ttl"`
+	Generation  string            `json:"generation,omitempty"`
+	Deadline    time.Time         `json:"deadline,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Endpoint    string            `json:"endpoint,omitempty"`
+	UserProject string            `json:"userProject,omitempty"`
+	KMSKeyName  string            `json:"kmsKeyName,omitempty"`
+	Patch       bool              `json:"patch,omitempty"`
+}
+
+// MarshalJSON captures the lock object, time-to-live, metadata,
+// the settings used to access the lock object
+// (endpoint, user project, KMS key name, and patch extend),
+// and (if m is held) the lock id, and lease deadline, of m,
+// so another process can restore it with UnmarshalJSON,
+// for example, to hand off a lock through a task payload.
+//
+// Marshaling doesn't abandon m,
+// the process should stop using it once it's handed off.
+// Requires a Mutex backed by Cloud Storage,
+// and fails for a Mutex with a customer-supplied encryption key,
+// or a custom http.Client, which can't be marshaled.
+func (m *Mutex) MarshalJSON() ([]byte, error) {
+	o, ok := m.backend.(*gcsObject)
+	if !ok {
+		return nil, errors.New("gmutex: marshal requires Cloud Storage")
+	}
+	if o.encryptionKey != "" {
+		return nil, errors.New("gmutex: marshal of mutex with encryption key")
+	}
+	if o.client != nil {
+		return nil, errors.New("gmutex: marshal of mutex with custom client")
+	}
+
+	state := mutexState{
+		Bucket:      o.bucket,
+		Object:      o.object,
+		TTL:         m.ttl,
+		Metadata:    m.metadata,
+		UserProject: o.userProject,
+		KMSKeyName:  o.kmsKeyName,
+		Patch:       o.patch,
+	}
+	if def, err := defaultEndpoint(); err != nil || *def != *o.baseUrl {
+		state.Endpoint = o.baseUrl.String()
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.generation != "" {
+		state.Generation = m.generation
+		state.Deadline = m.lease.Deadline()
+	}
+	return json.Marshal(state)
+}
+
+// UnmarshalJSON restores a Mutex marshaled by MarshalJSON into m,
+// which must be a zero Mutex.
+// If the marshaled Mutex was held, m is restored held,
+// as if by Adopt, but without ensuring mutual exclusion:
+// call Extend (or UpdateData) before resuming work.
+// Its lease keeps the marshaled deadline.
+func (m *Mutex) UnmarshalJSON(data []byte) error {
+	if m.backend != nil {
+		return errors.New("gmutex: unmarshal into initialized mutex")
+	}
+
+	var state mutexState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if state.Bucket == "" || state.Object == "" || state.Generation == "0" {
+		return errors.New("gmutex: invalid mutex state")
+	}
+	if err := m.SetMetadata(state.Metadata); err != nil {
+		return err
+	}
+
+	if err := initClient(context.Background(), storageScope); err != nil {
+		return err
+	}
+	baseUrl, err := parseEndpoint(state.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	m.backend = &gcsObject{
+		bucket:      state.Bucket,
+		object:      state.Object,
+		baseUrl:     baseUrl,
+		userProject: state.UserProject,
+		kmsKeyName:  state.KMSKeyName,
+		patch:       state.Patch,
+	}
+	m.sem = make(chan struct{}, 1)
+	m.SetTTL(time.Duration(state.TTL) * time.Second)
+	m.SetHolder("")
+
+	if state.Generation != "" {
+		m.sem <- struct{}{}
+		m.mtx.Lock()
+		m.generation = state.Generation
+		m.lease = newLease(m, state.Generation)
+		if !state.Deadline.IsZero() {
+			m.lease.renew(state.Deadline.Add(-m.TTL()), m.TTL())
+		}
+		m.locked = time.Now()
+		m.mtx.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package gmutex
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are cumulative statistics of a Mutex.
+type Stats struct {
+	Acquisitions   int64         // Locks acquired.
+	Contentions    int64         // Lock attempts that found the lock in use.
+	WaitTime       time.Duration // Time spent acquiring locks.
+	Retries        int64         // Requests retried after transient errors.
+	ExtendFailures int64         // Extends and updates that failed.
+	Lost           int64         // Locks found to be stale: expired or stolen.
+}
+
+type stats struct {
+	acquisitions   atomic.Int64
+	contentions    atomic.Int64
+	waitTime       atomic.Int64
+	retries        atomic.Int64
+	extendFailures atomic.Int64
+	lost           atomic.Int64
+}
+
+// Stats returns the statistics of m.
+func (m *Mutex) Stats() Stats {
+	return Stats{
+		Acquisitions:   m.stats.acquisitions.Load(),
+		Contentions:    m.stats.contentions.Load(),
+		WaitTime:       time.Duration(m.stats.waitTime.Load()),
+		Retries:        m.stats.retries.Load(),
+		ExtendFailures: m.stats.extendFailures.Load(),
+		Lost:           m.stats.lost.Load(),
+	}
+}
+
+// StatsVar returns an expvar.Var that reports the statistics of m.
+// Publish it with expvar.Publish.
+func (m *Mutex) StatsVar() expvar.Var {
+	return expvar.Func(func() any { return m.Stats() })
+}
@@ -0,0 +1,47 @@
+package gmutex
+
+import (
+	"context"
+	"time"
+)
+
+// A TimeoutError is returned by LockFor, TryLockFor, and WithMaxWait,
+// if the lock could not be acquired within the given timeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return "lock mutex: timeout after " + e.Timeout.String()
+}
+
+// Unwrap returns context.DeadlineExceeded.
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// LockFor locks m, like Lock, but gives up after the given timeout,
+// independently of the context's deadline.
+// Returns a *TimeoutError if the timeout expires.
+func (m *Mutex) LockFor(ctx context.Context, timeout time.Duration) error {
+	return m.Lock(ctx, WithMaxWait(timeout))
+}
+
+// TryLockFor tries to lock m, like TryLock,
+// but gives up retrying transient errors after the given timeout,
+// independently of the context's deadline.
+// Returns a *TimeoutError if the timeout expires.
+func (m *Mutex) TryLockFor(ctx context.Context, timeout time.Duration) (bool, error) {
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	locked, err := m.TryLock(tctx)
+	return locked, timeoutError(ctx, tctx, timeout, err)
+}
+
+func timeoutError(parent, ctx context.Context, timeout time.Duration, err error) error {
+	// Only report a timeout if it was ours, not the parent's.
+	if err != nil && parent.Err() == nil && ctx.Err() == context.DeadlineExceeded {
+		return &TimeoutError{Timeout: timeout}
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package gmutex
+
+import (
+	"context"
+	"net/http"
+
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/trace"
+)
+
+// startSpan starts an OpenCensus span around a storage operation,
+// so slow lock operations appear in traces.
+func (m *Mutex) startSpan(ctx context.Context, op string, try attempt) (context.Context, *trace.Span) {
+	ctx, span := trace.StartSpan(ctx, "gmutex."+op, trace.WithSpanKind(trace.SpanKindClient))
+	if span.IsRecordingEvents() {
+		attrs := []trace.Attribute{
+			trace.StringAttribute("gmutex.lock", m.String()),
+			trace.Int64Attribute("gmutex.attempt", int64(try.n)),
+		}
+		if o, ok := m.backend.(*gcsObject); ok {
+			attrs = append(attrs,
+				trace.StringAttribute("gcs.bucket", o.bucket),
+				trace.StringAttribute("gcs.object", o.object))
+		}
+		span.AddAttributes(attrs...)
+	}
+	return ctx, span
+}
+
+func (m *Mutex) endSpan(ctx context.Context, span *trace.Span, op string, try attempt, status int, err error) {
+	if m.debug {
+		kvs := []any{"attempt", try.n, "backoff", try.delay.String(), "status", status}
+		if err != nil {
+			kvs = append(kvs, "error", err.Error())
+		}
+		m.debugw(ctx, "gmutex: "+op, kvs...)
+	}
+
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	} else if status != 0 {
+		span.AddAttributes(trace.Int64Attribute(ochttp.StatusCodeAttribute, int64(status)))
+		span.SetStatus(ochttp.TraceStatus(status, http.StatusText(status)))
+	}
+	span.End()
+}
@@ -0,0 +1,121 @@
+package gmutex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Usage records the historical use of a lock object
+// (see SetUsageTracking).
+type Usage struct {
+	Acquisitions int64         `json:"acquisitions"`
+	LastHolder   string        `json:"lastHolder"`
+	LastLocked   time.Time     `json:"lastLocked"`
+	TotalHeld    time.Duration `json:"totalHeld"`
+}
+
+// AverageHeld returns the average time the lock was held.
+func (u Usage) AverageHeld() time.Duration {
+	if u.Acquisitions == 0 {
+		return 0
+	}
+	return u.TotalHeld / time.Duration(u.Acquisitions)
+}
+
+// SetUsageTracking sets whether m records its use in a sibling object
+// (named after the lock object, with a ".usage" suffix),
+// for lightweight historical visibility into lock usage (see GetUsage).
+//
+// Usage is recorded in the background when m is unlocked,
+// bounded by the context passed to Unlock, on a best effort basis:
+// concurrent updates are serialized with generation preconditions,
+// but updates can be lost under heavy load.
+// Requires a Mutex backed by Cloud Storage.
+func (m *Mutex) SetUsageTracking(track bool) error {
+	if _, ok := m.backend.(*gcsObject); !ok && track {
+		return errors.New("gmutex: usage tracking requires Cloud Storage")
+	}
+	m.usage = track
+	return nil
+}
+
+// GetUsage returns the recorded use of the given lock object.
+// Returns false if no use was recorded.
+func GetUsage(ctx context.Context, bucket, object string) (Usage, bool, error) {
+	m, err := New(ctx, bucket, object, 0)
+	if err != nil {
+		return Usage{}, false, err
+	}
+
+	var usage Usage
+	var buf bytes.Buffer
+	status, _, err := m.usageObject().Inspect(ctx, &buf)
+	if status == http.StatusOK && err == nil {
+		err = json.Unmarshal(buf.Bytes(), &usage)
+		return usage, err == nil, err
+	}
+	if status == http.StatusNotFound {
+		return usage, false, nil
+	}
+	if err != nil {
+		return usage, false, fmt.Errorf("get usage: %w", err)
+	}
+	return usage, false, fmt.Errorf("get usage: http status %d: %s", status, http.StatusText(status))
+}
+
+// recordUsage records an acquisition of the lock, held for the given time.
+// Usage is recorded in the background, so it doesn't delay unlocking.
+func (m *Mutex) recordUsage(ctx context.Context, locked time.Time, held time.Duration) {
+	if !m.usage {
+		return
+	}
+	go recordUsage(ctx, m.usageObject(), m.holder, locked, held)
+}
+
+func recordUsage(ctx context.Context, o *gcsObject, holder string, locked time.Time, held time.Duration) {
+	// Best effort, bounded by the context.
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		// Read the current usage, and update it at the same generation.
+		var usage Usage
+		var buf bytes.Buffer
+		status, attrs, err := o.Inspect(ctx, &buf)
+		switch {
+		case status == http.StatusOK && err == nil:
+			if json.Unmarshal(buf.Bytes(), &usage) != nil {
+				usage = Usage{}
+			}
+		case status == http.StatusNotFound:
+			attrs.Generation = "0"
+		default:
+			return
+		}
+
+		usage.Acquisitions++
+		usage.LastHolder = holder
+		usage.LastLocked = locked.UTC()
+		usage.TotalHeld += held
+
+		data, err := json.Marshal(usage)
+		if err != nil {
+			return
+		}
+		status, _, _ = o.Create(ctx, attrs.Generation, Attrs{}, bytes.NewReader(data))
+		if status != http.StatusPreconditionFailed {
+			return
+		}
+	}
+}
+
+func (m *Mutex) usageObject() *gcsObject {
+	o := *m.backend.(*gcsObject)
+	o.object += ".usage"
+	return &o
+}
@@ -0,0 +1,241 @@
+package gmutex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Versioning reports whether the bucket of m has object versioning,
+// or soft delete, enabled.
+// Requires a Mutex backed by Cloud Storage,
+// and the storage.buckets.get permission.
+//
+// In such buckets, every extend, update, and unlock
+// leaves behind a noncurrent (or soft-deleted) version of the lock object.
+// These are never considered held, but they accrue storage costs.
+// Use SetPurgeVersions, or PurgeVersions, to delete noncurrent versions,
+// and prefer a short soft delete retention period for lock buckets.
+func (m *Mutex) Versioning(ctx context.Context) (versioning, softDelete bool, err error) {
+	o, ok := m.backend.(*gcsObject)
+	if !ok {
+		return false, false, errors.New("gmutex: versioning requires Cloud Storage")
+	}
+
+	var backoff expBackOff // Exponential backoff for transient errors.
+
+	for {
+		status, bucket, err := o.bucketInfo(ctx)
+		if status == http.StatusOK {
+			return bucket.Versioning.Enabled, bucket.SoftDeletePolicy.RetentionDurationSeconds != "" &&
+				bucket.SoftDeletePolicy.RetentionDurationSeconds != "0", nil
+		}
+		if status == http.StatusNotFound {
+			return false, false, fmt.Errorf("inspect bucket: %w", ErrBucketNotFound)
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("inspect", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return false, false, err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return false, false, fmt.Errorf("inspect bucket: %w", err)
+		}
+		return false, false, fmt.Errorf("inspect bucket: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+// SetPurgeVersions sets whether m deletes the noncurrent versions
+// it leaves behind when extending, updating, or unlocking the lock object,
+// in buckets with object versioning.
+// Purging is best effort: failures are ignored.
+// Requires a Mutex backed by Cloud Storage.
+func (m *Mutex) SetPurgeVersions(purge bool) error {
+	if _, ok := m.backend.(*gcsObject); !ok && purge {
+		return errors.New("gmutex: versioning requires Cloud Storage")
+	}
+	m.purge = purge
+	return nil
+}
+
+// PurgeVersions deletes all noncurrent versions of the lock object,
+// returning the number of versions deleted.
+// Requires a Mutex backed by Cloud Storage.
+func (m *Mutex) PurgeVersions(ctx context.Context) (int, error) {
+	o, ok := m.backend.(*gcsObject)
+	if !ok {
+		return 0, errors.New("gmutex: versioning requires Cloud Storage")
+	}
+
+	var backoff expBackOff // Exponential backoff for transient errors.
+
+	var purged int
+	for {
+		status, versions, err := o.noncurrentVersions(ctx)
+		if status == http.StatusOK {
+			for len(versions) > 0 {
+				status, err = o.deleteVersion(ctx, versions[0])
+				if status != http.StatusOK && status != http.StatusNoContent && status != http.StatusNotFound {
+					break
+				}
+				if status != http.StatusNotFound {
+					purged++
+				}
+				versions = versions[1:]
+			}
+			if len(versions) == 0 {
+				return purged, nil
+			}
+		}
+		if status == http.StatusNotFound {
+			return purged, fmt.Errorf("purge versions: %w", ErrBucketNotFound)
+		}
+
+		// For transient errors, backoff and retry.
+		if retriable(status, err) {
+			m.hookRetry("purge", status, err)
+			if err := backoff.wait(ctx); err != nil {
+				return purged, err
+			}
+			continue
+		}
+
+		// Can't recover, give up.
+		if err != nil {
+			return purged, fmt.Errorf("purge versions: %w", err)
+		}
+		return purged, fmt.Errorf("purge versions: http status %d: %s", status, http.StatusText(status))
+	}
+}
+
+func (m *Mutex) purgeVersion(ctx context.Context, generation string) {
+	if !m.purge || generation == "" || generation == "0" {
+		return
+	}
+	if o, ok := m.backend.(*gcsObject); ok {
+		// Best effort.
+		o.deleteVersion(ctx, generation)
+	}
+}
+
+type bucketInfo struct {
+	Versioning struct {
+		Enabled bool `json:"enabled"`
+	} `json:"versioning"`
+	SoftDeletePolicy struct {
+		RetentionDurationSeconds string `json:"retentionDurationSeconds"`
+	} `json:"softDeletePolicy"`
+}
+
+func (o *gcsObject) bucketInfo(ctx context.Context) (int, bucketInfo, error) {
+	url := url.URL{
+		Scheme:   o.baseUrl.Scheme,
+		Host:     o.baseUrl.Host,
+		Path:     "/storage/v1/b/" + o.bucket,
+		RawPath:  "/storage/v1/b/" + url.PathEscape(o.bucket),
+		RawQuery: "fields=versioning,softDeletePolicy",
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	var bucket bucketInfo
+	res, err := o.do(req)
+	if err != nil {
+		return 0, bucket, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return res.StatusCode, bucket, nil
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&bucket)
+	return res.StatusCode, bucket, err
+}
+
+// noncurrentVersions lists the generations of noncurrent versions of the object.
+func (o *gcsObject) noncurrentVersions(ctx context.Context) (int, []string, error) {
+	var versions []string
+	var token string
+	for {
+		query := url.Values{}
+		query.Set("prefix", o.object)
+		query.Set("versions", "true")
+		query.Set("fields", "items(name,generation,timeDeleted),nextPageToken")
+		if token != "" {
+			query.Set("pageToken", token)
+		}
+		url := url.URL{
+			Scheme:   o.baseUrl.Scheme,
+			Host:     o.baseUrl.Host,
+			Path:     "/storage/v1/b/" + o.bucket + "/o",
+			RawPath:  "/storage/v1/b/" + url.PathEscape(o.bucket) + "/o",
+			RawQuery: query.Encode(),
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+		if err != nil {
+			panic(err)
+		}
+
+		res, err := o.do(req)
+		if err != nil {
+			return 0, nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return res.StatusCode, nil, nil
+		}
+
+		var body struct {
+			NextPageToken string `json:"nextPageToken"`
+			Items         []struct {
+				Name       string    `json:"name"`
+				Generation string    `json:"generation"`
+				Deleted    time.Time `json:"timeDeleted"`
+			} `json:"items"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&body)
+		res.Body.Close()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		for _, item := range body.Items {
+			if item.Name == o.object && !item.Deleted.IsZero() {
+				versions = append(versions, item.Generation)
+			}
+		}
+		if body.NextPageToken == "" {
+			return http.StatusOK, versions, nil
+		}
+		token = body.NextPageToken
+	}
+}
+
+// deleteVersion deletes a specific version of the object.
+func (o *gcsObject) deleteVersion(ctx context.Context, generation string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, o.url()+"?generation="+generation, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	res, err := o.do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
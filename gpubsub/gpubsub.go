@@ -0,0 +1,90 @@
+// Package gpubsub handles Pub/Sub push subscriptions on Cloud Run.
+package gpubsub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ncruces/go-gcp/gauth"
+	"github.com/ncruces/go-gcp/glog"
+	"github.com/ncruces/go-gcp/gtrace"
+)
+
+// A Message is a Pub/Sub message, delivered by a push subscription.
+type Message struct {
+	ID          string            `json:"messageId"`
+	Data        []byte            `json:"data"`
+	Attributes  map[string]string `json:"attributes"`
+	OrderingKey string            `json:"orderingKey"`
+	PublishTime time.Time         `json:"publishTime"`
+
+	// Subscription is the full name of the subscription,
+	// projects/PROJECT_ID/subscriptions/SUBSCRIPTION_ID.
+	Subscription string `json:"-"`
+
+	// DeliveryAttempt counts deliveries of the message,
+	// starting at 1, if the subscription has a dead-letter topic,
+	// and is zero otherwise.
+	DeliveryAttempt int `json:"-"`
+}
+
+// A HandlerFunc handles a Pub/Sub message.
+// Returning nil acknowledges the message;
+// returning an error has it redelivered.
+//
+// The context carries the publisher's trace context (see gtrace.InjectPubSub),
+// and the verified push identity (see gauth.FromContext).
+// The Logger correlates entries with the request, and the trace.
+type HandlerFunc func(ctx context.Context, log glog.Logger, msg *Message) error
+
+// Handler returns an http.Handler for a Pub/Sub push subscription.
+//
+// If v is not nil, requests must carry an ID token
+// (configure the subscription's push authentication) that v accepts;
+// otherwise, authentication is left to Cloud Run.
+// Requests that fail verification get a 401 Unauthorized (or 403 Forbidden),
+// and those with malformed envelopes a 400 Bad Request.
+func Handler(v *gauth.Verifier, fn HandlerFunc) http.Handler {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		log := glog.ForRequest(r)
+
+		var envelope struct {
+			Message         Message `json:"message"`
+			Subscription    string  `json:"subscription"`
+			DeliveryAttempt int     `json:"deliveryAttempt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&envelope); err != nil {
+			log.Errorf("gpubsub: malformed push envelope: %v", err)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		msg := &envelope.Message
+		msg.Subscription = envelope.Subscription
+		msg.DeliveryAttempt = envelope.DeliveryAttempt
+
+		ctx := gtrace.ExtractPubSub(r.Context(), msg.Attributes)
+		log.SetContext(ctx)
+		log.SetLabel("message_id", msg.ID)
+		ctx = glog.NewContext(ctx, log)
+
+		if err := fn(ctx, log, msg); err != nil {
+			log.Errorf("gpubsub: message %s (attempt %d): %v", msg.ID, msg.DeliveryAttempt, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if v == nil {
+		return h
+	}
+	return v.Handler(h)
+}
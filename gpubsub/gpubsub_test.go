@@ -0,0 +1,71 @@
+package gpubsub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ncruces/go-gcp/glog"
+	"github.com/ncruces/go-gcp/gtrace"
+)
+
+const envelope = `{
+	"message": {
+		"data": "aGVsbG8=",
+		"attributes": {
+			"key": "value",
+			"googclient_traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+		},
+		"messageId": "136969346945",
+		"publishTime": "2024-01-01T00:00:00Z"
+	},
+	"subscription": "projects/myproject/subscriptions/mysubscription",
+	"deliveryAttempt": 3
+}`
+
+func TestHandler(t *testing.T) {
+	var got *Message
+	var trace string
+	fail := false
+	handler := Handler(nil, func(ctx context.Context, log glog.Logger, msg *Message) error {
+		got, trace = msg, gtrace.TraceID(ctx)
+		if fail {
+			return errors.New("fail")
+		}
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(envelope)))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if string(got.Data) != "hello" || got.Attributes["key"] != "value" || got.ID != "136969346945" {
+		t.Errorf("message = %+v", got)
+	}
+	if got.Subscription != "projects/myproject/subscriptions/mysubscription" || got.DeliveryAttempt != 3 {
+		t.Errorf("subscription = %q, attempt = %d", got.Subscription, got.DeliveryAttempt)
+	}
+	if got.PublishTime.IsZero() {
+		t.Error("publish time not decoded")
+	}
+	if trace != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("trace = %q", trace)
+	}
+
+	fail = true
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(envelope)))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,276 @@
+// Package gqueue implements a claim-based work queue on Google Cloud Storage,
+// for low-volume batch coordination without Pub/Sub.
+//
+// Producers write task objects under a prefix.
+// Workers claim tasks by rewriting them with a generation precondition,
+// recording themselves as the holder, with a visibility timeout,
+// like a gmutex lease: until the claim expires,
+// or is released, no other worker can claim the task.
+// Claims can be extended, while the task is processed.
+// Tasks that keep failing are moved under a dead-letter prefix.
+package gqueue
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex"
+)
+
+// Errors returned by Queue, and Task, operations.
+var (
+	ErrEmpty     = errors.New("gqueue: no tasks available")
+	ErrClaimLost = errors.New("gqueue: claim lost")
+)
+
+// A Queue is a work queue in a Cloud Storage bucket.
+type Queue struct {
+	bucket string
+	prefix string
+
+	// Visibility is how long a claim lasts, unless extended;
+	// the default is one minute.
+	Visibility time.Duration
+
+	// MaxAttempts is how many times a task can be claimed,
+	// before it's moved to the dead-letter prefix;
+	// the default is five.
+	MaxAttempts int
+
+	// Worker identifies this worker on its claims;
+	// the default is the host name and process id.
+	Worker string
+}
+
+// New creates a Queue in the given bucket,
+// with tasks stored under prefix+"tasks/",
+// and dead-letters under prefix+"dead/".
+func New(bucket, prefix string) *Queue {
+	host, _ := os.Hostname()
+	return &Queue{
+		bucket:      bucket,
+		prefix:      prefix,
+		Visibility:  time.Minute,
+		MaxAttempts: 5,
+		Worker:      host + ":" + strconv.Itoa(os.Getpid()),
+	}
+}
+
+// A Task is a claimed task.
+type Task struct {
+	ID       string
+	Data     []byte
+	Attempts int // times the task was claimed, including this one
+
+	queue      *Queue
+	backend    gmutex.Backend
+	generation string
+}
+
+// Push adds a task with data to the queue, returning its ID.
+// IDs are ordered by creation time, so tasks are claimed roughly in order.
+func (q *Queue) Push(ctx context.Context, data []byte) (string, error) {
+	var suffix [4]byte
+	rand.Read(suffix[:])
+	id := fmt.Sprintf("%019d-%s", time.Now().UnixNano(), hex.EncodeToString(suffix[:]))
+
+	b, err := gmutex.NewBackend(ctx, q.bucket, q.prefix+"tasks/"+id)
+	if err != nil {
+		return "", err
+	}
+	status, _, err := b.Create(ctx, "0", gmutex.Attrs{}, bytes.NewReader(data))
+	if err := check("push", id, status, err); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Claim claims the oldest available task.
+// Tasks are available if unclaimed, or if their claim expired.
+// Returns ErrEmpty if no tasks are available.
+func (q *Queue) Claim(ctx context.Context) (*Task, error) {
+	infos, err := gmutex.ListObjects(ctx, q.bucket, q.prefix+"tasks/")
+	if err != nil {
+		return nil, fmt.Errorf("gqueue: %w", err)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Object < infos[j].Object })
+
+	for _, info := range infos {
+		if info.Holder != "" && info.Held {
+			continue
+		}
+		id := strings.TrimPrefix(info.Object, q.prefix+"tasks/")
+		task, err := q.claim(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if task != nil {
+			return task, nil
+		}
+	}
+	return nil, ErrEmpty
+}
+
+// claim tries to claim a task, returning nil if it's unavailable.
+func (q *Queue) claim(ctx context.Context, id string) (*Task, error) {
+	b, err := gmutex.NewBackend(ctx, q.bucket, q.prefix+"tasks/"+id)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	status, attrs, err := b.Inspect(ctx, &buf)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := check("claim", id, status, err); err != nil {
+		return nil, err
+	}
+	if attrs.Holder != "" && !expired(attrs) {
+		return nil, nil
+	}
+
+	attempts, _ := strconv.Atoi(attrs.Metadata["attempts"])
+	if attempts >= q.maxAttempts() {
+		return nil, q.deadLetter(ctx, b, id, attrs.Generation, buf.Bytes(), attempts)
+	}
+
+	task := &Task{
+		ID:       id,
+		Data:     buf.Bytes(),
+		Attempts: attempts + 1,
+		queue:    q,
+		backend:  b,
+	}
+	status, task.generation, err = b.Create(ctx, attrs.Generation, task.attrs(q.visibility()), bytes.NewReader(task.Data))
+	if status == http.StatusPreconditionFailed || status == http.StatusNotFound {
+		// Claimed, or completed, by another worker.
+		return nil, nil
+	}
+	if err := check("claim", id, status, err); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// deadLetter moves a task to the dead-letter prefix.
+func (q *Queue) deadLetter(ctx context.Context, b gmutex.Backend, id, generation string, data []byte, attempts int) error {
+	dead, err := gmutex.NewBackend(ctx, q.bucket, q.prefix+"dead/"+id)
+	if err != nil {
+		return err
+	}
+	attrs := gmutex.Attrs{Metadata: map[string]string{"attempts": strconv.Itoa(attempts)}}
+	status, _, err := dead.Create(ctx, "", attrs, bytes.NewReader(data))
+	if err := check("dead-letter", id, status, err); err != nil {
+		return err
+	}
+	status, err = b.Delete(ctx, generation)
+	if status == http.StatusPreconditionFailed || status == http.StatusNotFound {
+		return nil
+	}
+	return check("dead-letter", id, status, err)
+}
+
+// DeadLetters lists the IDs of tasks moved to the dead-letter prefix.
+func (q *Queue) DeadLetters(ctx context.Context) ([]string, error) {
+	infos, err := gmutex.ListObjects(ctx, q.bucket, q.prefix+"dead/")
+	if err != nil {
+		return nil, fmt.Errorf("gqueue: %w", err)
+	}
+	ids := make([]string, 0, len(infos))
+	for _, info := range infos {
+		ids = append(ids, strings.TrimPrefix(info.Object, q.prefix+"dead/"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Extend extends the claim on t for another visibility timeout.
+// Returns ErrClaimLost if the claim expired, and another worker took it.
+func (t *Task) Extend(ctx context.Context) error {
+	status, gen, err := t.backend.Extend(ctx, t.generation, t.attrs(t.queue.visibility()))
+	if err := t.check("extend", status, err); err != nil {
+		return err
+	}
+	t.generation = gen
+	return nil
+}
+
+// Complete removes t from the queue.
+// Returns ErrClaimLost if the claim expired, and another worker took it.
+func (t *Task) Complete(ctx context.Context) error {
+	status, err := t.backend.Delete(ctx, t.generation)
+	return t.check("complete", status, err)
+}
+
+// Release gives up the claim on t, making it available again,
+// for example, to retry after a failure.
+// Returns ErrClaimLost if the claim expired, and another worker took it.
+func (t *Task) Release(ctx context.Context) error {
+	attrs := t.attrs(0)
+	attrs.Holder = ""
+	status, gen, err := t.backend.Create(ctx, t.generation, attrs, bytes.NewReader(t.Data))
+	if err := t.check("release", status, err); err != nil {
+		return err
+	}
+	t.generation = gen
+	return nil
+}
+
+func (t *Task) attrs(ttl time.Duration) gmutex.Attrs {
+	return gmutex.Attrs{
+		TTL:      ttl,
+		Holder:   t.queue.Worker,
+		Metadata: map[string]string{"attempts": strconv.Itoa(t.Attempts)},
+	}
+}
+
+func (t *Task) check(op string, status int, err error) error {
+	if status == http.StatusPreconditionFailed || status == http.StatusNotFound {
+		return ErrClaimLost
+	}
+	return check(op, t.ID, status, err)
+}
+
+func (q *Queue) visibility() time.Duration {
+	if q.Visibility <= 0 {
+		return time.Minute
+	}
+	return q.Visibility
+}
+
+func (q *Queue) maxAttempts() int {
+	if q.MaxAttempts <= 0 {
+		return 5
+	}
+	return q.MaxAttempts
+}
+
+// expired reports whether a claim expired, using server time.
+func expired(attrs gmutex.Attrs) bool {
+	if attrs.TTL <= 0 || attrs.Date.IsZero() {
+		return false
+	}
+	return attrs.Modified.Add(attrs.TTL).Before(attrs.Date)
+}
+
+func check(op, id string, status int, err error) error {
+	switch {
+	case err != nil:
+		return fmt.Errorf("gqueue: %s %s: %w", op, id, err)
+	case status == http.StatusOK, status == http.StatusNoContent:
+		return nil
+	default:
+		return fmt.Errorf("gqueue: %s %s: http status %d: %s", op, id, status, http.StatusText(status))
+	}
+}
@@ -0,0 +1,94 @@
+package gqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+)
+
+func TestQueue(t *testing.T) {
+	server := gmutextest.Start(t, "bucket")
+
+	ctx := context.Background()
+	a := New("bucket", "queue/")
+	b := New("bucket", "queue/")
+	a.Worker, b.Worker = "a", "b"
+	a.MaxAttempts, b.MaxAttempts = 3, 3
+
+	id1, err := a.Push(ctx, []byte("one"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, err := a.Push(ctx, []byte("two"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t1, err := a.Claim(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if t1.ID != id1 || string(t1.Data) != "one" || t1.Attempts != 1 {
+		t.Errorf("Claim() = %+v", t1)
+	}
+	t2, err := b.Claim(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if t2.ID != id2 {
+		t.Errorf("Claim() = %+v, want %s", t2, id2)
+	}
+	if _, err := b.Claim(ctx); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Claim() = %v, want ErrEmpty", err)
+	}
+
+	if err := t1.Extend(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := t1.Complete(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Released tasks are available again.
+	if err := t2.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t2a, err := a.Claim(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if t2a.ID != id2 || t2a.Attempts != 2 {
+		t.Errorf("Claim() = %+v", t2a)
+	}
+
+	// Expired claims are available again.
+	server.Advance(2 * time.Minute)
+	t2b, err := b.Claim(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if t2b.ID != id2 || t2b.Attempts != 3 {
+		t.Errorf("Claim() = %+v", t2b)
+	}
+	if err := t2a.Complete(ctx); !errors.Is(err, ErrClaimLost) {
+		t.Errorf("Complete() = %v, want ErrClaimLost", err)
+	}
+
+	// Tasks that fail too often are dead-lettered.
+	if err := t2b.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Claim(ctx); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Claim() = %v, want ErrEmpty", err)
+	}
+	dead, err := a.DeadLetters(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0] != id2 {
+		t.Errorf("DeadLetters() = %v", dead)
+	}
+}
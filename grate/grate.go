@@ -0,0 +1,258 @@
+// Package grate implements a distributed rate limiter,
+// with its state in a Google Cloud Storage object,
+// so a scaled-out service can enforce a global rate,
+// for example, towards a third-party API.
+//
+// The limiter is a token bucket, updated with compare-and-swap,
+// and refilled using server time, so instances' clocks don't matter.
+// Cloud Storage limits writes to an object to about one per second,
+// so instances reserve tokens in batches, and hand them out locally.
+// Unused reserved tokens are discarded after a second,
+// so the global rate is never exceeded.
+// Discarded tokens aren't returned to the shared bucket, so they're lost,
+// and a fleet of mostly idle instances undershoots the rate
+// by up to a batch per instance per second;
+// a smaller Batch wastes fewer tokens, at the cost of more writes.
+package grate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex"
+)
+
+// A Limiter controls how frequently events are allowed to happen,
+// across all instances sharing its object.
+type Limiter struct {
+	bucket string
+	object string
+	rate   float64
+	burst  int
+
+	// Batch is the number of tokens reserved at a time,
+	// the default is the rate per second (at least one).
+	Batch int
+
+	mtx       sync.Mutex
+	tokens    int
+	reserved  time.Time
+	retry     time.Time
+	reserving *reservation
+}
+
+// A reservation is a batch of tokens being taken from the shared bucket,
+// which callers out of local tokens wait for.
+type reservation struct {
+	done chan struct{}
+	err  error
+}
+
+// state is the shared token bucket,
+// with the tokens available when the object was last written.
+type state struct {
+	Tokens float64 `json:"tokens"`
+}
+
+// reservationTTL is how long reserved tokens can be used for.
+const reservationTTL = time.Second
+
+// reserveTimeout bounds a reservation,
+// which doesn't stop if the caller that started it gives up.
+const reserveTimeout = 30 * time.Second
+
+// New creates a Limiter, stored in the given bucket and object,
+// that allows events up to rate per second, with bursts of up to burst.
+// It panics if rate is negative (or not finite), or burst isn't positive.
+func New(bucket, object string, rate float64, burst int) *Limiter {
+	if !(rate >= 0) || math.IsInf(rate, 1) {
+		panic("grate: invalid rate")
+	}
+	if burst <= 0 {
+		panic("grate: invalid burst")
+	}
+	return &Limiter{
+		bucket: bucket,
+		object: object,
+		rate:   rate,
+		burst:  burst,
+	}
+}
+
+// Allow reports whether an event may happen now.
+// If this instance is out of reserved tokens,
+// it waits for a batch to be reserved, or until ctx is done.
+func (l *Limiter) Allow(ctx context.Context) (bool, error) {
+	for {
+		l.mtx.Lock()
+		now := time.Now()
+		if now.Sub(l.reserved) > reservationTTL {
+			l.tokens = 0
+		}
+		if l.tokens > 0 {
+			l.tokens--
+			l.mtx.Unlock()
+			return true, nil
+		}
+		if now.Before(l.retry) {
+			l.mtx.Unlock()
+			return false, nil
+		}
+
+		// Join the reservation in flight, or start one.
+		r := l.reserving
+		if r == nil {
+			r = &reservation{done: make(chan struct{})}
+			l.reserving = r
+			go l.reserve(context.WithoutCancel(ctx), r)
+		}
+		l.mtx.Unlock()
+
+		select {
+		case <-r.done:
+			if r.err != nil {
+				return false, r.err
+			}
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// Wait blocks until an event may happen,
+// or until ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		ok, err := l.Allow(ctx)
+		if ok || err != nil {
+			return err
+		}
+
+		l.mtx.Lock()
+		delay := time.Until(l.retry)
+		l.mtx.Unlock()
+		if delay < 10*time.Millisecond {
+			delay = 10 * time.Millisecond
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a batch of tokens from the shared bucket,
+// for the callers waiting for r.
+// If none are available, it sets when to retry.
+func (l *Limiter) reserve(ctx context.Context, r *reservation) {
+	ctx, cancel := context.WithTimeout(ctx, reserveTimeout)
+	defer cancel()
+	taken, missing, err := l.take(ctx)
+
+	l.mtx.Lock()
+	if err == nil {
+		now := time.Now()
+		l.tokens = taken
+		l.reserved = now
+		if taken == 0 && l.rate > 0 {
+			l.retry = now.Add(time.Duration(missing / l.rate * float64(time.Second)))
+		} else if taken == 0 {
+			l.retry = now.Add(time.Minute)
+		}
+	}
+	r.err = err
+	l.reserving = nil
+	l.mtx.Unlock()
+	close(r.done)
+}
+
+// take takes up to a batch of tokens from the shared bucket,
+// returning how many were taken,
+// and how many are missing for the next one.
+func (l *Limiter) take(ctx context.Context) (taken int, missing float64, err error) {
+	batch := l.Batch
+	if batch <= 0 {
+		batch = int(math.Max(1, l.rate))
+	}
+	if batch > l.burst {
+		batch = l.burst
+	}
+
+	b, err := gmutex.NewBackend(ctx, l.bucket, l.object)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var backoff gmutex.BackOff
+	for {
+		var s state
+		var buf bytes.Buffer
+		status, attrs, err := b.Inspect(ctx, &buf)
+		switch {
+		case err != nil:
+			return 0, 0, fmt.Errorf("grate: %w", err)
+		case status == http.StatusNotFound:
+			s.Tokens = float64(l.burst)
+			attrs.Generation = "0"
+		case status == http.StatusOK:
+			if err := json.Unmarshal(buf.Bytes(), &s); err != nil {
+				return 0, 0, fmt.Errorf("grate: %s: %w", l.object, err)
+			}
+			// Refill for the time since the last write, using server time.
+			// Timestamps have second resolution,
+			// but rounding doesn't accumulate across writes.
+			if elapsed := attrs.Date.Sub(attrs.Modified); elapsed > 0 {
+				s.Tokens += l.rate * elapsed.Seconds()
+			}
+			s.Tokens = math.Min(float64(l.burst), s.Tokens)
+		case retriable(status):
+			if err := backoff.Wait(ctx); err != nil {
+				return 0, 0, err
+			}
+			continue
+		default:
+			return 0, 0, fmt.Errorf("grate: http status %d: %s", status, http.StatusText(status))
+		}
+
+		taken = int(math.Min(float64(batch), math.Floor(s.Tokens)))
+		s.Tokens -= float64(taken)
+		missing = 1 - s.Tokens
+
+		data, err := json.Marshal(s)
+		if err != nil {
+			return 0, 0, err
+		}
+		status, _, err = b.Create(ctx, attrs.Generation, gmutex.Attrs{}, bytes.NewReader(data))
+		switch {
+		case err != nil:
+			return 0, 0, fmt.Errorf("grate: %w", err)
+		case status == http.StatusOK:
+		case status == http.StatusPreconditionFailed || retriable(status):
+			// Updated concurrently, or throttled: back off, and try again.
+			if err := backoff.Wait(ctx); err != nil {
+				return 0, 0, err
+			}
+			continue
+		default:
+			return 0, 0, fmt.Errorf("grate: http status %d: %s", status, http.StatusText(status))
+		}
+		break
+	}
+
+	return taken, missing, nil
+}
+
+func retriable(status int) bool {
+	return status == http.StatusTooManyRequests ||
+		status == http.StatusServiceUnavailable
+}
@@ -0,0 +1,149 @@
+package grate
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex"
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+)
+
+func TestLimiter(t *testing.T) {
+	gmutextest.Start(t, "bucket")
+
+	ctx := context.Background()
+	a := New("bucket", "limiter", 10, 10)
+	b := New("bucket", "limiter", 10, 10)
+	a.Batch, b.Batch = 4, 4
+
+	// Both instances share a burst of 10.
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		l := a
+		if i%2 != 0 {
+			l = b
+		}
+		ok, err := l.Allow(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			allowed++
+		}
+	}
+	if allowed < 8 || allowed > 10 {
+		t.Errorf("allowed %d events, want at most 10", allowed)
+	}
+
+	// Tokens are refilled over time.
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := a.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Errorf("Wait() returned after %v, want throttling", time.Since(start))
+	}
+}
+
+func TestLimiter_serverTime(t *testing.T) {
+	server := gmutextest.Start(t, "bucket")
+	ctx := context.Background()
+
+	// Exhaust the burst.
+	a := New("bucket", "limiter", 0.01, 2)
+	for i := 0; i < 2; i++ {
+		if ok, err := a.Allow(ctx); err != nil || !ok {
+			t.Fatalf("Allow() = %v, %v, want true", ok, err)
+		}
+	}
+	b := New("bucket", "limiter", 0.01, 2)
+	if ok, err := b.Allow(ctx); err != nil || ok {
+		t.Fatalf("Allow() = %v, %v, want false", ok, err)
+	}
+
+	// Tokens are refilled by server time, not the local clock.
+	server.Advance(10 * time.Minute)
+	c := New("bucket", "limiter", 0.01, 2)
+	c.Batch = 2
+	for i := 0; i < 2; i++ {
+		if ok, err := c.Allow(ctx); err != nil || !ok {
+			t.Fatalf("Allow() = %v, %v, want true", ok, err)
+		}
+	}
+	if ok, err := c.Allow(ctx); err != nil || ok {
+		t.Fatalf("Allow() = %v, %v, want false: refill is capped by burst", ok, err)
+	}
+}
+
+func TestNew_invalid(t *testing.T) {
+	tests := []struct {
+		rate  float64
+		burst int
+	}{
+		{-1, 1},
+		{math.NaN(), 1},
+		{math.Inf(1), 1},
+		{1, 0},
+		{1, -1},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New(%v, %d) didn't panic", tt.rate, tt.burst)
+				}
+			}()
+			New("bucket", "limiter", tt.rate, tt.burst)
+		}()
+	}
+}
+
+func TestLimiter_Allow_canceled(t *testing.T) {
+	server := gmutextest.Start(t, "bucket")
+
+	// Hold requests to Cloud Storage until released.
+	release := make(chan struct{})
+	transport := server.Client().Transport
+	gmutex.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		<-release
+		return transport.RoundTrip(req)
+	})}
+
+	// Callers waiting for a reservation give up when their ctx is done,
+	// rather than waiting for each other.
+	l := New("bucket", "limiter", 10, 10)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if _, err := l.Allow(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Allow() = %v, want context.DeadlineExceeded", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("canceled callers took %v", elapsed)
+	}
+
+	// The reservation they started completes for later callers.
+	close(release)
+	if ok, err := l.Allow(context.Background()); err != nil || !ok {
+		t.Fatalf("Allow() = %v, %v, want true", ok, err)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
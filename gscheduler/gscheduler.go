@@ -0,0 +1,89 @@
+// Package gscheduler handles Cloud Scheduler HTTP invocations on Cloud Run.
+package gscheduler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ncruces/go-gcp/gauth"
+	"github.com/ncruces/go-gcp/glog"
+	"github.com/ncruces/go-gcp/gmutex"
+)
+
+// Verifier authenticates invocations,
+// which must carry an ID token (configure the job's OIDC token).
+// If nil, authentication is left to Cloud Run.
+var Verifier *gauth.Verifier
+
+// Header, if set, names a header that invocations must carry,
+// with Secret as its value (configure it in the job's headers).
+// If Header is set, but Secret is empty, all invocations are rejected.
+var Header, Secret string
+
+// Bucket is the Cloud Storage bucket that holds the locks
+// that prevent overlapping runs of a job.
+// It defaults to the GSCHEDULER_BUCKET environment variable;
+// if empty, overlapping runs are not prevented.
+var Bucket = os.Getenv("GSCHEDULER_BUCKET")
+
+// Handler returns an http.Handler that authenticates Cloud Scheduler
+// invocations of the job with the given name, and runs fn.
+//
+// If Bucket is set, fn runs under a lock with the given time-to-live
+// (see gmutex.RunExclusive), and invocations that overlap a running one
+// are logged, and skipped.
+//
+// Invocations that fail authentication get a 401 Unauthorized,
+// or 403 Forbidden, response; skipped invocations a 200 OK,
+// so Cloud Scheduler doesn't retry them;
+// and those where fn fails a 500 Internal Server Error,
+// so Cloud Scheduler retries them, according to the job's retry config.
+func Handler(name string, ttl time.Duration, fn func(ctx context.Context) error) http.Handler {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := glog.ForRequest(r)
+		log.SetLabel("job", name)
+
+		if Header != "" && Secret == "" {
+			log.Errorf("gscheduler: rejected invocation of %s: shared header without a secret", name)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		if Header != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(Header)), []byte(Secret)) != 1 {
+			log.Warningf("gscheduler: rejected invocation of %s: missing shared header", name)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		ctx := glog.NewContext(r.Context(), log)
+		var err error
+		if Bucket == "" {
+			err = fn(ctx)
+		} else {
+			err = gmutex.RunExclusive(ctx, Bucket, "gscheduler/"+name, ttl, fn)
+		}
+
+		var held *gmutex.HeldError
+		switch {
+		case err == nil:
+			w.WriteHeader(http.StatusNoContent)
+		case errors.As(err, &held):
+			log.Noticef("gscheduler: skipped overlapping invocation of %s: %v", name, err)
+			w.WriteHeader(http.StatusOK)
+		default:
+			log.Errorf("gscheduler: %s: %v", name, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Verifier == nil {
+			h.ServeHTTP(w, r)
+		} else {
+			Verifier.Handler(h).ServeHTTP(w, r)
+		}
+	})
+}
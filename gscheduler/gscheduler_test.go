@@ -0,0 +1,98 @@
+package gscheduler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/gmutex/gmutextest"
+)
+
+func TestHandler(t *testing.T) {
+	gmutextest.Start(t, "bucket")
+	Bucket = "bucket"
+	defer func() { Bucket = "" }()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var fail bool
+	handler := Handler("job", time.Minute, func(ctx context.Context) error {
+		if fail {
+			return errors.New("fail")
+		}
+		close(started)
+		<-release
+		return nil
+	})
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+		done <- rec.Code
+	}()
+	<-started
+
+	// Overlapping invocations are skipped.
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("overlapping status = %d, want 200", rec.Code)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", code)
+	}
+
+	fail = true
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("failed status = %d, want 500", rec.Code)
+	}
+}
+
+func TestHandler_header(t *testing.T) {
+	Header, Secret = "X-Scheduler-Secret", "s3cr3t"
+	defer func() { Header, Secret = "", "" }()
+
+	handler := Handler("job", time.Minute, func(ctx context.Context) error { return nil })
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Scheduler-Secret", "s3cr3t")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", rec.Code)
+	}
+}
+
+func TestHandler_headerWithoutSecret(t *testing.T) {
+	// Like an unset environment variable.
+	Header, Secret = "X-Scheduler-Secret", ""
+	defer func() { Header, Secret = "", "" }()
+
+	handler := Handler("job", time.Minute, func(ctx context.Context) error { return nil })
+
+	for _, value := range []string{"", "guess"} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if value != "" {
+			req.Header.Set("X-Scheduler-Secret", value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want 403", rec.Code)
+		}
+	}
+}
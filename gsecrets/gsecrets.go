@@ -0,0 +1,183 @@
+// Package gsecrets accesses Secret Manager secrets, with caching.
+package gsecrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2/google"
+
+	"github.com/ncruces/go-gcp/gmeta"
+)
+
+// HTTPClient should be set to an http.Client before first use.
+// If unset google.DefaultClient will be used.
+var HTTPClient *http.Client
+
+// TTL is how long the latest version of a secret is cached,
+// before it's fetched again.
+// Pinned versions never change, so they're cached indefinitely.
+var TTL = 5 * time.Minute
+
+// staleTTL is how long a stale value is used, after failing to refresh it,
+// before trying again.
+const staleTTL = 30 * time.Second
+
+var endpoint = "https://secretmanager.googleapis.com/v1/"
+
+var (
+	initMtx  sync.Mutex
+	cacheMtx sync.Mutex
+	cache    = map[string]*entry{}
+)
+
+type entry struct {
+	sync.Mutex
+	value   []byte
+	expires time.Time
+}
+
+func initClient(ctx context.Context) (err error) {
+	initMtx.Lock()
+	defer initMtx.Unlock()
+	if HTTPClient == nil {
+		const scope = "https://www.googleapis.com/auth/cloud-platform"
+		HTTPClient, err = google.DefaultClient(ctx, scope)
+	}
+	return err
+}
+
+// Get returns the value of a secret version.
+//
+// The name can be a full resource name, like
+// projects/PROJECT_ID/secrets/SECRET_ID/versions/VERSION,
+// or be relative to the current project, like SECRET_ID/versions/VERSION.
+// If the version is omitted, the latest version is used.
+//
+// Values are cached: pinned versions indefinitely,
+// the latest version for TTL.
+// If refreshing the latest version fails, the stale value is returned,
+// and used for a while before trying again.
+func Get(ctx context.Context, name string) ([]byte, error) {
+	name, err := resourceName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheMtx.Lock()
+	e := cache[name]
+	if e == nil {
+		e = &entry{}
+		cache[name] = e
+	}
+	cacheMtx.Unlock()
+
+	e.Lock()
+	defer e.Unlock()
+
+	if e.value != nil && time.Now().Before(e.expires) {
+		return bytes.Clone(e.value), nil
+	}
+
+	value, err := access(ctx, name)
+	if err != nil {
+		if e.value != nil {
+			e.expires = time.Now().Add(staleTTL)
+			return bytes.Clone(e.value), nil
+		}
+		return nil, err
+	}
+
+	e.value = value
+	if strings.HasSuffix(name, "/versions/latest") {
+		e.expires = time.Now().Add(TTL)
+	} else {
+		e.expires = time.Unix(1<<62, 0)
+	}
+	return bytes.Clone(value), nil
+}
+
+// GetJSON decodes the JSON value of a secret version into v (see Get).
+func GetJSON(ctx context.Context, name string, v any) error {
+	value, err := Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(value, v); err != nil {
+		return fmt.Errorf("gsecrets: %s: %w", name, err)
+	}
+	return nil
+}
+
+func resourceName(name string) (string, error) {
+	if !strings.HasPrefix(name, "projects/") {
+		project, err := gmeta.ProjectID()
+		if err != nil {
+			return "", err
+		}
+		name = "projects/" + project + "/secrets/" + name
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	return name, nil
+}
+
+func access(ctx context.Context, name string) ([]byte, error) {
+	if err := initClient(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+name+":access", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(res.Body).Decode(&e)
+		if e.Error.Message == "" {
+			e.Error.Message = http.StatusText(res.StatusCode)
+		}
+		return nil, fmt.Errorf("gsecrets: %s: http status %d: %s", name, res.StatusCode, e.Error.Message)
+	}
+
+	var out struct {
+		Payload struct {
+			Data       []byte `json:"data"`
+			DataCRC32C string `json:"dataCrc32c"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("gsecrets: %s: %w", name, err)
+	}
+	if out.Payload.DataCRC32C != "" {
+		want, _ := strconv.ParseUint(out.Payload.DataCRC32C, 10, 32)
+		if crc32.Checksum(out.Payload.Data, castagnoli) != uint32(want) {
+			return nil, fmt.Errorf("gsecrets: %s: checksum mismatch", name)
+		}
+	}
+	if out.Payload.Data == nil {
+		out.Payload.Data = []byte{}
+	}
+	return out.Payload.Data, nil
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
@@ -0,0 +1,120 @@
+package gsecrets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+	requests := map[string]int{}
+	value := "v1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/projects/project/secrets/api-key/versions/latest:access":
+		case "/projects/project/secrets/api-key/versions/1:access":
+			value = "v1"
+		case "/projects/other/secrets/config/versions/latest:access":
+			value = `{"user":"admin"}`
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"message":"Secret not found"}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"payload":{"data":%q,"dataCrc32c":"%d"}}`,
+			base64.StdEncoding.EncodeToString([]byte(value)),
+			crc32.Checksum([]byte(value), castagnoli))
+	}))
+	defer server.Close()
+
+	os.Setenv("GOOGLE_CLOUD_PROJECT", "project")
+	defer os.Unsetenv("GOOGLE_CLOUD_PROJECT")
+	HTTPClient = server.Client()
+	endpoint = server.URL + "/"
+	ctx := context.Background()
+
+	get := func(name, want string) {
+		t.Helper()
+		got, err := Get(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("Get(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	get("api-key", "v1")
+	value = "v2"
+	get("api-key", "v1") // cached
+	if n := requests["/projects/project/secrets/api-key/versions/latest:access"]; n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+
+	defer func(ttl time.Duration) { TTL = ttl }(TTL)
+	TTL = 0
+	cache = map[string]*entry{}
+	get("api-key", "v2")
+	get("api-key/versions/1", "v1")
+	get("api-key/versions/1", "v1")
+	if n := requests["/projects/project/secrets/api-key/versions/1:access"]; n != 1 {
+		t.Errorf("got %d requests for a pinned version, want 1", n)
+	}
+
+	var config struct{ User string }
+	if err := GetJSON(ctx, "projects/other/secrets/config", &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.User != "admin" {
+		t.Errorf("GetJSON() = %+v", config)
+	}
+
+	if _, err := Get(ctx, "missing"); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestGet_stale(t *testing.T) {
+	var requests int
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"payload":{"data":%q}}`, base64.StdEncoding.EncodeToString([]byte("value")))
+	}))
+	defer server.Close()
+
+	defer func(c *http.Client, e string, ttl time.Duration) { HTTPClient, endpoint, TTL = c, e, ttl }(HTTPClient, endpoint, TTL)
+	HTTPClient = server.Client()
+	endpoint = server.URL + "/"
+	TTL = 0
+	cache = map[string]*entry{}
+	ctx := context.Background()
+
+	if _, err := Get(ctx, "projects/project/secrets/stale"); err != nil {
+		t.Fatal(err)
+	}
+
+	// During an outage, the stale value is returned,
+	// and refreshing it isn't retried right away.
+	failing = true
+	for i := 0; i < 3; i++ {
+		got, err := Get(ctx, "projects/project/secrets/stale")
+		if err != nil || string(got) != "value" {
+			t.Fatalf("Get() = %q, %v, want stale value", got, err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+}
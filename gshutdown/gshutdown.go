@@ -0,0 +1,148 @@
+// Package gshutdown implements graceful termination for Cloud Run.
+//
+// On SIGTERM, Cloud Run gives an instance 10 seconds to shut down.
+// Within that grace period, the server should stop accepting requests,
+// and drain in-flight ones; then, locks should be released,
+// and buffered traces, and logs, flushed.
+package gshutdown
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ncruces/go-gcp/glog"
+	"github.com/ncruces/go-gcp/gmutex"
+	"github.com/ncruces/go-gcp/gtrace"
+)
+
+// Grace is the time allowed for shutdown, after SIGTERM is received.
+// Cloud Run sends SIGTERM 10 seconds before shutting an instance down.
+var Grace = 9 * time.Second
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// state is what's needed to shut down once:
+// the registered hooks, the context to cancel, and the result.
+// The package functions use std.
+type state struct {
+	mtx    sync.Mutex
+	hooks  []hook
+	once   sync.Once
+	result error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var std = newState()
+
+func newState() *state {
+	s := &state{}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+// OnShutdown registers fn to run on shutdown.
+// Hooks run in the order they were registered,
+// after the server is drained,
+// and before traces, and logs, are flushed.
+func OnShutdown(name string, fn func(ctx context.Context) error) {
+	std.onShutdown(name, fn)
+}
+
+func (s *state) onShutdown(name string, fn func(ctx context.Context) error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.hooks = append(s.hooks, hook{name, fn})
+}
+
+// OnShutdownUnlock registers m to be unlocked on shutdown, if held,
+// so other instances don't wait for the lock to expire.
+func OnShutdownUnlock(m *gmutex.Mutex) {
+	OnShutdown("unlock "+m.String(), func(ctx context.Context) error {
+		_, err := m.UnlockIfHeld(ctx)
+		return err
+	})
+}
+
+// Context returns a context that is canceled when shutdown starts,
+// so background work can stop.
+func Context() context.Context {
+	return std.ctx
+}
+
+// ListenAndServe calls server.ListenAndServe, and on SIGTERM
+// (or interrupt) shuts down the server, waiting for in-flight requests,
+// and runs shutdown hooks (see Shutdown), all within Grace.
+//
+// Returns once shutdown completes, or if the server fails.
+func ListenAndServe(server *http.Server) error {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(sig)
+	return std.serve(server, server.ListenAndServe, sig)
+}
+
+func (s *state) serve(server *http.Server, listen func() error, sig <-chan os.Signal) error {
+	errc := make(chan error, 1)
+	go func() { errc <- listen() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case sig := <-sig:
+		glog.Noticef("gshutdown: received %v, shutting down", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), Grace)
+	defer cancel()
+
+	serr := server.Shutdown(ctx)
+	if lerr := <-errc; !errors.Is(lerr, http.ErrServerClosed) && serr == nil {
+		serr = lerr
+	}
+	if serr != nil {
+		glog.Errorf("gshutdown: server: %v", serr)
+	}
+	return errors.Join(serr, s.shutdown(ctx))
+}
+
+// Shutdown cancels Context, and runs shutdown hooks:
+// first those registered with OnShutdown, in order;
+// then gtrace.Shutdown; then glog.Sync.
+// Failing hooks are logged, and don't stop the following ones.
+// Hooks run once; later calls return the same result.
+func Shutdown(ctx context.Context) error {
+	return std.shutdown(ctx)
+}
+
+func (s *state) shutdown(ctx context.Context) error {
+	s.once.Do(func() {
+		s.cancel()
+
+		s.mtx.Lock()
+		all := append(s.hooks[:len(s.hooks):len(s.hooks)],
+			hook{"gtrace", gtrace.Shutdown},
+			hook{"glog", func(context.Context) error { return glog.Sync() }})
+		s.mtx.Unlock()
+
+		var errs []error
+		for _, h := range all {
+			if e := h.fn(ctx); e != nil {
+				glog.Errorf("gshutdown: %s: %v", h.name, e)
+				errs = append(errs, e)
+			}
+		}
+		s.result = errors.Join(errs...)
+	})
+	return s.result
+}
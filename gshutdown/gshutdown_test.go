@@ -0,0 +1,102 @@
+package gshutdown
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestListenAndServe(t *testing.T) {
+	// Each run uses its own state, so shutdown can be tested repeatedly.
+	for i := 0; i < 2; i++ {
+		testServe(t, newState())
+	}
+}
+
+func testServe(t *testing.T, s *state) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var completed atomic.Bool
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+		completed.Store(true)
+	})}
+
+	var order []string
+	s.onShutdown("first", func(ctx context.Context) error {
+		if !completed.Load() {
+			t.Error("hook ran before the server drained")
+		}
+		order = append(order, "first")
+		return nil
+	})
+	s.onShutdown("second", func(ctx context.Context) error {
+		order = append(order, "second")
+		return nil
+	})
+
+	sig := make(chan os.Signal, 1)
+	done := make(chan error)
+	go func() { done <- s.serve(server, func() error { return server.Serve(ln) }, sig) }()
+
+	// Start a slow request before the signal.
+	body := make(chan string)
+	go func() {
+		res, err := http.Get("http://" + addr)
+		if err != nil {
+			t.Error(err)
+			body <- ""
+			return
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		body <- string(b)
+	}()
+	<-started
+
+	if s.ctx.Err() != nil {
+		t.Fatal("context canceled before shutdown")
+	}
+	sig <- syscall.SIGTERM
+
+	// While draining, new connections are refused.
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("new connections accepted while draining")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	if b := <-body; b != "done" {
+		t.Errorf("in-flight request got %q, want done", b)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("hooks ran in order %v", order)
+	}
+	if s.ctx.Err() == nil {
+		t.Error("context not canceled after shutdown")
+	}
+}
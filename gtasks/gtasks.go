@@ -0,0 +1,138 @@
+// Package gtasks handles Cloud Tasks HTTP target requests on Cloud Run.
+package gtasks
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ncruces/go-gcp/gauth"
+	"github.com/ncruces/go-gcp/glog"
+)
+
+// A Task describes the task being executed,
+// from the X-CloudTasks-* request headers.
+type Task struct {
+	QueueName        string    // the short name of the queue
+	TaskName         string    // the short name of the task
+	RetryCount       int       // number of times the task was retried
+	ExecutionCount   int       // number of times the handler responded
+	ETA              time.Time // schedule time of the task
+	PreviousResponse int       // HTTP status code of the previous attempt
+	RetryReason      string    // reason for retrying the task
+}
+
+type contextKey struct{}
+
+// FromContext returns the Task stored in ctx, if any (see Middleware).
+func FromContext(ctx context.Context) (*Task, bool) {
+	task, ok := ctx.Value(contextKey{}).(*Task)
+	return task, ok
+}
+
+// Middleware returns middleware that only accepts requests from Cloud Tasks,
+// storing the Task in the request's context (see FromContext).
+//
+// If v is not nil, requests must carry an ID token
+// (configure the task's OIDC token) that v accepts;
+// otherwise, authentication is left to Cloud Run.
+// If queues are given, only requests from those queues are accepted.
+// Rejected requests get a 401 Unauthorized, or 403 Forbidden, response.
+func Middleware(v *gauth.Verifier, queues ...string) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		tasks := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			task, ok := parseTask(r.Header)
+			if !ok || len(queues) > 0 && !contains(queues, task.QueueName) {
+				glog.ForRequest(r).Warningf("gtasks: rejected request from queue %q", task.QueueName)
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, task)))
+		})
+		if v == nil {
+			return tasks
+		}
+		return v.Handler(tasks)
+	}
+}
+
+func parseTask(h http.Header) (*Task, bool) {
+	task := Task{
+		QueueName:   h.Get("X-CloudTasks-QueueName"),
+		TaskName:    h.Get("X-CloudTasks-TaskName"),
+		RetryReason: h.Get("X-CloudTasks-TaskRetryReason"),
+	}
+	task.RetryCount, _ = strconv.Atoi(h.Get("X-CloudTasks-TaskRetryCount"))
+	task.ExecutionCount, _ = strconv.Atoi(h.Get("X-CloudTasks-TaskExecutionCount"))
+	task.PreviousResponse, _ = strconv.Atoi(h.Get("X-CloudTasks-TaskPreviousResponse"))
+	if eta, err := strconv.ParseFloat(h.Get("X-CloudTasks-TaskETA"), 64); err == nil {
+		sec, frac := math.Modf(eta)
+		task.ETA = time.Unix(int64(sec), int64(frac*1e9))
+	}
+	return &task, task.QueueName != "" && task.TaskName != ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrRetryLater asks Cloud Tasks to retry the task later.
+// It is reported as 503 Service Unavailable,
+// which also has the queue back off.
+var ErrRetryLater = errors.New("gtasks: retry later")
+
+// Permanent wraps err to indicate the task should not be retried.
+// The error is logged, and the task acknowledged.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
+
+// A HandlerFunc handles a task, reporting the outcome as an error.
+//
+// Returning nil, or a Permanent error, completes the task.
+// Returning ErrRetryLater (or an error wrapping it) retries the task,
+// with the queue backing off; any other error retries the task.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP implements http.Handler,
+// mapping errors returned by fn to status codes.
+// Handlers that return errors should not write a response.
+func (fn HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := fn(w, r)
+	if err == nil {
+		return
+	}
+
+	log := glog.ForRequest(r)
+	if task, ok := FromContext(r.Context()); ok {
+		log.SetLabel("task_name", task.TaskName)
+	}
+
+	var permanent permanentError
+	switch {
+	case errors.As(err, &permanent):
+		log.Errorf("gtasks: dropping task: %v", permanent.error)
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, ErrRetryLater):
+		log.Infof("gtasks: %v", err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	default:
+		log.Errorf("gtasks: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,64 @@
+package gtasks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	var got *Task
+	var ret error
+	handler := Middleware(nil, "my-queue")(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		got, _ = FromContext(r.Context())
+		return ret
+	}))
+
+	request := func(queue string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("X-CloudTasks-QueueName", queue)
+		req.Header.Set("X-CloudTasks-TaskName", "1234")
+		req.Header.Set("X-CloudTasks-TaskRetryCount", "2")
+		req.Header.Set("X-CloudTasks-TaskExecutionCount", "1")
+		req.Header.Set("X-CloudTasks-TaskETA", "1700000000.5")
+		return req
+	}
+
+	tests := []struct {
+		queue  string
+		ret    error
+		status int
+	}{
+		{"my-queue", nil, http.StatusOK},
+		{"other-queue", nil, http.StatusForbidden},
+		{"", nil, http.StatusForbidden},
+		{"my-queue", ErrRetryLater, http.StatusServiceUnavailable},
+		{"my-queue", fmt.Errorf("quota: %w", ErrRetryLater), http.StatusServiceUnavailable},
+		{"my-queue", errors.New("fail"), http.StatusInternalServerError},
+		{"my-queue", Permanent(errors.New("bad payload")), http.StatusOK},
+	}
+	for _, tt := range tests {
+		got, ret = nil, tt.ret
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, request(tt.queue))
+		if rec.Code != tt.status {
+			t.Errorf("queue %q, error %v: status = %d, want %d", tt.queue, tt.ret, rec.Code, tt.status)
+		}
+	}
+
+	got = nil
+	handler.ServeHTTP(httptest.NewRecorder(), request("my-queue"))
+	want := Task{
+		QueueName:      "my-queue",
+		TaskName:       "1234",
+		RetryCount:     2,
+		ExecutionCount: 1,
+		ETA:            time.Unix(1700000000, 5e8),
+	}
+	if got == nil || *got != want {
+		t.Errorf("task = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package gtrace
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ncruces/go-gcp/glog"
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// WithBaggage returns a copy of ctx that carries the request-scoped
+// key/value pair (for example, a tenant or an experiment).
+//
+// Baggage is propagated by the tracing HTTP clients and handlers
+// (in the W3C Baggage format), set as attributes on spans started from ctx,
+// and as labels on entries logged with glog.ForContext(ctx).
+func WithBaggage(ctx context.Context, key, value string) context.Context {
+	m, err := baggage.NewMemberRaw(key, value)
+	if err != nil {
+		return ctx
+	}
+	b, err := baggage.FromContext(ctx).SetMember(m)
+	if err != nil {
+		return ctx
+	}
+	ctx = baggage.ContextWithBaggage(ctx, b)
+
+	log := glog.ForContext(ctx)
+	log.SetLabel(key, value)
+	return glog.NewContext(ctx, log)
+}
+
+// Baggage returns the request-scoped key/value pairs carried by ctx.
+func Baggage(ctx context.Context) map[string]string {
+	members := baggage.FromContext(ctx).Members()
+	if len(members) == 0 {
+		return nil
+	}
+	kvs := make(map[string]string, len(members))
+	for _, m := range members {
+		kvs[m.Key()] = m.Value()
+	}
+	return kvs
+}
+
+// baggageTransport injects baggage into outgoing requests.
+type baggageTransport struct {
+	base http.RoundTripper
+}
+
+func (t baggageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if b := baggage.FromContext(req.Context()); b.Len() > 0 {
+		req = req.Clone(req.Context())
+		propagation.Baggage{}.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// baggageHandler extracts baggage from incoming requests,
+// and sets it as attributes on the request's span,
+// and as labels on the request's logger.
+func baggageHandler(h http.Handler) http.Handler {
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagation.Baggage{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		kvs := Baggage(ctx)
+		if len(kvs) > 0 {
+			log := glog.ForContext(ctx)
+			for k, v := range kvs {
+				log.SetLabel(k, v)
+			}
+			ctx = glog.NewContext(ctx, log)
+			if span := trace.FromContext(ctx); span != nil {
+				span.AddAttributes(ocAttributes(kvs)...)
+			}
+			r = r.WithContext(ctx)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func ocAttributes(kvs map[string]string) []trace.Attribute {
+	attrs := make([]trace.Attribute, 0, len(kvs))
+	for k, v := range kvs {
+		attrs = append(attrs, trace.StringAttribute(k, v))
+	}
+	return attrs
+}
+
+// baggageProcessor sets baggage as attributes on OpenTelemetry spans.
+type baggageProcessor struct{}
+
+func (baggageProcessor) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {
+	for _, m := range baggage.FromContext(ctx).Members() {
+		s.SetAttributes(attribute.String(m.Key(), m.Value()))
+	}
+}
+
+func (baggageProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (baggageProcessor) Shutdown(context.Context) error   { return nil }
+func (baggageProcessor) ForceFlush(context.Context) error { return nil }
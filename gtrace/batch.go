@@ -0,0 +1,89 @@
+package gtrace
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var batching = batchConfig{
+	queue:    sdktrace.DefaultMaxQueueSize,
+	batch:    sdktrace.DefaultMaxExportBatchSize,
+	interval: sdktrace.DefaultScheduleDelay * time.Millisecond,
+}
+
+var dropped atomic.Uint64
+
+type batchConfig struct {
+	queue    int
+	batch    int
+	interval time.Duration
+}
+
+// SetBatching configures how OpenTelemetry spans are buffered for export,
+// and must be called before InitOpenTelemetry.
+// At most queue spans are buffered, and spans are exported in batches
+// of up to batch spans, at least once every interval.
+// Spans that don't fit in the queue are dropped (see DroppedSpans).
+//
+// For OpenCensus, see WithBundleSize, WithBundleDelay, and WithBufferSize.
+func SetBatching(queue, batch int, interval time.Duration) {
+	batching = batchConfig{queue: queue, batch: batch, interval: interval}
+}
+
+// DroppedSpans returns the number of OpenTelemetry spans dropped,
+// because the export queue was full, or because exporting them failed.
+// High-throughput services can monitor it, and tune SetBatching.
+func DroppedSpans() uint64 {
+	return dropped.Load()
+}
+
+// newQueueProcessor returns a processor that batches spans for export,
+// counting the spans it drops.
+func newQueueProcessor(exporter sdktrace.SpanExporter, config batchConfig) sdktrace.SpanProcessor {
+	p := &queueProcessor{max: int64(config.queue)}
+	p.SpanProcessor = sdktrace.NewBatchSpanProcessor(
+		countingExporter{exporter, &p.queued},
+		sdktrace.WithMaxQueueSize(config.queue),
+		sdktrace.WithMaxExportBatchSize(config.batch),
+		sdktrace.WithBatchTimeout(config.interval))
+	return p
+}
+
+// queueProcessor keeps track of queued spans, dropping spans that don't fit,
+// so the batch processor never drops spans silently.
+type queueProcessor struct {
+	sdktrace.SpanProcessor
+	queued atomic.Int64
+	max    int64
+}
+
+func (p *queueProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+	if p.queued.Add(1) > p.max {
+		p.queued.Add(-1)
+		dropped.Add(1)
+		return
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// countingExporter counts exported spans out of the queue,
+// and spans it failed to export as dropped.
+type countingExporter struct {
+	sdktrace.SpanExporter
+	queued *atomic.Int64
+}
+
+func (e countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.queued.Add(-int64(len(spans)))
+	if err != nil {
+		dropped.Add(uint64(len(spans)))
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package gtrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// blockingExporter blocks exports until release is closed.
+type blockingExporter struct {
+	*tracetest.InMemoryExporter
+	release chan struct{}
+}
+
+func (e blockingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	<-e.release
+	return e.InMemoryExporter.ExportSpans(ctx, spans)
+}
+
+// failingExporter fails every export.
+type failingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (failingExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	return errors.New("export failed")
+}
+
+func TestDroppedSpans_overflow(t *testing.T) {
+	exporter := blockingExporter{tracetest.NewInMemoryExporter(), make(chan struct{})}
+	processor := newQueueProcessor(exporter, batchConfig{queue: 2, batch: 2, interval: time.Hour})
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	tracer := tp.Tracer("test")
+
+	// Spans stay queued while the export is blocked,
+	// so only the first 2 fit.
+	before := DroppedSpans()
+	for i := 0; i < 5; i++ {
+		_, span := tracer.Start(context.Background(), "span")
+		span.End()
+	}
+	if n := DroppedSpans() - before; n != 3 {
+		t.Errorf("dropped %d spans, want 3", n)
+	}
+
+	close(exporter.release)
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := DroppedSpans() - before; n != 3 {
+		t.Errorf("dropped %d spans after export, want 3", n)
+	}
+}
+
+func TestDroppedSpans_exportFailed(t *testing.T) {
+	exporter := failingExporter{tracetest.NewInMemoryExporter()}
+	processor := newQueueProcessor(exporter, batchConfig{queue: 16, batch: 16, interval: time.Hour})
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	tracer := tp.Tracer("test")
+
+	before := DroppedSpans()
+	for i := 0; i < 2; i++ {
+		_, span := tracer.Start(context.Background(), "span")
+		span.End()
+	}
+	tp.ForceFlush(context.Background())
+	if n := DroppedSpans() - before; n != 2 {
+		t.Errorf("dropped %d spans, want 2", n)
+	}
+
+	// Failed spans leave the queue, so new spans still fit.
+	if n := processor.(*queueProcessor).queued.Load(); n != 0 {
+		t.Errorf("queued = %d, want 0", n)
+	}
+}
+
+func TestDroppedSpans_unsampled(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	processor := newQueueProcessor(exporter, batchConfig{queue: 1, batch: 1, interval: time.Hour})
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.NeverSample()),
+		sdktrace.WithSpanProcessor(processor))
+
+	// Unsampled spans aren't queued, or counted.
+	before := DroppedSpans()
+	for i := 0; i < 3; i++ {
+		_, span := tp.Tracer("test").Start(context.Background(), "span")
+		span.End()
+	}
+	if n := DroppedSpans() - before; n != 0 {
+		t.Errorf("dropped %d spans, want 0", n)
+	}
+}
@@ -0,0 +1,53 @@
+package gtrace
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// consoleExporter writes spans to the console, one line per span,
+// when there's no Google Cloud project to export them to
+// (for example, in local development).
+type consoleExporter struct {
+	mtx sync.Mutex
+	w   io.Writer
+}
+
+func newConsoleExporter() *consoleExporter {
+	return &consoleExporter{w: os.Stderr}
+}
+
+// ExportSpan implements trace.Exporter.
+func (e *consoleExporter) ExportSpan(s *trace.SpanData) {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%s trace=%s span=%s",
+		s.StartTime.Format(time.TimeOnly), s.TraceID, s.SpanID)
+	if s.ParentSpanID != (trace.SpanID{}) {
+		fmt.Fprintf(&buf, " parent=%s", s.ParentSpanID)
+	}
+	fmt.Fprintf(&buf, " %q %v", s.Name, s.EndTime.Sub(s.StartTime))
+	if s.Status.Code != trace.StatusCodeOK {
+		fmt.Fprintf(&buf, " error=%q", s.Status.Message)
+	}
+
+	keys := make([]string, 0, len(s.Attributes))
+	for k := range s.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, " %s=%v", k, s.Attributes[k])
+	}
+	buf.WriteByte('\n')
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	io.WriteString(e.w, buf.String())
+}
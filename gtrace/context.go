@@ -0,0 +1,47 @@
+package gtrace
+
+import (
+	"context"
+	"net/url"
+
+	"go.opencensus.io/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// TraceID returns the hex encoded ID of the trace in ctx,
+// or an empty string if ctx carries no trace.
+func TraceID(ctx context.Context) string {
+	if sc := oteltrace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	if span := trace.FromContext(ctx); span != nil {
+		return span.SpanContext().TraceID.String()
+	}
+	return ""
+}
+
+// SpanID returns the hex encoded ID of the span in ctx,
+// or an empty string if ctx carries no span.
+func SpanID(ctx context.Context) string {
+	if sc := oteltrace.SpanContextFromContext(ctx); sc.HasSpanID() {
+		return sc.SpanID().String()
+	}
+	if span := trace.FromContext(ctx); span != nil {
+		return span.SpanContext().SpanID.String()
+	}
+	return ""
+}
+
+// TraceURL returns a link to the trace in ctx, in the Cloud Console,
+// or an empty string if ctx carries no trace.
+func TraceURL(ctx context.Context) string {
+	id := TraceID(ctx)
+	if id == "" {
+		return ""
+	}
+	query := url.Values{"tid": {id}}
+	if ProjectID != "" {
+		query.Set("project", ProjectID)
+	}
+	return "https://console.cloud.google.com/traces/list?" + query.Encode()
+}
@@ -0,0 +1,73 @@
+package gtrace
+
+import (
+	"context"
+	"net/http"
+
+	"go.opencensus.io/trace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// DebugHeader is the request header that forces sampling a request,
+// whatever its value, to capture a full trace of a specific request
+// without raising global sampling rates.
+//
+// Anyone that can reach the service can force sampling;
+// consider stripping the header at the edge of public services.
+const DebugHeader = "X-Debug-Trace"
+
+type forceSamplingKey struct{}
+
+// ForceSampling returns a copy of ctx that forces sampling
+// spans started from it (see StartSpan).
+func ForceSampling(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceSamplingKey{}, true)
+}
+
+func forcedSampling(ctx context.Context) bool {
+	forced, _ := ctx.Value(forceSamplingKey{}).(bool)
+	return forced
+}
+
+func debugRequest(r *http.Request) bool {
+	return r.Header.Get(DebugHeader) != ""
+}
+
+// debugStartOptions forces OpenCensus to sample debug requests.
+func debugStartOptions(r *http.Request) trace.StartOptions {
+	if debugRequest(r) {
+		return trace.StartOptions{Sampler: trace.AlwaysSample()}
+	}
+	return trace.StartOptions{}
+}
+
+// debugHandler forces OpenTelemetry to sample debug requests.
+func debugHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if debugRequest(r) {
+			r = r.WithContext(ForceSampling(r.Context()))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// debugSampler samples spans forced by ForceSampling,
+// and delegates other sampling decisions.
+type debugSampler struct {
+	next sdktrace.Sampler
+}
+
+func (s debugSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if forcedSampling(p.ParentContext) {
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.RecordAndSample,
+			Tracestate: oteltrace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
+	}
+	return s.next.ShouldSample(p)
+}
+
+func (s debugSampler) Description() string {
+	return "DebugSampler{" + s.next.Description() + "}"
+}
@@ -0,0 +1,59 @@
+package gtrace
+
+import (
+	"context"
+
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// StartDetachedSpan starts a span in a new trace,
+// linked to the span in ctx (if any),
+// returning a context with the new span, that isn't canceled with ctx.
+// The returned context keeps the values of ctx (like baggage).
+//
+// Use it for background work that outlives the request that started it
+// (for example, on Cloud Run with instance-based CPU allocation).
+// Call End to end the span:
+//
+//	ctx, span := gtrace.StartDetachedSpan(r.Context(), "background")
+//	go func() {
+//		defer span.End(nil)
+//		// Do some work.
+//	}()
+func StartDetachedSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	ctx = context.WithoutCancel(ctx)
+	link := oteltrace.SpanContextFromContext(otelContext(ctx))
+
+	// Drop the parent spans from the context.
+	ctx = trace.NewContext(ctx, nil)
+	ctx = oteltrace.ContextWithSpanContext(ctx, oteltrace.SpanContext{})
+
+	var s Span
+	if provider.Load() != nil {
+		opts := []oteltrace.SpanStartOption{oteltrace.WithNewRoot()}
+		if link.IsValid() {
+			opts = append(opts, oteltrace.WithLinks(oteltrace.Link{SpanContext: link}))
+		}
+		ctx, s.ot = otel.Tracer("github.com/ncruces/go-gcp/gtrace").Start(ctx, name, opts...)
+	} else {
+		var opts []trace.StartOption
+		if forcedSampling(ctx) {
+			opts = append(opts, trace.WithSampler(trace.AlwaysSample()))
+		}
+		ctx, s.oc = trace.StartSpan(ctx, name, opts...)
+		if link.IsValid() {
+			s.oc.AddLink(trace.Link{
+				TraceID: trace.TraceID(link.TraceID()),
+				SpanID:  trace.SpanID(link.SpanID()),
+				Type:    trace.LinkTypeParent,
+			})
+		}
+		if kvs := Baggage(ctx); kvs != nil && s.oc.IsRecordingEvents() {
+			s.oc.AddAttributes(ocAttributes(kvs)...)
+		}
+	}
+	s.SetAttributes(attrs...)
+	return ctx, &s
+}
@@ -0,0 +1,61 @@
+package gtrace
+
+import (
+	"context"
+	"net/http"
+
+	"cloud.google.com/go/functions/metadata"
+	"go.opencensus.io/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// eventAttributes returns attributes describing the event that triggered
+// a Cloud Function, from the function's metadata in ctx, if any.
+func eventAttributes(ctx context.Context) []Attribute {
+	meta, _ := metadata.FromContext(ctx)
+	if meta == nil {
+		return nil
+	}
+	attrs := []Attribute{
+		String("cloudevents.event_id", meta.EventID),
+		String("cloudevents.event_type", meta.EventType),
+	}
+	if meta.Resource != nil {
+		attrs = append(attrs, String("cloudevents.event_source", meta.Resource.Name))
+	}
+	return attrs
+}
+
+// cloudEventAttributes returns attributes describing the CloudEvent
+// delivered (in binary content mode) by r, if any (for example, by Eventarc).
+func cloudEventAttributes(r *http.Request) []Attribute {
+	id := r.Header.Get("Ce-Id")
+	if id == "" {
+		return nil
+	}
+	attrs := []Attribute{
+		String("cloudevents.event_id", id),
+		String("cloudevents.event_type", r.Header.Get("Ce-Type")),
+		String("cloudevents.event_source", r.Header.Get("Ce-Source")),
+	}
+	if subject := r.Header.Get("Ce-Subject"); subject != "" {
+		attrs = append(attrs, String("cloudevents.event_subject", subject))
+	}
+	return attrs
+}
+
+// eventHandler sets attributes describing the CloudEvent delivered
+// by incoming requests, if any, on the request's span.
+func eventHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attrs := cloudEventAttributes(r); attrs != nil {
+			ctx := r.Context()
+			s := Span{oc: trace.FromContext(ctx)}
+			if span := oteltrace.SpanFromContext(ctx); span.SpanContext().IsValid() {
+				s.ot = span
+			}
+			s.SetAttributes(attrs...)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
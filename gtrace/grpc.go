@@ -0,0 +1,101 @@
+package gtrace
+
+import (
+	"context"
+	"net/http"
+
+	"go.opencensus.io/plugin/ocgrpc"
+	ocpropagation "go.opencensus.io/trace/propagation"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	ocbridge "go.opentelemetry.io/otel/bridge/opencensus"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+)
+
+// NewGRPCClientHandler returns a tracing gRPC client stats.Handler,
+// to use with grpc.WithStatsHandler.
+func NewGRPCClientHandler() stats.Handler {
+	return &ocgrpc.ClientHandler{}
+}
+
+// NewGRPCServerHandler returns a tracing gRPC server stats.Handler,
+// to use with grpc.StatsHandler.
+// It accepts traces propagated in the Cloud Trace format.
+func NewGRPCServerHandler() stats.Handler {
+	return &grpcServerHandler{}
+}
+
+// NewOTelGRPCClientHandler returns a gRPC client stats.Handler
+// traced with OpenTelemetry.
+// Traces are propagated in the binary gRPC format (grpc-trace-bin),
+// as well as the HTTP formats.
+func NewOTelGRPCClientHandler() stats.Handler {
+	return otelgrpc.NewClientHandler(otelgrpc.WithPropagators(grpcPropagator))
+}
+
+// NewOTelGRPCServerHandler returns a gRPC server stats.Handler
+// traced with OpenTelemetry.
+// It accepts traces propagated in the binary gRPC format (grpc-trace-bin),
+// or the HTTP formats.
+func NewOTelGRPCServerHandler() stats.Handler {
+	return otelgrpc.NewServerHandler(otelgrpc.WithPropagators(grpcPropagator))
+}
+
+// grpcPropagator propagates traces in the binary gRPC format,
+// and the HTTP formats.
+// When extracting, the last format found wins: prefer the HTTP formats.
+var grpcPropagator = propagation.NewCompositeTextMapPropagator(
+	binaryPropagator{}, propagator)
+
+// binaryPropagator propagates traces in the binary gRPC format,
+// used by Google client libraries, Envoy, and Cloud Endpoints.
+// gRPC base64 encodes metadata with a -bin suffix.
+type binaryPropagator struct{}
+
+const binaryKey = "grpc-trace-bin"
+
+func (binaryPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	if sc := oteltrace.SpanContextFromContext(otelContext(ctx)); sc.IsValid() {
+		carrier.Set(binaryKey, string(ocpropagation.Binary(ocbridge.OTelSpanContextToOC(sc))))
+	}
+}
+
+func (binaryPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	if v := carrier.Get(binaryKey); v != "" {
+		if sc, ok := ocpropagation.FromBinary([]byte(v)); ok {
+			return oteltrace.ContextWithRemoteSpanContext(ctx, ocbridge.OCSpanContextToOTel(sc))
+		}
+	}
+	return ctx
+}
+
+func (binaryPropagator) Fields() []string {
+	return []string{binaryKey}
+}
+
+type grpcServerHandler struct {
+	ocgrpc.ServerHandler
+}
+
+func (h *grpcServerHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	// Google front ends propagate traces with HTTP headers,
+	// convert them to the binary format expected by OpenCensus.
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("grpc-trace-bin")) == 0 {
+		req := http.Request{Header: http.Header{}}
+		for _, k := range []string{"X-Cloud-Trace-Context", "Traceparent", "Tracestate"} {
+			if v := md.Get(k); len(v) > 0 {
+				req.Header.Set(k, v[0])
+			}
+		}
+		if len(req.Header) > 0 {
+			if sc, ok := (&HTTPFormat{}).SpanContextFromRequest(&req); ok {
+				md = md.Copy()
+				md.Set("grpc-trace-bin", string(ocpropagation.Binary(sc)))
+				ctx = metadata.NewIncomingContext(ctx, md)
+			}
+		}
+	}
+	return h.ServerHandler.TagRPC(ctx, info)
+}
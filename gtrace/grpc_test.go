@@ -0,0 +1,129 @@
+package gtrace
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+)
+
+// startGRPC serves the health service, with the given server handler,
+// and returns a client connection with the given client options,
+// and the context of the last call handled by the server.
+func startGRPC(t *testing.T, server stats.Handler, opts ...grpc.DialOption) (healthpb.HealthClient, func() context.Context) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := make(chan context.Context, 1)
+	s := grpc.NewServer(
+		grpc.StatsHandler(server),
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			calls <- ctx
+			return handler(ctx, req)
+		}))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(),
+		append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn), func() context.Context { return <-calls }
+}
+
+func TestGRPCHandlers(t *testing.T) {
+	client, last := startGRPC(t, NewGRPCServerHandler(),
+		grpc.WithStatsHandler(NewGRPCClientHandler()))
+
+	ctx, span := trace.StartSpan(context.Background(), "client", trace.WithSampler(trace.AlwaysSample()))
+	defer span.End()
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := trace.FromContext(last())
+	if server == nil {
+		t.Fatal("no server span")
+	}
+	if got, want := server.SpanContext().TraceID, span.SpanContext().TraceID; got != want {
+		t.Errorf("server trace = %v, want %v", got, want)
+	}
+}
+
+func TestGRPCServerHandler_httpHeaders(t *testing.T) {
+	// Google front ends propagate traces with HTTP headers.
+	client, last := startGRPC(t, NewGRPCServerHandler())
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := trace.FromContext(last())
+	if server == nil {
+		t.Fatal("no server span")
+	}
+	if got := server.SpanContext().TraceID.String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("server trace = %v, want the traceparent's", got)
+	}
+}
+
+func TestOTelGRPCHandlers(t *testing.T) {
+	client, last := startGRPC(t, NewOTelGRPCServerHandler(),
+		grpc.WithStatsHandler(NewOTelGRPCClientHandler()))
+
+	sc := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    oteltrace.TraceID{0x4b, 0xf9, 0x2f, 0x35},
+		SpanID:     oteltrace.SpanID{0x01},
+		TraceFlags: oteltrace.FlagsSampled,
+	})
+	ctx := oteltrace.ContextWithSpanContext(context.Background(), sc)
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := oteltrace.SpanContextFromContext(last()).TraceID(); got != sc.TraceID() {
+		t.Errorf("server trace = %v, want %v", got, sc.TraceID())
+	}
+}
+
+func TestBinaryPropagator(t *testing.T) {
+	sc := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    oteltrace.TraceID{0x4b, 0xf9, 0x2f, 0x35},
+		SpanID:     oteltrace.SpanID{0x01},
+		TraceFlags: oteltrace.FlagsSampled,
+	})
+
+	carrier := propagation.MapCarrier{}
+	binaryPropagator{}.Inject(oteltrace.ContextWithSpanContext(context.Background(), sc), carrier)
+	if carrier[binaryKey] == "" {
+		t.Fatalf("Inject() = %v, want %s", carrier, binaryKey)
+	}
+
+	got := oteltrace.SpanContextFromContext(binaryPropagator{}.Extract(context.Background(), carrier))
+	if got.TraceID() != sc.TraceID() || got.SpanID() != sc.SpanID() || !got.IsSampled() || !got.IsRemote() {
+		t.Errorf("Extract() = %v, want %v", got, sc)
+	}
+
+	// Without a span, nothing is injected.
+	carrier = propagation.MapCarrier{}
+	binaryPropagator{}.Inject(context.Background(), carrier)
+	if len(carrier) != 0 {
+		t.Errorf("Inject() = %v, want empty", carrier)
+	}
+}
@@ -7,30 +7,74 @@ import (
 	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 
 	"contrib.go.opencensus.io/exporter/stackdriver"
 	"contrib.go.opencensus.io/exporter/stackdriver/propagation"
 	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/plugin/ochttp/propagation/tracecontext"
 	"go.opencensus.io/trace"
 )
 
-var once sync.Once
+var (
+	once     sync.Once
+	exporter atomic.Pointer[stackdriver.Exporter]
+	console  atomic.Pointer[consoleExporter]
+)
 
 // ProjectID should be set to the Google Cloud project ID.
 var ProjectID string = os.Getenv("GOOGLE_CLOUD_PROJECT")
 
 // Init initializes Cloud Trace.
+// Spans are annotated with the Cloud Run service, revision,
+// region, and instance, if available.
+// Options configure the exporter; only the first call's are used.
+// If no project is detected (for example, in local development),
+// spans are written to the console instead.
+// If the OTEL_EXPORTER_OTLP_ENDPOINT environment variable is set,
+// spans are exported with OTLP over HTTP (for example, to a local
+// OpenTelemetry Collector, Jaeger, or Zipkin) instead of Cloud Trace:
+// InitOpenTelemetry is called, OpenCensus spans are bridged to
+// its TracerProvider, and options are ignored.
 // Can be called multiple times.
 // Logs the error if called asynchronously.
-func Init() (err error) {
+func Init(opts ...Option) (err error) {
 	callers := runtime.Callers(3, make([]uintptr, 1))
 
 	once.Do(func() {
-		exporter, ierr := stackdriver.NewExporter(stackdriver.Options{
-			ProjectID: ProjectID,
-		})
+		if otlpConfigured() {
+			// Export to a local endpoint, instead of Cloud Trace,
+			// bridging OpenCensus spans to OpenTelemetry.
+			ierr := InitOpenTelemetry()
+			if ierr != nil && callers == 0 {
+				json.NewEncoder(os.Stderr).Encode(map[string]string{
+					"message":  ierr.Error(),
+					"severity": "CRITICAL",
+				})
+			}
+			err = ierr
+			return
+		}
+
+		options := stackdriver.Options{
+			ProjectID:              ProjectID,
+			DefaultTraceAttributes: traceAttributes(resourceAttributes()),
+		}
+		for _, opt := range opts {
+			opt(&options)
+		}
+
+		e, ierr := stackdriver.NewExporter(options)
 		if ierr == nil {
-			trace.RegisterExporter(exporter)
+			trace.RegisterExporter(redactExporter{e})
+			exporter.Store(e)
+			return
+		}
+		if options.ProjectID == "" {
+			// No project detected, export to the console.
+			c := newConsoleExporter()
+			trace.RegisterExporter(redactExporter{c})
+			console.Store(c)
 			return
 		}
 		if callers == 0 {
@@ -47,32 +91,75 @@ func Init() (err error) {
 
 // HTTPFormat implements propagation.HTTPFormat to propagate traces in
 // HTTP headers for Cloud Trace.
+//
+// Traces are propagated in both the X-Cloud-Trace-Context,
+// and the W3C Trace Context (traceparent and tracestate), formats.
+// Incoming traces are accepted in either format,
+// preferring W3C Trace Context.
 type HTTPFormat struct {
 	propagation.HTTPFormat
 }
 
+// SpanContextFromRequest extracts a span context from incoming requests.
+func (f *HTTPFormat) SpanContextFromRequest(req *http.Request) (trace.SpanContext, bool) {
+	if sc, ok := (&tracecontext.HTTPFormat{}).SpanContextFromRequest(req); ok {
+		return sc, true
+	}
+	return f.HTTPFormat.SpanContextFromRequest(req)
+}
+
+// SpanContextToRequest modifies the given request to include
+// span context headers in both formats.
+func (f *HTTPFormat) SpanContextToRequest(sc trace.SpanContext, req *http.Request) {
+	f.HTTPFormat.SpanContextToRequest(sc, req)
+	(&tracecontext.HTTPFormat{}).SpanContextToRequest(sc, req)
+}
+
 // NewHTTPClient returns a tracing http.Client.
+// Tracing clients, and handlers, call Init in the background on first use;
+// to configure it, call Init before using them.
 func NewHTTPClient() *http.Client {
 	return &http.Client{
-		Transport: &ochttp.Transport{
-			// Use Google Cloud propagation format.
-			Propagation: &propagation.HTTPFormat{},
-		},
+		Transport: NewHTTPTransport(),
 	}
 }
 
 // NewHTTPTransport returns a tracing http.RoundTripper.
 func NewHTTPTransport() http.RoundTripper {
-	return &ochttp.Transport{
-		// Use Google Cloud propagation format.
-		Propagation: &propagation.HTTPFormat{},
+	return lazyTransport{
+		base: &ochttp.Transport{
+			// Use Google Cloud propagation formats.
+			Propagation:    &HTTPFormat{},
+			Base:           baggageTransport{},
+			FormatSpanName: FormatSpanName,
+		},
+		init: initLazily,
 	}
 }
 
-// NewHTTPHandler returns a tracing http.Handler.
+// NewHTTPHandler returns a tracing http.Handler,
+// that serves requests with http.DefaultServeMux.
+// See WrapHTTPHandler.
 func NewHTTPHandler() http.Handler {
-	return &ochttp.Handler{
-		// Use the Google Cloud propagation format.
-		Propagation: &propagation.HTTPFormat{},
+	return WrapHTTPHandler(http.DefaultServeMux)
+}
+
+// WrapHTTPHandler returns a tracing http.Handler,
+// that serves requests with h.
+// Requests with a DebugHeader are always sampled.
+// Requests that deliver CloudEvents (for example, from Eventarc)
+// are annotated with the event's ID, type, and source.
+func WrapHTTPHandler(h http.Handler, opts ...HandlerOption) http.Handler {
+	handler := &ochttp.Handler{
+		// Use the Google Cloud propagation formats.
+		Propagation:      &HTTPFormat{},
+		Handler:          baggageHandler(eventHandler(h)),
+		FormatSpanName:   FormatSpanName,
+		IsHealthEndpoint: IsHealthCheck,
+		GetStartOptions:  debugStartOptions,
+	}
+	for _, opt := range opts {
+		opt(handler)
 	}
+	return lazyHandler(handler, initLazily)
 }
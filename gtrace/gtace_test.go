@@ -1,11 +1,20 @@
 package gtrace_test
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/ncruces/go-gcp/glog"
 	"github.com/ncruces/go-gcp/gtrace"
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc"
 )
 
 func Example() {
@@ -21,3 +30,299 @@ func Example() {
 
 	glog.Critical(http.ListenAndServe(":"+port, gtrace.NewHTTPHandler()))
 }
+
+func ExampleInitOpenTelemetry() {
+	go gtrace.InitOpenTelemetry()
+	glog.Notice("Starting server...")
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	http.HandleFunc("/", http.NotFound)
+
+	glog.Critical(http.ListenAndServe(":"+port, gtrace.NewOTelHTTPHandler()))
+}
+
+func ExampleSetTailSampling() {
+	// Export failed, and slow, requests, and 1% of the rest.
+	gtrace.SetTailSampling(time.Second, 0.01)
+	go gtrace.InitOpenTelemetry()
+
+	http.HandleFunc("/", http.NotFound)
+
+	glog.Critical(http.ListenAndServe(":8080", gtrace.NewOTelHTTPHandler()))
+}
+
+func ExampleStartSpan() {
+	ctx := context.Background()
+
+	work := func(ctx context.Context) (err error) {
+		ctx, span := gtrace.StartSpan(ctx, "work", gtrace.Int("items", 42))
+		defer func() { span.End(err) }()
+
+		// Do some work, traced by ctx.
+		fmt.Println(gtrace.TraceID(ctx) != "")
+		return nil
+	}
+
+	fmt.Println(work(ctx))
+	// Output:
+	// true
+	// <nil>
+}
+
+func ExampleHTTPFormat() {
+	sc := trace.SpanContext{
+		TraceID:      trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:       trace.SpanID{0, 0, 0, 0, 0, 0, 0, 1},
+		TraceOptions: 1,
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	(&gtrace.HTTPFormat{}).SpanContextToRequest(sc, req)
+	fmt.Println(req.Header.Get("X-Cloud-Trace-Context"))
+	fmt.Println(req.Header.Get("Traceparent"))
+
+	// Either format is accepted.
+	req.Header.Del("Traceparent")
+	got, ok := (&gtrace.HTTPFormat{}).SpanContextFromRequest(req)
+	fmt.Println(ok, got.TraceID == sc.TraceID)
+	// Output:
+	// 4bf92f3577b34da6a3ce929d0e0e4736/1;o=1
+	// 00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000001-01
+	// true true
+}
+
+func ExampleInstrument() {
+	go gtrace.Init()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Logs with request metadata, correlated with the request's trace.
+		glog.ForContext(r.Context()).Info("Handling request...")
+		http.NotFound(w, r)
+	})
+
+	glog.Critical(http.ListenAndServe(":8080", gtrace.Instrument(http.DefaultServeMux)))
+}
+
+func ExampleWithBaggage() {
+	ctx := gtrace.WithBaggage(context.Background(), "tenant", "acme")
+
+	// Requests sent with ctx, by tracing HTTP clients, carry the baggage,
+	// as do spans started from ctx, and entries logged with glog.ForContext(ctx).
+	fmt.Println(gtrace.Baggage(ctx))
+	// Output:
+	// map[tenant:acme]
+}
+
+func ExampleTraceURL() {
+	gtrace.ProjectID = "my-project"
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Link the trace in error responses, so it can be reported.
+		http.Error(w, "Internal error, trace: "+gtrace.TraceURL(r.Context()),
+			http.StatusInternalServerError)
+	})
+
+	// A request continuing a trace, as traced handlers serve it.
+	ctx, span := trace.StartSpanWithRemoteParent(context.Background(), "request", trace.SpanContext{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0, 0, 0, 0, 0, 0, 0, 1},
+	})
+	defer span.End()
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	fmt.Print(res.Body)
+	// Output:
+	// Internal error, trace: https://console.cloud.google.com/traces/list?project=my-project&tid=4bf92f3577b34da6a3ce929d0e0e4736
+}
+
+func ExampleInjectPubSub() {
+	ctx, span := gtrace.StartSpan(context.Background(), "publish")
+	defer span.End(nil)
+
+	attrs := map[string]string{}
+	gtrace.InjectPubSub(ctx, attrs)
+
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Println(keys)
+
+	// The subscriber continues the publisher's trace.
+	sub := gtrace.ExtractPubSub(context.Background(), attrs)
+	fmt.Println(gtrace.TraceID(sub) == gtrace.TraceID(ctx))
+	// Output:
+	// [googclient_traceparent googclient_x-cloud-trace-context]
+	// true
+}
+
+func ExampleInjectCloudTasks() {
+	ctx, span := gtrace.StartSpan(context.Background(), "enqueue")
+	defer span.End(nil)
+
+	// Set these as the headers of the task's HTTP request.
+	headers := map[string]string{"Content-Type": "application/json"}
+	gtrace.InjectCloudTasks(ctx, headers)
+
+	// The task handler continues the trace.
+	req, _ := http.NewRequest("POST", "/task", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	task := gtrace.ExtractCloudTasks(req)
+	fmt.Println(gtrace.TraceID(task) == gtrace.TraceID(ctx))
+	// Output:
+	// true
+}
+
+func ExampleAnnotate() {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		gtrace.Annotate(r.Context(), "cache miss", gtrace.String("key", r.URL.Path))
+		http.NotFound(w, r)
+	})
+
+	glog.Critical(http.ListenAndServe(":8080", gtrace.NewHTTPHandler()))
+}
+
+func ExampleRouteTemplates() {
+	format := gtrace.RouteTemplates("/users/{id}", "/static/{file...}")
+
+	for _, path := range []string{"/users/42", "/static/css/site.css", "/about"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		fmt.Println(format(req))
+	}
+	// Output:
+	// /users/{id}
+	// /static/{file...}
+	// /about
+}
+
+func ExampleHealthChecks() {
+	// Assign it to gtrace.IsHealthCheck, before creating traced handlers,
+	// so health checks aren't traced.
+	isHealthCheck := gtrace.HealthChecks("/healthz")
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/healthz", nil),
+		httptest.NewRequest("GET", "/users", nil),
+	} {
+		fmt.Println(req.URL.Path, isHealthCheck(req))
+	}
+
+	// Probes are recognized by their user agent.
+	probe := httptest.NewRequest("GET", "/", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.29")
+	fmt.Println(probe.UserAgent(), isHealthCheck(probe))
+	// Output:
+	// /healthz true
+	// /users false
+	// kube-probe/1.29 true
+}
+
+func ExampleStartDetachedSpan() {
+	ctx, span := gtrace.StartSpan(context.Background(), "request")
+	defer span.End(nil)
+
+	// The background work starts a new trace, linked to the request.
+	bg, work := gtrace.StartDetachedSpan(ctx, "background")
+	defer work.End(nil)
+
+	fmt.Println(gtrace.TraceID(bg) != gtrace.TraceID(ctx))
+	// Output:
+	// true
+}
+
+func ExampleForceSampling() {
+	ctx := gtrace.ForceSampling(context.Background())
+
+	ctx, span := gtrace.StartSpan(ctx, "reproduction")
+	defer span.End(nil)
+
+	fmt.Println(trace.FromContext(ctx).SpanContext().IsSampled())
+	// Output:
+	// true
+}
+
+func ExampleWrapHTTPHandler() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/", http.NotFound)
+
+	handler := gtrace.WrapHTTPHandler(mux,
+		gtrace.WithPublicEndpoint(true),
+		gtrace.WithSpanNameFormatter(gtrace.RouteTemplates("/users/{id}")))
+
+	glog.Critical(http.ListenAndServe(":8080", handler))
+}
+
+func ExampleDroppedSpans() {
+	gtrace.SetBatching(8192, 512, 5*time.Second)
+	go gtrace.InitOpenTelemetry()
+
+	go func() {
+		for range time.Tick(time.Minute) {
+			if n := gtrace.DroppedSpans(); n > 0 {
+				glog.Warningf("Dropped %d spans.", n)
+			}
+		}
+	}()
+
+	http.HandleFunc("/", http.NotFound)
+
+	glog.Critical(http.ListenAndServe(":8080", gtrace.NewOTelHTTPHandler()))
+}
+
+func ExampleMaskParams() {
+	redact := gtrace.MaskParams("token")
+
+	u, _ := url.Parse("https://example.com/api?id=42&token=secret")
+	fmt.Println(redact(u))
+	fmt.Println(gtrace.StripQuery(u))
+	// Output:
+	// https://example.com/api?id=42&token=REDACTED
+	// https://example.com/api
+}
+
+func ExampleNewOTelGRPCServerHandler() {
+	go gtrace.InitOpenTelemetry()
+
+	// Accepts traces from Google client libraries, Envoy, and Cloud Endpoints.
+	server := grpc.NewServer(grpc.StatsHandler(gtrace.NewOTelGRPCServerHandler()))
+
+	lis, err := net.Listen("tcp", ":8080")
+	if err != nil {
+		glog.Critical(err)
+	}
+	glog.Critical(server.Serve(lis))
+}
+
+func ExampleRetryTransport() {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first couple of attempts.
+		if calls++; calls < 3 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &gtrace.RetryTransport{
+		Base:       gtrace.NewHTTPTransport(),
+		MinBackoff: time.Millisecond,
+	}}
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		glog.Critical(err)
+		return
+	}
+	res.Body.Close()
+	fmt.Println(res.StatusCode, calls)
+	// Output:
+	// 200 3
+}
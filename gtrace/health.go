@@ -0,0 +1,31 @@
+package gtrace
+
+import (
+	"net/http"
+	"strings"
+)
+
+// IsHealthCheck, if set, reports requests that shouldn't be traced
+// (for example, health checks and probes),
+// so they don't dominate dashboards and sampled traces.
+// Set it before creating handlers.
+// Instrument doesn't store a request logger for these requests either.
+var IsHealthCheck func(r *http.Request) bool
+
+// HealthChecks returns a function that reports requests
+// for any of the given paths (like "/healthz"),
+// or from Google Cloud health checkers and Kubernetes probes, as health checks.
+//
+//	gtrace.IsHealthCheck = gtrace.HealthChecks("/healthz", "/readyz")
+func HealthChecks(paths ...string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		for _, p := range paths {
+			if r.URL.Path == p {
+				return true
+			}
+		}
+		ua := r.UserAgent()
+		return strings.HasPrefix(ua, "GoogleHC/") ||
+			strings.HasPrefix(ua, "kube-probe/")
+	}
+}
@@ -0,0 +1,41 @@
+package gtrace
+
+import (
+	"net/http"
+
+	"github.com/ncruces/go-gcp/glog"
+	"go.opencensus.io/plugin/ochttp"
+)
+
+// Instrument returns a tracing http.Handler that serves requests with h,
+// and stores a request glog.Logger, correlated with the request's trace,
+// in the request context (see glog.ForContext).
+// Health checks (see IsHealthCheck) are served untraced,
+// and requests with a DebugHeader are always sampled.
+func Instrument(h http.Handler) http.Handler {
+	inner := baggageHandler(eventHandler(h))
+	traced := lazyHandler(&ochttp.Handler{
+		// Use the Google Cloud propagation formats.
+		Propagation:     &HTTPFormat{},
+		FormatSpanName:  FormatSpanName,
+		GetStartOptions: debugStartOptions,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			log := glog.ForRequest(r)
+			log.SetContext(ctx)
+			inner.ServeHTTP(w, r.WithContext(glog.NewContext(ctx, log)))
+		}),
+	}, initLazily)
+
+	health := IsHealthCheck
+	if health == nil {
+		return traced
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if health(r) {
+			h.ServeHTTP(w, r)
+		} else {
+			traced.ServeHTTP(w, r)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package gtrace
+
+import (
+	"net/http"
+	"sync"
+)
+
+var (
+	lazyOnce     sync.Once
+	lazyOTelOnce sync.Once
+)
+
+// initLazily calls Init in the background, once,
+// so tracing transports and handlers used before Init export spans.
+// Errors are logged, like when Init is called asynchronously.
+func initLazily() {
+	lazyOnce.Do(func() { go Init() })
+}
+
+// initOTelLazily calls InitOpenTelemetry in the background, once.
+func initOTelLazily() {
+	lazyOTelOnce.Do(func() { go InitOpenTelemetry() })
+}
+
+// lazyTransport initializes tracing on first use.
+type lazyTransport struct {
+	base http.RoundTripper
+	init func()
+}
+
+func (t lazyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.init()
+	return t.base.RoundTrip(req)
+}
+
+// lazyHandler initializes tracing on first use.
+func lazyHandler(h http.Handler, init func()) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		init()
+		h.ServeHTTP(w, r)
+	})
+}
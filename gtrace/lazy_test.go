@@ -0,0 +1,62 @@
+package gtrace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// countingInit counts calls, and initializes once, like initLazily.
+type countingInit struct {
+	once  sync.Once
+	calls int
+	inits int
+}
+
+func (c *countingInit) init() {
+	c.calls++
+	c.once.Do(func() { c.inits++ })
+}
+
+func TestLazyHandler(t *testing.T) {
+	var c countingInit
+	var served int
+	h := lazyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.inits != 1 {
+			t.Error("served before initializing")
+		}
+		served++
+	}), c.init)
+
+	if c.calls != 0 {
+		t.Fatal("initialized before first use")
+	}
+	for i := 0; i < 3; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+	if served != 3 || c.calls != 3 || c.inits != 1 {
+		t.Errorf("served %d, with %d calls to init, and %d inits", served, c.calls, c.inits)
+	}
+}
+
+func TestLazyTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var c countingInit
+	client := &http.Client{Transport: lazyTransport{base: http.DefaultTransport, init: c.init}}
+	if c.calls != 0 {
+		t.Fatal("initialized before first use")
+	}
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if c.calls != 2 || c.inits != 1 {
+		t.Errorf("%d calls to init, and %d inits", c.calls, c.inits)
+	}
+}
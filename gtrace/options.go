@@ -0,0 +1,74 @@
+package gtrace
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"contrib.go.opencensus.io/exporter/stackdriver"
+	"contrib.go.opencensus.io/exporter/stackdriver/monitoredresource"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/trace/propagation"
+)
+
+// An Option configures the Cloud Trace exporter created by Init.
+// Options not covered by the helpers below
+// can be set by modifying stackdriver.Options directly.
+type Option func(*stackdriver.Options)
+
+// WithOnError sets the function called when spans fail to export.
+func WithOnError(f func(err error)) Option {
+	return func(o *stackdriver.Options) { o.OnError = f }
+}
+
+// WithBundleDelay sets the maximum time spans are buffered before exporting.
+func WithBundleDelay(d time.Duration) Option {
+	return func(o *stackdriver.Options) { o.BundleDelayThreshold = d }
+}
+
+// WithBundleSize sets the number of spans buffered before exporting.
+func WithBundleSize(n int) Option {
+	return func(o *stackdriver.Options) { o.BundleCountThreshold = n }
+}
+
+// WithBufferSize sets the maximum bytes of spans buffered for export.
+// Spans that don't fit are dropped.
+func WithBufferSize(n int) Option {
+	return func(o *stackdriver.Options) { o.TraceSpansBufferMaxBytes = n }
+}
+
+// WithMonitoredResource sets the monitored resource spans are attributed to.
+func WithMonitoredResource(r monitoredresource.Interface) Option {
+	return func(o *stackdriver.Options) { o.MonitoredResource = r }
+}
+
+// WithContext sets the context used by the exporter's API calls.
+func WithContext(ctx context.Context) Option {
+	return func(o *stackdriver.Options) { o.Context = ctx }
+}
+
+// A HandlerOption configures the tracing http.Handler
+// returned by WrapHTTPHandler.
+// Options not covered by the helpers below
+// can be set by modifying ochttp.Handler directly.
+type HandlerOption func(*ochttp.Handler)
+
+// WithPublicEndpoint sets whether the handler serves a public endpoint.
+// Public endpoints link to incoming traces, instead of continuing them,
+// so callers can't control sampling, or pollute traces.
+func WithPublicEndpoint(public bool) HandlerOption {
+	return func(h *ochttp.Handler) { h.IsPublicEndpoint = public }
+}
+
+// WithSpanNameFormatter sets the function that names request spans
+// (see FormatSpanName and RouteTemplates).
+func WithSpanNameFormatter(f func(r *http.Request) string) HandlerOption {
+	return func(h *ochttp.Handler) { h.FormatSpanName = f }
+}
+
+// WithPropagation sets the format used to propagate incoming traces
+// (for example, &tracecontext.HTTPFormat{} to accept only W3C Trace Context).
+// The default is &HTTPFormat{}.
+func WithPropagation(format propagation.HTTPFormat) HandlerOption {
+	return func(h *ochttp.Handler) { h.Propagation = format }
+}
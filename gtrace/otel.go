@@ -0,0 +1,146 @@
+package gtrace
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	texporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
+	gcppropagator "github.com/GoogleCloudPlatform/opentelemetry-operations-go/propagator"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	ocbridge "go.opentelemetry.io/otel/bridge/opencensus"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var (
+	otelOnce sync.Once
+	provider atomic.Pointer[sdktrace.TracerProvider]
+)
+
+// InitOpenTelemetry initializes Cloud Trace using OpenTelemetry,
+// registering a global TracerProvider that exports to Cloud Trace,
+// and a global propagator that uses both the Cloud Trace,
+// and the W3C Trace Context, formats.
+// Traces are annotated with the Cloud Run service, revision,
+// region, and instance, if available.
+// If no project is detected (for example, in local development),
+// spans are written to the console instead.
+// If the OTEL_EXPORTER_OTLP_ENDPOINT environment variable is set,
+// spans are exported with OTLP over HTTP (for example, to a local
+// OpenTelemetry Collector, Jaeger, or Zipkin) instead of Cloud Trace.
+// OpenCensus spans are bridged to OpenTelemetry,
+// so code using either API contributes to the same traces:
+// this TracerProvider is the only one the bridge is installed for.
+// Can be called multiple times.
+// Logs the error if called asynchronously.
+func InitOpenTelemetry() (err error) {
+	callers := runtime.Callers(3, make([]uintptr, 1))
+
+	otelOnce.Do(func() {
+		var processor sdktrace.SpanProcessor
+		var exporter *texporter.Exporter
+		var ierr error
+		if otlpConfigured() {
+			// Export to a local endpoint, instead of Cloud Trace.
+			processor, ierr = newOTLPProcessor()
+		} else if exporter, ierr = texporter.New(texporter.WithProjectID(ProjectID)); ierr == nil {
+			processor = newQueueProcessor(exporter, batching)
+		} else if ProjectID == "" {
+			// No project detected, export to the console.
+			stdout, cerr := stdouttrace.New(
+				stdouttrace.WithWriter(os.Stderr),
+				stdouttrace.WithPrettyPrint())
+			if cerr == nil {
+				processor = sdktrace.NewSimpleSpanProcessor(stdout)
+				ierr = nil
+			}
+		}
+		if ierr == nil {
+			opts := []sdktrace.TracerProviderOption{
+				sdktrace.WithSpanProcessor(baggageProcessor{}),
+				sdktrace.WithSpanProcessor(redactProcessor{}),
+				sdktrace.WithResource(newResource(resourceAttributes())),
+			}
+			sampler := sdktrace.ParentBased(sdktrace.AlwaysSample())
+			if tailSampling != nil {
+				// Trace everything, decide what to export later.
+				processor = newTailProcessor(processor, *tailSampling)
+				sampler = sdktrace.AlwaysSample()
+			}
+			tp := sdktrace.NewTracerProvider(append(opts,
+				sdktrace.WithSampler(debugSampler{sampler}),
+				sdktrace.WithSpanProcessor(processor))...)
+			otel.SetTracerProvider(tp)
+			provider.Store(tp)
+			// Bridge OpenCensus spans, so both APIs contribute to the same traces.
+			ocbridge.InstallTraceBridge(ocbridge.WithTracerProvider(tp))
+			otel.SetTextMapPropagator(propagator)
+			return
+		}
+		if callers == 0 {
+			json.NewEncoder(os.Stderr).Encode(map[string]string{
+				"message":  ierr.Error(),
+				"severity": "CRITICAL",
+			})
+		}
+		err = ierr
+	})
+
+	return
+}
+
+// propagator propagates traces in both the X-Cloud-Trace-Context,
+// and the W3C Trace Context, formats, and baggage in the W3C Baggage format.
+// When extracting, the last format found wins: prefer W3C Trace Context.
+var propagator = propagation.NewCompositeTextMapPropagator(
+	gcppropagator.CloudTraceFormatPropagator{},
+	propagation.TraceContext{},
+	propagation.Baggage{})
+
+// NewOTelHTTPClient returns an http.Client traced with OpenTelemetry.
+// OpenTelemetry clients, and handlers, call InitOpenTelemetry
+// in the background on first use.
+func NewOTelHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: NewOTelHTTPTransport(),
+	}
+}
+
+// NewOTelHTTPTransport returns an http.RoundTripper traced with OpenTelemetry.
+func NewOTelHTTPTransport() http.RoundTripper {
+	opts := []otelhttp.Option{otelhttp.WithPropagators(propagator)}
+	if format := FormatSpanName; format != nil {
+		opts = append(opts, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return format(r)
+		}))
+	}
+	return lazyTransport{
+		base: otelhttp.NewTransport(http.DefaultTransport, opts...),
+		init: initOTelLazily,
+	}
+}
+
+// NewOTelHTTPHandler returns an http.Handler traced with OpenTelemetry,
+// that serves requests with http.DefaultServeMux.
+// Requests with a DebugHeader are always sampled.
+func NewOTelHTTPHandler() http.Handler {
+	format := FormatSpanName
+	health := IsHealthCheck
+	return lazyHandler(debugHandler(otelhttp.NewHandler(baggageHandler(eventHandler(http.DefaultServeMux)), "http.server",
+		otelhttp.WithPropagators(propagator),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return health == nil || !health(r)
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if format != nil {
+				return format(r)
+			}
+			return r.URL.Path
+		}))), initOTelLazily)
+}
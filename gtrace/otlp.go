@@ -0,0 +1,28 @@
+package gtrace
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// otlpConfigured reports whether an OTLP endpoint is configured
+// (for example, a local OpenTelemetry Collector, Jaeger, or Zipkin),
+// with the standard OTEL_EXPORTER_OTLP_ENDPOINT,
+// or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, environment variables.
+func otlpConfigured() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// newOTLPProcessor returns a processor that exports spans
+// to the configured OTLP endpoint, over HTTP.
+func newOTLPProcessor() (sdktrace.SpanProcessor, error) {
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return newQueueProcessor(exporter, batching), nil
+}
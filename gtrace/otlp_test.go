@@ -0,0 +1,63 @@
+package gtrace
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestOTLPConfigured(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	if otlpConfigured() {
+		t.Error("otlpConfigured() = true, want false")
+	}
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://localhost:4318/v1/traces")
+	if !otlpConfigured() {
+		t.Error("otlpConfigured() = false, want true")
+	}
+}
+
+func TestOTLPProcessor(t *testing.T) {
+	var mtx sync.Mutex
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" {
+			t.Errorf("request = %s %s, want POST /v1/traces", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-protobuf" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		mtx.Lock()
+		bodies = append(bodies, body)
+		mtx.Unlock()
+	}))
+	defer server.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+
+	processor, err := newOTLPProcessor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+
+	ctx := context.Background()
+	_, span := tp.Tracer("test").Start(ctx, "exported-span")
+	span.End()
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(bodies) != 1 || !bytes.Contains(bodies[0], []byte("exported-span")) {
+		t.Errorf("exported %q, want exported-span", bodies)
+	}
+}
@@ -0,0 +1,43 @@
+package gtrace
+
+import (
+	"os"
+	"runtime"
+	"sync"
+
+	"cloud.google.com/go/profiler"
+	"github.com/ncruces/go-gcp/glog"
+)
+
+var profilerOnce sync.Once
+
+// InitProfiler starts the Cloud Profiler agent,
+// with the service and version of the Cloud Run service
+// (or job, or function).
+// Can be called multiple times.
+// Logs the error if called asynchronously.
+func InitProfiler() (err error) {
+	callers := runtime.Callers(3, make([]uintptr, 1))
+
+	profilerOnce.Do(func() {
+		service := os.Getenv("K_SERVICE")
+		if service == "" {
+			service = os.Getenv("CLOUD_RUN_JOB")
+		}
+
+		ierr := profiler.Start(profiler.Config{
+			ProjectID:      ProjectID,
+			Service:        service,
+			ServiceVersion: os.Getenv("K_REVISION"),
+		})
+		if ierr == nil {
+			return
+		}
+		if callers == 0 {
+			glog.Critical(ierr)
+		}
+		err = ierr
+	})
+
+	return
+}
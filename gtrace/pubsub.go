@@ -0,0 +1,84 @@
+package gtrace
+
+import (
+	"context"
+	"strings"
+
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// InjectPubSub adds the trace context (and baggage) in ctx
+// to the attributes of a Pub/Sub message,
+// so subscribers can continue the publisher's trace (see ExtractPubSub).
+// Attributes are prefixed with "googclient_",
+// like the Pub/Sub client libraries do.
+func InjectPubSub(ctx context.Context, attrs map[string]string) {
+	propagator.Inject(otelContext(ctx), pubsubCarrier(attrs))
+}
+
+// ExtractPubSub returns a copy of ctx with the trace context (and baggage)
+// from the attributes of a Pub/Sub message (see InjectPubSub).
+// Spans started from the returned context (see StartSpan)
+// continue the publisher's trace.
+func ExtractPubSub(ctx context.Context, attrs map[string]string) context.Context {
+	return propagator.Extract(ctx, pubsubCarrier(attrs))
+}
+
+// pubsubCarrier carries trace context in Pub/Sub message attributes.
+type pubsubCarrier map[string]string
+
+const pubsubPrefix = "googclient_"
+
+func (c pubsubCarrier) Get(key string) string {
+	return c[pubsubPrefix+key]
+}
+
+func (c pubsubCarrier) Set(key, value string) {
+	c[pubsubPrefix+key] = value
+}
+
+func (c pubsubCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		if key, ok := strings.CutPrefix(k, pubsubPrefix); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+var _ propagation.TextMapCarrier = pubsubCarrier(nil)
+
+// otelContext returns a copy of ctx with the OpenCensus span in ctx,
+// if any, as an OpenTelemetry span context, so it can be propagated.
+func otelContext(ctx context.Context) context.Context {
+	if oteltrace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	if span := trace.FromContext(ctx); span != nil {
+		sc := span.SpanContext()
+		return oteltrace.ContextWithSpanContext(ctx,
+			oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+				TraceID:    oteltrace.TraceID(sc.TraceID),
+				SpanID:     oteltrace.SpanID(sc.SpanID),
+				TraceFlags: oteltrace.TraceFlags(sc.TraceOptions),
+			}))
+	}
+	return ctx
+}
+
+// remoteParent returns the remote OpenTelemetry span context in ctx,
+// if any, as an OpenCensus span context, so it can be continued.
+func remoteParent(ctx context.Context) (trace.SpanContext, bool) {
+	sc := oteltrace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsRemote() {
+		return trace.SpanContext{}, false
+	}
+	return trace.SpanContext{
+		TraceID:      trace.TraceID(sc.TraceID()),
+		SpanID:       trace.SpanID(sc.SpanID()),
+		TraceOptions: trace.TraceOptions(sc.TraceFlags()),
+	}, true
+}
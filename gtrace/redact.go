@@ -0,0 +1,119 @@
+package gtrace
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// RedactURL, if set, sanitizes the URLs (and query strings)
+// recorded as span attributes by the tracing HTTP clients and handlers,
+// so tokens and personal data in URLs aren't exported.
+// Set it before calling Init or InitOpenTelemetry.
+//
+//	gtrace.RedactURL = gtrace.MaskParams("token", "email")
+var RedactURL func(u *url.URL) string
+
+// StripQuery returns u without its query string.
+func StripQuery(u *url.URL) string {
+	c := *u
+	c.RawQuery = ""
+	c.ForceQuery = false
+	return c.String()
+}
+
+// MaskParams returns a function that masks
+// the values of the given query parameters of URLs.
+func MaskParams(params ...string) func(u *url.URL) string {
+	return func(u *url.URL) string {
+		query := u.Query()
+		masked := false
+		for _, p := range params {
+			if vs, ok := query[p]; ok {
+				for i := range vs {
+					vs[i] = "REDACTED"
+				}
+				masked = true
+			}
+		}
+		if !masked {
+			return u.String()
+		}
+		c := *u
+		c.RawQuery = query.Encode()
+		return c.String()
+	}
+}
+
+// redact sanitizes the value of a URL attribute with RedactURL.
+func redact(key, value string) (string, bool) {
+	redact := RedactURL
+	if redact == nil {
+		return value, false
+	}
+	switch key {
+	case "http.url", "http.target", "url.full":
+		u, err := url.Parse(value)
+		if err != nil {
+			return "", true
+		}
+		return redact(u), true
+	case "url.query":
+		u := url.URL{RawQuery: value}
+		_, query, _ := strings.Cut(redact(&u), "?")
+		return query, true
+	}
+	return value, false
+}
+
+// redactExporter sanitizes OpenCensus span attributes, before exporting.
+// Registered exporters are compared by value,
+// so redactExporter{e} can be unregistered.
+type redactExporter struct {
+	trace.Exporter
+}
+
+func (e redactExporter) ExportSpan(s *trace.SpanData) {
+	var attrs map[string]any
+	for k, v := range s.Attributes {
+		if v, ok := v.(string); ok {
+			if v, ok := redact(k, v); ok {
+				if attrs == nil {
+					attrs = make(map[string]any, len(s.Attributes))
+					for k, v := range s.Attributes {
+						attrs[k] = v
+					}
+				}
+				attrs[k] = v
+			}
+		}
+	}
+	if attrs != nil {
+		c := *s
+		c.Attributes = attrs
+		s = &c
+	}
+	e.Exporter.ExportSpan(s)
+}
+
+// redactProcessor sanitizes OpenTelemetry span attributes,
+// set when spans are started.
+type redactProcessor struct{}
+
+func (redactProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	for _, kv := range s.Attributes() {
+		if kv.Value.Type() == attribute.STRING {
+			if v, ok := redact(string(kv.Key), kv.Value.AsString()); ok {
+				s.SetAttributes(attribute.String(string(kv.Key), v))
+			}
+		}
+	}
+}
+
+func (redactProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (redactProcessor) Shutdown(context.Context) error   { return nil }
+func (redactProcessor) ForceFlush(context.Context) error { return nil }
@@ -0,0 +1,75 @@
+package gtrace
+
+import (
+	"os"
+
+	"github.com/ncruces/go-gcp/gmeta"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// resourceAttributes detects the Cloud Run service (or job, or function),
+// revision, region, and instance, from the environment,
+// and the metadata server,
+// so traces can be filtered by service and revision.
+func resourceAttributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	add := func(key attribute.Key, value string) {
+		if value != "" {
+			attrs = append(attrs, key.String(value))
+		}
+	}
+
+	switch {
+	case os.Getenv("FUNCTION_TARGET") != "":
+		add(semconv.CloudPlatformKey, semconv.CloudPlatformGCPCloudFunctions.Value.AsString())
+		add(semconv.ServiceNameKey, os.Getenv("K_SERVICE"))
+		add(semconv.ServiceVersionKey, os.Getenv("K_REVISION"))
+	case os.Getenv("K_SERVICE") != "":
+		add(semconv.CloudPlatformKey, semconv.CloudPlatformGCPCloudRun.Value.AsString())
+		add(semconv.ServiceNameKey, os.Getenv("K_SERVICE"))
+		add(semconv.ServiceVersionKey, os.Getenv("K_REVISION"))
+	case os.Getenv("CLOUD_RUN_JOB") != "":
+		add(semconv.CloudPlatformKey, semconv.CloudPlatformGCPCloudRun.Value.AsString())
+		add(semconv.ServiceNameKey, os.Getenv("CLOUD_RUN_JOB"))
+		add(semconv.FaaSInvocationIDKey, os.Getenv("CLOUD_RUN_EXECUTION"))
+	default:
+		return attrs
+	}
+	add(semconv.CloudProviderKey, semconv.CloudProviderGCP.Value.AsString())
+	if ProjectID != "" {
+		add(semconv.CloudAccountIDKey, ProjectID)
+	} else if id, err := gmeta.ProjectID(); err == nil {
+		add(semconv.CloudAccountIDKey, id)
+	}
+	if region, err := gmeta.Region(); err == nil {
+		add(semconv.CloudRegionKey, region)
+	}
+	if id, err := gmeta.InstanceID(); err == nil {
+		add(semconv.FaaSInstanceKey, id)
+	}
+	return attrs
+}
+
+func newResource(attrs []attribute.KeyValue) *resource.Resource {
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, attrs...))
+	if err != nil {
+		return resource.Default()
+	}
+	return res
+}
+
+// traceAttributes converts resource attributes into
+// default attributes for OpenCensus spans.
+func traceAttributes(attrs []attribute.KeyValue) map[string]interface{} {
+	if len(attrs) == 0 {
+		return nil
+	}
+	res := make(map[string]interface{}, len(attrs))
+	for _, kv := range attrs {
+		res[string(kv.Key)] = kv.Value.AsString()
+	}
+	return res
+}
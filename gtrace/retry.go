@@ -0,0 +1,263 @@
+package gtrace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// A RetryTransport is an http.RoundTripper that retries
+// idempotent requests that fail with transient errors,
+// with exponential backoff, and optionally hedges slow requests.
+// Each attempt is recorded as a child span,
+// with its attempt number, and backoff.
+//
+// Requests are idempotent if their method is,
+// or if they have an Idempotency-Key header.
+// Requests with a body are retried only if they set GetBody.
+type RetryTransport struct {
+	// Base sends each attempt (for example, NewHTTPTransport()).
+	// If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// MaxAttempts is the maximum number of attempts, the default is 3.
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff bound the backoff between attempts,
+	// the defaults are 100ms and 5s.
+	MinBackoff, MaxBackoff time.Duration
+
+	// HedgeAfter, if positive, starts another attempt
+	// if the previous one hasn't completed after that long,
+	// and uses the first to complete.
+	HedgeAfter time.Duration
+}
+
+type attempt struct {
+	res   *http.Response
+	err   error
+	idx   int
+	retry bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	max := t.MaxAttempts
+	if max == 0 {
+		max = 3
+	}
+	if max <= 1 || !replayable(req) {
+		return t.base().RoundTrip(req)
+	}
+	if req.Body != nil {
+		// Each attempt gets its own body.
+		req.Body.Close()
+	}
+
+	ctx := req.Context()
+	results := make(chan attempt, max)
+	var cancels []context.CancelFunc
+	var pending int
+
+	send := func(backoff time.Duration, hedged bool) {
+		actx, cancel := context.WithCancel(ctx)
+		idx := len(cancels)
+		cancels = append(cancels, cancel)
+		pending++
+
+		go func() {
+			actx, span := StartSpan(actx, "http.attempt",
+				Int("http.attempt", int64(idx+1)),
+				Int("http.backoff_ms", backoff.Milliseconds()),
+				Bool("http.hedged", hedged))
+
+			var res *http.Response
+			r, err := cloneRequest(actx, req)
+			if err == nil {
+				res, err = t.base().RoundTrip(r)
+			}
+			retry := retriable(res, err)
+			if res != nil {
+				span.SetAttributes(Int("http.status_code", int64(res.StatusCode)))
+				if retry {
+					span.End(errors.New(res.Status))
+				} else {
+					span.End(nil)
+				}
+			} else {
+				span.End(err)
+			}
+			results <- attempt{res, err, idx, retry}
+		}()
+	}
+
+	// done returns the result of an attempt,
+	// canceling (and draining) the others.
+	done := func(a attempt) (*http.Response, error) {
+		for i, cancel := range cancels {
+			if i != a.idx {
+				cancel()
+			}
+		}
+		go func(pending int) {
+			for ; pending > 0; pending-- {
+				if a := <-results; a.res != nil {
+					a.res.Body.Close()
+				}
+			}
+		}(pending)
+
+		cancel := cancels[a.idx]
+		if a.res == nil {
+			cancel()
+			return nil, a.err
+		}
+		a.res.Body = cancelBody{a.res.Body, cancel}
+		return a.res, nil
+	}
+
+	backoff := t.minBackoff()
+	send(0, false)
+
+	var last attempt
+	for {
+		var hedge <-chan time.Time
+		var timer *time.Timer
+		if t.HedgeAfter > 0 && len(cancels) < max {
+			timer = time.NewTimer(t.HedgeAfter)
+			hedge = timer.C
+		}
+
+		select {
+		case <-hedge:
+			send(0, true)
+			continue
+		case a := <-results:
+			if timer != nil {
+				timer.Stop()
+			}
+			pending--
+			if last.res != nil {
+				last.res.Body.Close()
+			}
+			if !a.retry {
+				return done(a)
+			}
+			last = a
+		}
+
+		if pending > 0 {
+			// Wait for hedged attempts.
+			continue
+		}
+		if len(cancels) == max {
+			// Out of attempts.
+			return done(last)
+		}
+
+		timer = time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if last.res != nil {
+				last.res.Body.Close()
+			}
+			cancels[last.idx]()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		send(backoff, false)
+		backoff = min(2*backoff, t.maxBackoff())
+	}
+}
+
+func (t *RetryTransport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *RetryTransport) minBackoff() time.Duration {
+	if t.MinBackoff <= 0 {
+		return 100 * time.Millisecond
+	}
+	return t.MinBackoff
+}
+
+func (t *RetryTransport) maxBackoff() time.Duration {
+	if t.MaxBackoff <= 0 {
+		return 5 * time.Second
+	}
+	return t.MaxBackoff
+}
+
+// replayable reports whether req can be safely retried.
+func replayable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" ||
+		req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// retriable reports whether an attempt failed with a transient error.
+// Permanent errors, like invalid URLs, unknown hosts,
+// or failed certificate verification, aren't retried.
+func retriable(res *http.Response, err error) bool {
+	if err != nil {
+		var nerr net.Error
+		switch {
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			return false
+		case errors.As(err, &nerr) && nerr.Timeout():
+			return true
+		}
+		return errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, syscall.ECONNABORTED) ||
+			errors.Is(err, syscall.EPIPE) ||
+			errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, io.EOF)
+	}
+	switch res.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func cloneRequest(ctx context.Context, req *http.Request) (*http.Request, error) {
+	r := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// cancelBody cancels the context of an attempt, once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,177 @@
+package gtrace
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport_hedge(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt hangs, until it's canceled.
+		if calls.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("hedged"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &RetryTransport{HedgeAfter: 50 * time.Millisecond}}
+	start := time.Now()
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if string(body) != "hedged" || calls.Load() != 2 {
+		t.Errorf("got %q, after %d attempts", body, calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("hedged request took %v", elapsed)
+	}
+}
+
+func TestRetryTransport_body(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	// Bodies are replayed with GetBody, which NewRequest sets.
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Idempotency-Key", "key")
+
+	client := &http.Client{Transport: &RetryTransport{MinBackoff: time.Millisecond}}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK || len(bodies) != 3 {
+		t.Fatalf("got status %d, after %d attempts", res.StatusCode, len(bodies))
+	}
+	for i, body := range bodies {
+		if body != "payload" {
+			t.Errorf("attempt %d got body %q", i+1, body)
+		}
+	}
+}
+
+func TestRetryTransport_notReplayable(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "try again", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &RetryTransport{MinBackoff: time.Millisecond}}
+
+	// Non-idempotent methods, and bodies without GetBody, pass through.
+	post, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	noGetBody, _ := http.NewRequest(http.MethodPut, server.URL, nil)
+	noGetBody.Body = io.NopCloser(strings.NewReader("payload"))
+
+	for _, req := range []*http.Request{post, noGetBody} {
+		calls = 0
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusServiceUnavailable || calls != 1 {
+			t.Errorf("%s got status %d, after %d attempts", req.Method, res.StatusCode, calls)
+		}
+	}
+}
+
+func TestRetryTransport_canceled(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "try again", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	// Canceling the request interrupts the backoff.
+	client := &http.Client{Transport: &RetryTransport{MinBackoff: time.Hour}}
+	start := time.Now()
+	_, err := client.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("canceled request took %v", elapsed)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("got %d attempts, want 1", n)
+	}
+}
+
+func Test_retriable(t *testing.T) {
+	opError := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://example.com", Err: &net.OpError{
+			Op: "read", Net: "tcp", Err: os.NewSyscallError("read", err)}}
+	}
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{opError(syscall.ECONNRESET), true},
+		{opError(syscall.ECONNREFUSED), true},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{&net.DNSError{Err: "timeout", Name: "example.com", IsTimeout: true}, true},
+		{&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}, false},
+		{&url.Error{Op: "Get", URL: "ftp://example.com", Err: errors.New("unsupported protocol scheme")}, false},
+		{&url.Error{Op: "Get", URL: "https://example.com", Err: x509.UnknownAuthorityError{}}, false},
+		{context.Canceled, false},
+		{&url.Error{Op: "Get", URL: "http://example.com", Err: context.DeadlineExceeded}, false},
+	}
+	for _, tt := range tests {
+		if got := retriable(nil, tt.err); got != tt.want {
+			t.Errorf("retriable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+
+	for status, want := range map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusBadRequest:          false,
+		http.StatusTooManyRequests:     true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusInternalServerError: true,
+	} {
+		if got := retriable(&http.Response{StatusCode: status}, nil); got != want {
+			t.Errorf("retriable(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package gtrace
+
+import (
+	"net/http"
+	"strings"
+)
+
+// FormatSpanName, if set, names the spans of requests
+// sent and served by the tracing HTTP clients and handlers.
+// Set it before creating clients and handlers.
+//
+// Use it to name spans after route templates (see RouteTemplates),
+// so Cloud Trace aggregates requests by endpoint, instead of by URL.
+var FormatSpanName func(r *http.Request) string
+
+// RouteTemplates returns a function that names requests after
+// the first template that matches the request's path,
+// or the path itself, if none matches.
+//
+// In templates, a {name} segment matches any path segment,
+// and a final {name...} segment matches the remainder of the path:
+//
+//	gtrace.FormatSpanName = gtrace.RouteTemplates("/users/{id}", "/static/{file...}")
+func RouteTemplates(templates ...string) func(r *http.Request) string {
+	routes := make([][]string, len(templates))
+	for i, t := range templates {
+		routes[i] = strings.Split(t, "/")
+	}
+
+	return func(r *http.Request) string {
+		path := strings.Split(r.URL.Path, "/")
+		for i, route := range routes {
+			if matchRoute(route, path) {
+				return templates[i]
+			}
+		}
+		return r.URL.Path
+	}
+}
+
+func matchRoute(route, path []string) bool {
+	for i, seg := range route {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "...}") {
+			return i == len(route)-1 && i < len(path)
+		}
+		if i >= len(path) {
+			return false
+		}
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if path[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != path[i] {
+			return false
+		}
+	}
+	return len(route) == len(path)
+}
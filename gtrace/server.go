@@ -0,0 +1,21 @@
+package gtrace
+
+import (
+	"context"
+	"net/http"
+)
+
+// ShutdownServer gracefully shuts down server,
+// waiting for requests in flight to complete (see http.Server.Shutdown),
+// and then tracing (see Shutdown), so their spans are exported.
+//
+// Call it when the instance is shutting down, with a context that
+// expires within the grace period: Cloud Run sends SIGTERM
+// 10 seconds before shutting an instance down.
+func ShutdownServer(ctx context.Context, server *http.Server) error {
+	err := server.Shutdown(ctx)
+	if serr := Shutdown(ctx); err == nil {
+		err = serr
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package gtrace_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ncruces/go-gcp/glog"
+	"github.com/ncruces/go-gcp/gtrace"
+)
+
+func TestShutdownServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var completed atomic.Bool
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+		completed.Store(true)
+	})}
+	go server.Serve(ln)
+
+	// A request in flight completes before shutdown returns.
+	res := make(chan error, 1)
+	go func() {
+		r, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			r.Body.Close()
+		}
+		res <- err
+	}()
+	<-started
+
+	if err := gtrace.ShutdownServer(context.Background(), server); err != nil {
+		t.Fatal(err)
+	}
+	if !completed.Load() {
+		t.Error("ShutdownServer() returned before the request in flight completed")
+	}
+	if err := <-res; err != nil {
+		t.Errorf("request in flight failed: %v", err)
+	}
+}
+
+func ExampleShutdownServer() {
+	go gtrace.Init()
+
+	server := &http.Server{Addr: ":8080", Handler: gtrace.NewHTTPHandler()}
+
+	// Cloud Run sends SIGTERM 10 seconds before shutting an instance down.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGTERM)
+		<-sig
+
+		ctx, cancel := context.WithTimeout(context.Background(), 9*time.Second)
+		defer cancel()
+		if err := gtrace.ShutdownServer(ctx, server); err != nil {
+			glog.Error(err)
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		glog.Critical(err)
+	}
+	<-done
+}
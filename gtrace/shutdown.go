@@ -0,0 +1,48 @@
+package gtrace
+
+import (
+	"context"
+
+	"go.opencensus.io/trace"
+)
+
+// Flush exports buffered spans,
+// so they aren't lost if the instance is shut down.
+func Flush(ctx context.Context) error {
+	if exporter := exporter.Load(); exporter != nil {
+		done := make(chan struct{})
+		go func() {
+			exporter.Flush()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if provider := provider.Load(); provider != nil {
+		return provider.ForceFlush(ctx)
+	}
+	return nil
+}
+
+// Shutdown exports buffered spans, and stops exporting spans.
+// Call it when the instance is shutting down
+// (for example, on SIGTERM in Cloud Run),
+// so the last spans aren't dropped.
+func Shutdown(ctx context.Context) error {
+	err := Flush(ctx)
+	if exporter := exporter.Load(); exporter != nil {
+		trace.UnregisterExporter(redactExporter{exporter})
+	}
+	if console := console.Load(); console != nil {
+		trace.UnregisterExporter(redactExporter{console})
+	}
+	if provider := provider.Load(); provider != nil {
+		if serr := provider.Shutdown(ctx); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package gtrace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// useProvider makes tp the provider Flush and Shutdown act on,
+// until the test ends.
+func useProvider(t *testing.T, tp *sdktrace.TracerProvider) {
+	old := provider.Swap(tp)
+	t.Cleanup(func() { provider.Store(old) })
+}
+
+func TestFlush(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	// Batch spans for longer than the test takes.
+	processor := newQueueProcessor(exporter, batchConfig{queue: 16, batch: 16, interval: time.Hour})
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	useProvider(t, tp)
+
+	ctx := context.Background()
+	_, span := tp.Tracer("test").Start(ctx, "span")
+	span.End()
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Fatalf("exported %d spans before Flush, want 0", n)
+	}
+
+	if err := Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if spans := exporter.GetSpans(); len(spans) != 1 || spans[0].Name != "span" {
+		t.Errorf("exported %v after Flush, want span", spans)
+	}
+}
+
+// keepingExporter keeps exported spans after Shutdown,
+// unlike tracetest.InMemoryExporter, which resets them.
+type keepingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (keepingExporter) Shutdown(context.Context) error { return nil }
+
+func TestShutdown(t *testing.T) {
+	exporter := keepingExporter{tracetest.NewInMemoryExporter()}
+	processor := newQueueProcessor(exporter, batchConfig{queue: 16, batch: 16, interval: time.Hour})
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	useProvider(t, tp)
+
+	ctx := context.Background()
+	_, span := tp.Tracer("test").Start(ctx, "before")
+	span.End()
+
+	if err := Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if spans := exporter.GetSpans(); len(spans) != 1 || spans[0].Name != "before" {
+		t.Errorf("exported %v on Shutdown, want before", spans)
+	}
+
+	// Spans ended after Shutdown aren't exported.
+	_, span = tp.Tracer("test").Start(ctx, "after")
+	span.End()
+	Flush(ctx)
+	if n := len(exporter.GetSpans()); n != 1 {
+		t.Errorf("exported %d spans after Shutdown, want 1", n)
+	}
+}
@@ -0,0 +1,161 @@
+package gtrace
+
+import (
+	"context"
+
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// An Attribute is a key/value pair describing a Span.
+type Attribute struct {
+	key   string
+	value any
+}
+
+// String returns a string Attribute.
+func String(key, value string) Attribute { return Attribute{key, value} }
+
+// Int returns an integer Attribute.
+func Int(key string, value int64) Attribute { return Attribute{key, value} }
+
+// Float returns a floating point Attribute.
+func Float(key string, value float64) Attribute { return Attribute{key, value} }
+
+// Bool returns a boolean Attribute.
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// A Span traces a unit of work.
+//
+// Spans are recorded with OpenTelemetry if InitOpenTelemetry was called,
+// and with OpenCensus otherwise.
+type Span struct {
+	oc *trace.Span
+	ot oteltrace.Span
+}
+
+// StartSpan starts a child span of the span in ctx (if any),
+// returning a context with the new span.
+// Baggage in ctx (see WithBaggage) is set as attributes on the span.
+// Root spans started in Cloud Functions are annotated with
+// the triggering event's ID, type, and source.
+// Call End to end the span:
+//
+//	ctx, span := gtrace.StartSpan(ctx, "work")
+//	defer func() { span.End(err) }()
+func StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if !oteltrace.SpanContextFromContext(ctx).IsValid() && trace.FromContext(ctx) == nil {
+		// A root span: describe the event that triggered the function, if any.
+		attrs = append(eventAttributes(ctx), attrs...)
+	}
+
+	var s Span
+	if provider.Load() != nil {
+		ctx, s.ot = otel.Tracer("github.com/ncruces/go-gcp/gtrace").Start(ctx, name)
+	} else {
+		var opts []trace.StartOption
+		if forcedSampling(ctx) {
+			opts = append(opts, trace.WithSampler(trace.AlwaysSample()))
+		}
+		if parent, ok := remoteParent(ctx); ok && trace.FromContext(ctx) == nil {
+			ctx, s.oc = trace.StartSpanWithRemoteParent(ctx, name, parent, opts...)
+		} else {
+			ctx, s.oc = trace.StartSpan(ctx, name, opts...)
+		}
+		if kvs := Baggage(ctx); kvs != nil && s.oc.IsRecordingEvents() {
+			s.oc.AddAttributes(ocAttributes(kvs)...)
+		}
+	}
+	s.SetAttributes(attrs...)
+	return ctx, &s
+}
+
+// SetAttributes sets attributes on the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if len(attrs) == 0 {
+		return
+	}
+	if s.ot != nil && s.ot.IsRecording() {
+		s.ot.SetAttributes(otelAttributes(attrs)...)
+	}
+	if s.oc != nil && s.oc.IsRecordingEvents() {
+		s.oc.AddAttributes(openCensusAttributes(attrs)...)
+	}
+}
+
+// AddEvent adds a timestamped annotation to the span
+// (for example, to mark cache hits, retries, or milestones).
+func (s *Span) AddEvent(msg string, attrs ...Attribute) {
+	if s.ot != nil && s.ot.IsRecording() {
+		s.ot.AddEvent(msg, oteltrace.WithAttributes(otelAttributes(attrs)...))
+	}
+	if s.oc != nil && s.oc.IsRecordingEvents() {
+		s.oc.Annotate(openCensusAttributes(attrs), msg)
+	}
+}
+
+// Annotate adds a timestamped annotation to the span in ctx, if any.
+// Does nothing if the span isn't sampled.
+func Annotate(ctx context.Context, msg string, attrs ...Attribute) {
+	var s Span
+	if span := oteltrace.SpanFromContext(ctx); span.SpanContext().IsValid() {
+		s.ot = span
+	} else {
+		s.oc = trace.FromContext(ctx)
+	}
+	s.AddEvent(msg, attrs...)
+}
+
+func otelAttributes(attrs []Attribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.value.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(a.key, v))
+		case int64:
+			kvs = append(kvs, attribute.Int64(a.key, v))
+		case float64:
+			kvs = append(kvs, attribute.Float64(a.key, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(a.key, v))
+		}
+	}
+	return kvs
+}
+
+func openCensusAttributes(attrs []Attribute) []trace.Attribute {
+	ocs := make([]trace.Attribute, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.value.(type) {
+		case string:
+			ocs = append(ocs, trace.StringAttribute(a.key, v))
+		case int64:
+			ocs = append(ocs, trace.Int64Attribute(a.key, v))
+		case float64:
+			ocs = append(ocs, trace.Float64Attribute(a.key, v))
+		case bool:
+			ocs = append(ocs, trace.BoolAttribute(a.key, v))
+		}
+	}
+	return ocs
+}
+
+// End ends the span, recording err (if not nil) as its status.
+func (s *Span) End(err error) {
+	if s.ot != nil {
+		if err != nil {
+			s.ot.RecordError(err)
+			s.ot.SetStatus(codes.Error, err.Error())
+		}
+		s.ot.End()
+	}
+	if s.oc != nil {
+		if err != nil {
+			s.oc.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+		}
+		s.oc.End()
+	}
+}
@@ -0,0 +1,113 @@
+package gtrace
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Spans buffered per trace, before tail sampling gives up on the trace.
+const tailMaxSpans = 1024
+
+var tailSampling *tailConfig
+
+type tailConfig struct {
+	latency  time.Duration
+	fraction float64
+}
+
+// SetTailSampling enables tail-based sampling for OpenTelemetry,
+// and must be called before InitOpenTelemetry.
+//
+// Every request is traced, but the spans of each trace are buffered
+// until the trace's local spans end, and the trace is exported only if
+// any span failed (see Span.End), any span took longer than latency,
+// or with probability fraction (a background sample).
+// This keeps Cloud Trace costs down without missing interesting traces.
+func SetTailSampling(latency time.Duration, fraction float64) {
+	tailSampling = &tailConfig{latency: latency, fraction: fraction}
+}
+
+// tailProcessor buffers the spans of each trace,
+// and forwards them to the next processor if the trace is kept.
+type tailProcessor struct {
+	tailConfig
+	next sdktrace.SpanProcessor
+
+	mtx    sync.Mutex
+	traces map[oteltrace.TraceID]*tailTrace
+}
+
+type tailTrace struct {
+	spans []sdktrace.ReadOnlySpan
+	open  int
+	keep  bool
+}
+
+func newTailProcessor(next sdktrace.SpanProcessor, config tailConfig) *tailProcessor {
+	return &tailProcessor{
+		tailConfig: config,
+		next:       next,
+		traces:     map[oteltrace.TraceID]*tailTrace{},
+	}
+}
+
+func (p *tailProcessor) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(ctx, s)
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	id := s.SpanContext().TraceID()
+	t := p.traces[id]
+	if t == nil {
+		t = &tailTrace{}
+		p.traces[id] = t
+	}
+	if forcedSampling(ctx) {
+		t.keep = true
+	}
+	t.open++
+}
+
+func (p *tailProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.mtx.Lock()
+	id := s.SpanContext().TraceID()
+	t := p.traces[id]
+	if t == nil {
+		// Started before tail sampling, or given up on.
+		p.mtx.Unlock()
+		return
+	}
+
+	t.open--
+	t.spans = append(t.spans, s)
+	if s.Status().Code == codes.Error || s.EndTime().Sub(s.StartTime()) > p.latency {
+		t.keep = true
+	}
+	if t.open > 0 && len(t.spans) < tailMaxSpans {
+		// Wait for the trace's local spans to end.
+		p.mtx.Unlock()
+		return
+	}
+	delete(p.traces, id)
+	p.mtx.Unlock()
+
+	if t.keep || rand.Float64() < p.fraction {
+		for _, s := range t.spans {
+			p.next.OnEnd(s)
+		}
+	}
+}
+
+func (p *tailProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+func (p *tailProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
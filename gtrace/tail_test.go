@@ -0,0 +1,101 @@
+package gtrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func newTailProvider(config tailConfig) (oteltrace.Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSpanProcessor(newTailProcessor(sdktrace.NewSimpleSpanProcessor(exporter), config)))
+	return tp.Tracer("test"), exporter
+}
+
+func TestTailSampling(t *testing.T) {
+	tracer, exporter := newTailProvider(tailConfig{latency: time.Second})
+	ctx := context.Background()
+	start := time.Now()
+
+	tests := []struct {
+		name  string
+		child func(oteltrace.Span)
+		keep  bool
+	}{
+		{"ok", func(s oteltrace.Span) {
+			s.End()
+		}, false},
+		{"error", func(s oteltrace.Span) {
+			s.SetStatus(codes.Error, "failed")
+			s.End()
+		}, true},
+		{"slow", func(s oteltrace.Span) {
+			s.End(oteltrace.WithTimestamp(start.Add(2 * time.Second)))
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			ctx, root := tracer.Start(ctx, "root", oteltrace.WithTimestamp(start))
+			_, child := tracer.Start(ctx, "child", oteltrace.WithTimestamp(start))
+			tt.child(child)
+			if n := len(exporter.GetSpans()); n != 0 {
+				t.Fatalf("exported %d spans before the trace ended", n)
+			}
+			root.End(oteltrace.WithTimestamp(start.Add(time.Millisecond)))
+
+			want := 0
+			if tt.keep {
+				want = 2
+			}
+			if n := len(exporter.GetSpans()); n != want {
+				t.Errorf("exported %d spans, want %d", n, want)
+			}
+		})
+	}
+}
+
+func TestTailSampling_forced(t *testing.T) {
+	tracer, exporter := newTailProvider(tailConfig{latency: time.Hour})
+
+	ctx := ForceSampling(context.Background())
+	_, span := tracer.Start(ctx, "forced")
+	span.End()
+	if n := len(exporter.GetSpans()); n != 1 {
+		t.Errorf("exported %d spans, want 1", n)
+	}
+}
+
+func TestTailSampling_fraction(t *testing.T) {
+	tracer, exporter := newTailProvider(tailConfig{latency: time.Hour, fraction: 1})
+
+	for i := 0; i < 3; i++ {
+		_, span := tracer.Start(context.Background(), "background")
+		span.End()
+	}
+	if n := len(exporter.GetSpans()); n != 3 {
+		t.Errorf("exported %d spans, want 3", n)
+	}
+}
+
+func TestTailSampling_spanEnd(t *testing.T) {
+	// Errors passed to Span.End keep the trace.
+	tracer, exporter := newTailProvider(tailConfig{latency: time.Hour})
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, span := tracer.Start(ctx, "child")
+	(&Span{ot: span}).End(errors.New("failed"))
+	root.End()
+	if n := len(exporter.GetSpans()); n != 2 {
+		t.Errorf("exported %d spans, want 2", n)
+	}
+}
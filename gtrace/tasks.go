@@ -0,0 +1,28 @@
+package gtrace
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// InjectCloudTasks adds the trace context (and baggage) in ctx
+// to the headers of a Cloud Tasks HTTP request,
+// so the task handler can continue the trace of the request
+// that created the task (see ExtractCloudTasks).
+func InjectCloudTasks(ctx context.Context, headers map[string]string) {
+	propagator.Inject(otelContext(ctx), propagation.MapCarrier(headers))
+}
+
+// ExtractCloudTasks returns a copy of the context of a Cloud Tasks request,
+// with the trace context (and baggage) from the task's headers
+// (see InjectCloudTasks).
+// Spans started from the returned context (see StartSpan)
+// continue the trace of the request that created the task.
+//
+// Handlers wrapped by NewHTTPHandler, NewOTelHTTPHandler, or Instrument,
+// already continue the trace.
+func ExtractCloudTasks(r *http.Request) context.Context {
+	return propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+}